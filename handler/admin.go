@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/auth"
+)
+
+// APIKeyResponse is an API key as shown to admins. The stored hash is left out
+// so that listing keys never exposes material for offline guessing.
+type APIKeyResponse struct {
+	ID        string     `json:"id"`
+	TenantID  string     `json:"tenant_id"`
+	Name      string     `json:"name,omitempty"`
+	Plan      string     `json:"plan,omitempty"`
+	Admin     bool       `json:"admin,omitempty"`
+	Prefix    string     `json:"prefix"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IssuedKeyResponse carries a new plaintext key, shown only once, with its details
+type IssuedKeyResponse struct {
+	Key    string         `json:"key"`
+	APIKey APIKeyResponse `json:"api_key"`
+}
+
+// newAPIKeyResponse copies the key's details without its hash
+func newAPIKeyResponse(key *auth.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:        key.ID,
+		TenantID:  key.TenantID,
+		Name:      key.Name,
+		Plan:      key.Plan,
+		Admin:     key.Admin,
+		Prefix:    key.Prefix,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+
+// newIssuedKeyResponse returns the plaintext key with the key's details
+func newIssuedKeyResponse(issued *auth.IssuedKey) IssuedKeyResponse {
+	return IssuedKeyResponse{Key: issued.Key, APIKey: newAPIKeyResponse(issued.APIKey)}
+}
+
+// CreateAPIKeyHandler issues a new API key for a tenant
+func CreateAPIKeyHandler(keys *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req auth.CreateKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		issued, err := keys.Create(c.Request.Context(), req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, newIssuedKeyResponse(issued))
+	}
+}
+
+// ListAPIKeysHandler lists API keys, optionally filtered by the tenant query parameter
+func ListAPIKeysHandler(keys *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := keys.List(c.Request.Context(), c.Query("tenant"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		keysOut := make([]APIKeyResponse, len(list))
+		for i, key := range list {
+			keysOut[i] = newAPIKeyResponse(key)
+		}
+		c.JSON(http.StatusOK, gin.H{"keys": keysOut})
+	}
+}
+
+// RotateAPIKeyHandler replaces an API key with a new one and revokes the original
+func RotateAPIKeyHandler(keys *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		issued, err := keys.Rotate(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, newIssuedKeyResponse(issued))
+	}
+}
+
+// RevokeAPIKeyHandler permanently disables an API key
+func RevokeAPIKeyHandler(keys *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := keys.Revoke(c.Request.Context(), c.Param("id")); err != nil {
+			c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// apiKeyErrorStatus maps key service errors to HTTP status codes
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, auth.ErrKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, auth.ErrKeyRevoked):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAdminRouter(t *testing.T) *gin.Engine {
+	store, err := auth.NewFileKeyStore(filepath.Join(t.TempDir(), "keys.json"))
+	require.NoError(t, err)
+	keys := auth.NewService(store)
+
+	r := gin.Default()
+	r.GET("/admin/keys", ListAPIKeysHandler(keys))
+	r.POST("/admin/keys", CreateAPIKeyHandler(keys))
+	r.POST("/admin/keys/:id/rotate", RotateAPIKeyHandler(keys))
+	r.DELETE("/admin/keys/:id", RevokeAPIKeyHandler(keys))
+	return r
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	r := setupAdminRouter(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/keys", strings.NewReader(`{"tenant_id":"acme","plan":"pro"}`))
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created IssuedKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.Key)
+	assert.NotContains(t, w.Body.String(), `"hash"`)
+	assert.Equal(t, "acme", created.APIKey.TenantID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/admin/keys/"+created.APIKey.ID+"/rotate", nil)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var rotated IssuedKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.NotEqual(t, created.Key, rotated.Key)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/admin/keys/"+rotated.APIKey.ID, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/admin/keys?tenant=acme", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, strings.Count(w.Body.String(), `"revoked_at"`))
+	assert.NotContains(t, w.Body.String(), created.Key)
+	assert.NotContains(t, w.Body.String(), auth.HashKey(created.Key))
+	assert.NotContains(t, w.Body.String(), `"hash"`)
+}
+
+func TestAPIKeyHandlers_Errors(t *testing.T) {
+	r := setupAdminRouter(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/keys", strings.NewReader(`{}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/admin/keys/key_missing/rotate", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/campaign"
+)
+
+// UpdateCampaignStatusRequest moves a campaign through review
+type UpdateCampaignStatusRequest struct {
+	Status campaign.Status `json:"status" binding:"required"`
+}
+
+// ListCampaignsHandler lists the tenant's campaigns, optionally filtered by the
+// status, channel and limit query parameters
+func ListCampaignsHandler(campaigns *campaign.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := campaign.Filter{
+			Status:  campaign.Status(c.Query("status")),
+			Channel: c.Query("channel"),
+		}
+		if limit := c.Query("limit"); limit != "" {
+			parsed, err := strconv.Atoi(limit)
+			if err != nil || parsed < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a non-negative number"})
+				return
+			}
+			filter.Limit = parsed
+		}
+
+		list, err := campaigns.List(c.Request.Context(), filter)
+		if err != nil {
+			c.JSON(campaignErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		if list == nil {
+			list = []*campaign.Campaign{}
+		}
+		c.JSON(http.StatusOK, gin.H{"campaigns": list})
+	}
+}
+
+// GetCampaignHandler returns a single campaign
+func GetCampaignHandler(campaigns *campaign.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		found, err := campaigns.Get(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.JSON(campaignErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, found)
+	}
+}
+
+// UpdateCampaignStatusHandler sets a campaign's status to draft, approved or archived
+func UpdateCampaignStatusHandler(campaigns *campaign.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req UpdateCampaignStatusRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		updated, err := campaigns.SetStatus(c.Request.Context(), c.Param("id"), req.Status)
+		if err != nil {
+			c.JSON(campaignErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// DuplicateCampaignHandler copies a campaign into a new draft
+func DuplicateCampaignHandler(campaigns *campaign.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		duplicate, err := campaigns.Duplicate(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.JSON(campaignErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, duplicate)
+	}
+}
+
+// DeleteCampaignHandler permanently removes a campaign
+func DeleteCampaignHandler(campaigns *campaign.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := campaigns.Delete(c.Request.Context(), c.Param("id")); err != nil {
+			c.JSON(campaignErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// campaignErrorStatus maps campaign service errors to HTTP status codes
+func campaignErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, campaign.ErrCampaignNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, campaign.ErrInvalidStatus):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/campaign"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCampaignRouter(t *testing.T) (*gin.Engine, *campaign.Service) {
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	t.Cleanup(func() { redisClient.Close() })
+	campaigns := campaign.NewService(campaign.NewRedisStore(redisClient))
+
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), tenant.Tenant{ID: c.GetHeader("X-Tenant")}))
+	})
+	r.GET("/campaigns", ListCampaignsHandler(campaigns))
+	r.GET("/campaigns/:id", GetCampaignHandler(campaigns))
+	r.PATCH("/campaigns/:id/status", UpdateCampaignStatusHandler(campaigns))
+	r.POST("/campaigns/:id/duplicate", DuplicateCampaignHandler(campaigns))
+	r.DELETE("/campaigns/:id", DeleteCampaignHandler(campaigns))
+	return r, campaigns
+}
+
+func TestCampaignLifecycle(t *testing.T) {
+	r, campaigns := setupCampaignRouter(t)
+	ctx := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"})
+	created, err := campaigns.Create(ctx, &campaign.Campaign{
+		Product:  "Trail running shoes",
+		Segments: []string{"Outdoor Enthusiasts"},
+		Variants: campaign.Variants([]string{"Run further"}, []string{"Lightweight and tough"}, "Shop now"),
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/campaigns/"+created.ID+"/status", strings.NewReader(`{"status":"approved"}`))
+	req.Header.Set("X-Tenant", "acme")
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"approved"`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/campaigns/"+created.ID+"/duplicate", nil)
+	req.Header.Set("X-Tenant", "acme")
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var duplicate campaign.Campaign
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &duplicate))
+	assert.Equal(t, created.ID, duplicate.DuplicatedFrom)
+	assert.Equal(t, campaign.StatusDraft, duplicate.Status)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/campaigns?status=draft", nil)
+	req.Header.Set("X-Tenant", "acme")
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var list struct {
+		Campaigns []campaign.Campaign `json:"campaigns"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Campaigns, 1)
+	assert.Equal(t, duplicate.ID, list.Campaigns[0].ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/campaigns/"+created.ID, nil)
+	req.Header.Set("X-Tenant", "acme")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/campaigns/"+created.ID, nil)
+	req.Header.Set("X-Tenant", "acme")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCampaignHandlers_Errors(t *testing.T) {
+	r, campaigns := setupCampaignRouter(t)
+	created, err := campaigns.Create(tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"}), &campaign.Campaign{Product: "Mug"})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/campaigns/"+created.ID, nil)
+	req.Header.Set("X-Tenant", "globex")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PATCH", "/campaigns/"+created.ID+"/status", strings.NewReader(`{"status":"published"}`))
+	req.Header.Set("X-Tenant", "acme")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PATCH", "/campaigns/"+created.ID+"/status", strings.NewReader(`{}`))
+	req.Header.Set("X-Tenant", "acme")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/campaigns?limit=lots", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/campaigns", nil)
+	req.Header.Set("X-Tenant", "globex")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"campaigns":[]}`, w.Body.String())
+}
@@ -4,12 +4,55 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/breaker"
+	"github.com/jesee-kuya/blue/internal/health"
 )
 
 func HealthCheck(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// HealthHandler reports the state of each upstream's circuit breaker. The
+// service still answers while an upstream is down, so open breakers mark it
+// degraded rather than failing the check.
+func HealthHandler(breakers *breaker.Group) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		statuses := []breaker.Status{}
+		status := "ok"
+		if breakers != nil {
+			statuses = breakers.Statuses(c.Request.Context())
+			if !breakers.Healthy(c.Request.Context()) {
+				status = "degraded"
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":   status,
+			"breakers": statuses,
+		})
+	}
+}
+
+// LivenessHandler reports that the process is running. It checks no
+// dependencies so a dependency outage never gets the process restarted.
+func LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// ReadinessHandler runs the component checks and responds 503 while a critical
+// component is down so load balancers stop routing traffic to the instance
+func ReadinessHandler(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checker.Run(c.Request.Context())
+
+		code := http.StatusOK
+		if !report.Ready() {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, report)
+	}
+}
+
 func SearchHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{})
 }
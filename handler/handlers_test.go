@@ -1,12 +1,18 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/breaker"
+	"github.com/jesee-kuya/blue/internal/health"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupRouter() *gin.Engine {
@@ -26,6 +32,76 @@ func TestHealthCheck(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestHealthHandler_ReportsBreakers(t *testing.T) {
+	breakers := breaker.NewGroup(breaker.Settings{FailureThreshold: 1}, nil)
+	breakers.Get("ebay")
+	breakers.Do(context.Background(), "qloo", func() error { return errors.New("connection refused") })
+
+	r := gin.New()
+	r.GET("/health", HealthHandler(breakers))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/health", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Status   string           `json:"status"`
+		Breakers []breaker.Status `json:"breakers"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "degraded", body.Status)
+	require.Len(t, body.Breakers, 2)
+	assert.Equal(t, breaker.Closed, body.Breakers[0].State)
+	assert.Equal(t, "qloo", body.Breakers[1].Name)
+	assert.Equal(t, breaker.Open, body.Breakers[1].State)
+	assert.NotNil(t, body.Breakers[1].OpenUntil)
+}
+
+func TestLivenessHandler(t *testing.T) {
+	r := gin.New()
+	r.GET("/health/live", LivenessHandler)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/health/live", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestReadinessHandler(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+
+	tests := []struct {
+		name   string
+		checks []health.Check
+		code   int
+		status string
+	}{
+		{"ready", []health.Check{{Name: "redis", Critical: true, Run: up}}, http.StatusOK, health.StatusOK},
+		{"degraded", []health.Check{{Name: "redis", Critical: true, Run: up}, {Name: "qloo", Run: down}}, http.StatusOK, health.StatusDegraded},
+		{"critical down", []health.Check{{Name: "redis", Critical: true, Run: down}}, http.StatusServiceUnavailable, health.StatusDown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/health/ready", ReadinessHandler(health.NewChecker(test.checks...)))
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/health/ready", nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.code, w.Code)
+
+			var report health.Report
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			assert.Equal(t, test.status, report.Status)
+			assert.Len(t, report.Components, len(test.checks))
+		})
+	}
+}
+
 func TestSearchHandler(t *testing.T) {
 	r := setupRouter()
 	w := httptest.NewRecorder()
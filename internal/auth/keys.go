@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// KeyPrefix marks plaintext keys issued by this service so they are easy to spot in leaks
+const KeyPrefix = "blue_"
+
+// Service issues, rotates, revokes and authenticates API keys
+type Service struct {
+	store KeyStore
+	now   func() time.Time
+}
+
+// NewService creates a new API key service backed by the given store
+func NewService(store KeyStore) *Service {
+	return &Service{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// HashKey returns the hex-encoded SHA-256 hash of a plaintext key. Keys are
+// generated with 256 bits of entropy, so a fast hash is sufficient.
+func HashKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create issues a new API key for a tenant
+func (s *Service) Create(ctx context.Context, req CreateKeyRequest) (*IssuedKey, error) {
+	if req.TenantID == "" {
+		return nil, fmt.Errorf("tenant_id is required")
+	}
+
+	plaintext, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &APIKey{
+		ID:        "key_" + id,
+		TenantID:  req.TenantID,
+		Name:      req.Name,
+		Plan:      req.Plan,
+		Admin:     req.Admin,
+		Hash:      HashKey(plaintext),
+		Prefix:    plaintext[:len(KeyPrefix)+6],
+		CreatedAt: s.now().UTC(),
+	}
+
+	if err := s.store.Save(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to save api key: %w", err)
+	}
+
+	return &IssuedKey{Key: plaintext, APIKey: key}, nil
+}
+
+// Import stores a key whose plaintext was generated elsewhere, such as a
+// bootstrap admin key supplied through the environment
+func (s *Service) Import(ctx context.Context, plaintext string, req CreateKeyRequest) (*APIKey, error) {
+	hash := HashKey(plaintext)
+	if existing, err := s.store.GetByHash(ctx, hash); err == nil {
+		return existing, nil
+	}
+
+	key := &APIKey{
+		ID:        "key_" + hash[:16],
+		TenantID:  req.TenantID,
+		Name:      req.Name,
+		Plan:      req.Plan,
+		Admin:     req.Admin,
+		Hash:      hash,
+		Prefix:    plaintext[:min(len(plaintext), len(KeyPrefix)+6)],
+		CreatedAt: s.now().UTC(),
+	}
+
+	if err := s.store.Save(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to save api key: %w", err)
+	}
+	return key, nil
+}
+
+// Rotate issues a replacement for an existing key and revokes the original
+func (s *Service) Rotate(ctx context.Context, id string) (*IssuedKey, error) {
+	old, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !old.Active() {
+		return nil, ErrKeyRevoked
+	}
+
+	issued, err := s.Create(ctx, CreateKeyRequest{
+		TenantID: old.TenantID,
+		Name:     old.Name,
+		Plan:     old.Plan,
+		Admin:    old.Admin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Leave only the old key usable rather than two live keys
+	if err := s.Revoke(ctx, id); err != nil {
+		if rollbackErr := s.Revoke(ctx, issued.APIKey.ID); rollbackErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to revoke replacement key %s: %w", issued.APIKey.ID, rollbackErr))
+		}
+		return nil, err
+	}
+
+	return issued, nil
+}
+
+// Revoke disables a key permanently
+func (s *Service) Revoke(ctx context.Context, id string) error {
+	key, err := s.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !key.Active() {
+		return nil
+	}
+
+	revokedAt := s.now().UTC()
+	key.RevokedAt = &revokedAt
+	return s.store.Save(ctx, key)
+}
+
+// List returns the keys belonging to a tenant, or all keys when tenantID is empty
+func (s *Service) List(ctx context.Context, tenantID string) ([]*APIKey, error) {
+	return s.store.List(ctx, tenantID)
+}
+
+// CheckConfig reports an error when no active API key exists, since every
+// protected route would then reject all requests
+func (s *Service) CheckConfig(ctx context.Context) error {
+	keys, err := s.store.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list API keys: %w", err)
+	}
+	for _, key := range keys {
+		if key.RevokedAt == nil {
+			return nil
+		}
+	}
+	return errors.New("no active API keys configured")
+}
+
+// Authenticate resolves a plaintext key to its stored record
+func (s *Service) Authenticate(ctx context.Context, plaintext string) (*APIKey, error) {
+	if plaintext == "" {
+		return nil, ErrKeyNotFound
+	}
+
+	key, err := s.store.GetByHash(ctx, HashKey(plaintext))
+	if err != nil {
+		return nil, err
+	}
+	if !key.Active() {
+		return nil, ErrKeyRevoked
+	}
+
+	return key, nil
+}
+
+// generateKey creates a new random plaintext key
+func generateKey() (string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	return KeyPrefix + secret, nil
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStores(t *testing.T) map[string]KeyStore {
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	t.Cleanup(func() { redisClient.Close() })
+
+	fileStore, err := NewFileKeyStore(filepath.Join(t.TempDir(), "keys.json"))
+	require.NoError(t, err)
+
+	return map[string]KeyStore{
+		"redis": NewRedisKeyStore(redisClient),
+		"file":  fileStore,
+	}
+}
+
+func TestService_CreateAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			service := NewService(store)
+
+			issued, err := service.Create(ctx, CreateKeyRequest{TenantID: "acme", Name: "ci", Plan: "pro"})
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(issued.Key, KeyPrefix))
+			assert.NotContains(t, issued.APIKey.Hash, issued.Key)
+			assert.Equal(t, HashKey(issued.Key), issued.APIKey.Hash)
+
+			key, err := service.Authenticate(ctx, issued.Key)
+			require.NoError(t, err)
+			assert.Equal(t, "acme", key.TenantID)
+			assert.Equal(t, "pro", key.Plan)
+
+			_, err = service.Authenticate(ctx, "blue_wrong")
+			assert.ErrorIs(t, err, ErrKeyNotFound)
+		})
+	}
+}
+
+func TestService_RotateAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			service := NewService(store)
+
+			original, err := service.Create(ctx, CreateKeyRequest{TenantID: "acme"})
+			require.NoError(t, err)
+
+			rotated, err := service.Rotate(ctx, original.APIKey.ID)
+			require.NoError(t, err)
+			assert.NotEqual(t, original.Key, rotated.Key)
+			assert.Equal(t, "acme", rotated.APIKey.TenantID)
+
+			_, err = service.Authenticate(ctx, original.Key)
+			assert.ErrorIs(t, err, ErrKeyRevoked)
+
+			_, err = service.Authenticate(ctx, rotated.Key)
+			assert.NoError(t, err)
+
+			require.NoError(t, service.Revoke(ctx, rotated.APIKey.ID))
+			_, err = service.Authenticate(ctx, rotated.Key)
+			assert.ErrorIs(t, err, ErrKeyRevoked)
+
+			_, err = service.Rotate(ctx, rotated.APIKey.ID)
+			assert.ErrorIs(t, err, ErrKeyRevoked)
+
+			assert.ErrorIs(t, service.Revoke(ctx, "key_missing"), ErrKeyNotFound)
+		})
+	}
+}
+
+// failingRevokeStore fails to save revocations of one key
+type failingRevokeStore struct {
+	KeyStore
+	id string
+}
+
+func (s *failingRevokeStore) Save(ctx context.Context, key *APIKey) error {
+	if key.ID == s.id && key.RevokedAt != nil {
+		return errors.New("store unavailable")
+	}
+	return s.KeyStore.Save(ctx, key)
+}
+
+func TestService_RotateRevokesReplacementWhenRevokeFails(t *testing.T) {
+	ctx := context.Background()
+	store := testStores(t)["redis"]
+	original, err := NewService(store).Create(ctx, CreateKeyRequest{TenantID: "acme"})
+	require.NoError(t, err)
+
+	service := NewService(&failingRevokeStore{KeyStore: store, id: original.APIKey.ID})
+	_, err = service.Rotate(ctx, original.APIKey.ID)
+	assert.ErrorContains(t, err, "store unavailable")
+
+	// The original key keeps working and no second live key was left behind
+	_, err = service.Authenticate(ctx, original.Key)
+	assert.NoError(t, err)
+	keys, err := service.List(ctx, "acme")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	for _, key := range keys {
+		assert.Equal(t, key.ID == original.APIKey.ID, key.Active())
+	}
+}
+
+func TestService_ListByTenant(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			service := NewService(store)
+
+			_, err := service.Create(ctx, CreateKeyRequest{TenantID: "acme"})
+			require.NoError(t, err)
+			_, err = service.Create(ctx, CreateKeyRequest{TenantID: "acme"})
+			require.NoError(t, err)
+			_, err = service.Create(ctx, CreateKeyRequest{TenantID: "globex"})
+			require.NoError(t, err)
+
+			acme, err := service.List(ctx, "acme")
+			require.NoError(t, err)
+			assert.Len(t, acme, 2)
+
+			all, err := service.List(ctx, "")
+			require.NoError(t, err)
+			assert.Len(t, all, 3)
+		})
+	}
+}
+
+func TestService_ImportIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(testStores(t)["redis"])
+
+	first, err := service.Import(ctx, "bootstrap-admin-secret", CreateKeyRequest{TenantID: "admin", Admin: true})
+	require.NoError(t, err)
+	second, err := service.Import(ctx, "bootstrap-admin-secret", CreateKeyRequest{TenantID: "admin", Admin: true})
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+
+	key, err := service.Authenticate(ctx, "bootstrap-admin-secret")
+	require.NoError(t, err)
+	assert.True(t, key.Admin)
+}
+
+func TestFileKeyStore_PersistsAcrossReloads(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	store, err := NewFileKeyStore(path)
+	require.NoError(t, err)
+
+	issued, err := NewService(store).Create(ctx, CreateKeyRequest{TenantID: "acme"})
+	require.NoError(t, err)
+
+	reloaded, err := NewFileKeyStore(path)
+	require.NoError(t, err)
+	key, err := NewService(reloaded).Authenticate(ctx, issued.Key)
+	require.NoError(t, err)
+	assert.Equal(t, issued.APIKey.ID, key.ID)
+}
+
+func TestCreate_RequiresTenant(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(testStores(t)["file"])
+
+	_, err := service.Create(ctx, CreateKeyRequest{})
+	assert.ErrorContains(t, err, "tenant_id is required")
+}
+
+func TestService_CheckConfig(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			service := NewService(store)
+			assert.ErrorContains(t, service.CheckConfig(ctx), "no active API keys")
+
+			issued, err := service.Create(ctx, CreateKeyRequest{TenantID: "acme", Name: "ci"})
+			require.NoError(t, err)
+			assert.NoError(t, service.CheckConfig(ctx))
+
+			require.NoError(t, service.Revoke(ctx, issued.APIKey.ID))
+			assert.ErrorContains(t, service.CheckConfig(ctx), "no active API keys")
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/jesee-kuya/blue/internal/cache"
+)
+
+// RedisKeyStore stores API keys in Redis
+type RedisKeyStore struct {
+	redisClient *cache.RedisClient
+}
+
+// NewRedisKeyStore creates a new Redis-backed key store
+func NewRedisKeyStore(redisClient *cache.RedisClient) *RedisKeyStore {
+	return &RedisKeyStore{redisClient: redisClient}
+}
+
+// Save stores the key and its hash and tenant indexes in one transaction, so an
+// interrupted save cannot leave a key without its index entries
+func (s *RedisKeyStore) Save(ctx context.Context, key *APIKey) error {
+	return s.redisClient.TxPipelined(ctx, func(tx *cache.Tx) error {
+		if err := tx.Set(ctx, fmt.Sprintf("auth:key:%s", key.ID), key); err != nil {
+			return err
+		}
+		if err := tx.Set(ctx, fmt.Sprintf("auth:hash:%s", key.Hash), key.ID); err != nil {
+			return err
+		}
+		if err := tx.SAdd(ctx, fmt.Sprintf("auth:tenant:%s:keys", key.TenantID), key.ID); err != nil {
+			return err
+		}
+		return tx.SAdd(ctx, "auth:tenants", key.TenantID)
+	})
+}
+
+// Get returns the key with the given ID
+func (s *RedisKeyStore) Get(ctx context.Context, id string) (*APIKey, error) {
+	var key APIKey
+	if err := s.redisClient.Get(ctx, fmt.Sprintf("auth:key:%s", id), &key); err != nil {
+		if cache.IsMiss(err) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByHash returns the key with the given hash
+func (s *RedisKeyStore) GetByHash(ctx context.Context, hash string) (*APIKey, error) {
+	var id string
+	if err := s.redisClient.Get(ctx, fmt.Sprintf("auth:hash:%s", hash), &id); err != nil {
+		if cache.IsMiss(err) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// List returns the keys belonging to a tenant, or all keys when tenantID is empty
+func (s *RedisKeyStore) List(ctx context.Context, tenantID string) ([]*APIKey, error) {
+	tenants := []string{tenantID}
+	if tenantID == "" {
+		var err error
+		if tenants, err = s.redisClient.SMembers(ctx, "auth:tenants"); err != nil {
+			return nil, err
+		}
+	}
+
+	var keys []*APIKey
+	for _, t := range tenants {
+		ids, err := s.redisClient.SMembers(ctx, fmt.Sprintf("auth:tenant:%s:keys", t))
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			key, err := s.Get(ctx, id)
+			if err != nil {
+				continue
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	sortKeys(keys)
+	return keys, nil
+}
+
+// FileKeyStore stores API keys in a JSON file, suitable for small static deployments
+type FileKeyStore struct {
+	path string
+	mu   sync.RWMutex
+	keys map[string]*APIKey
+}
+
+// NewFileKeyStore loads keys from the JSON file at path, creating an empty store if it does not exist
+func NewFileKeyStore(path string) (*FileKeyStore, error) {
+	store := &FileKeyStore{
+		path: path,
+		keys: make(map[string]*APIKey),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	var keys []*APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+	for _, key := range keys {
+		store.keys[key.ID] = key
+	}
+
+	return store, nil
+}
+
+// Save stores the key and writes the file atomically
+func (s *FileKeyStore) Save(_ context.Context, key *APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *key
+	s.keys[key.ID] = &copied
+
+	keys := make([]*APIKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	sortKeys(keys)
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal keys: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".keys-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Get returns the key with the given ID
+func (s *FileKeyStore) Get(_ context.Context, id string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	copied := *key
+	return &copied, nil
+}
+
+// GetByHash returns the key with the given hash
+func (s *FileKeyStore) GetByHash(_ context.Context, hash string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.keys {
+		if key.Hash == hash {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// List returns the keys belonging to a tenant, or all keys when tenantID is empty
+func (s *FileKeyStore) List(_ context.Context, tenantID string) ([]*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []*APIKey
+	for _, key := range s.keys {
+		if tenantID == "" || key.TenantID == tenantID {
+			copied := *key
+			keys = append(keys, &copied)
+		}
+	}
+
+	sortKeys(keys)
+	return keys, nil
+}
+
+// sortKeys orders keys by creation time so listings are stable
+func sortKeys(keys []*APIKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// APIKey represents a stored API key. The plaintext key is never persisted, only its hash.
+type APIKey struct {
+	ID        string     `json:"id"`
+	TenantID  string     `json:"tenant_id"`
+	Name      string     `json:"name,omitempty"`
+	Plan      string     `json:"plan,omitempty"`
+	Admin     bool       `json:"admin,omitempty"`
+	Hash      string     `json:"hash"`
+	Prefix    string     `json:"prefix"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the key can still be used to authenticate
+func (k *APIKey) Active() bool {
+	return k.RevokedAt == nil
+}
+
+// CreateKeyRequest describes a new API key to issue
+type CreateKeyRequest struct {
+	TenantID string `json:"tenant_id" binding:"required"`
+	Name     string `json:"name"`
+	Plan     string `json:"plan"`
+	Admin    bool   `json:"admin"`
+}
+
+// IssuedKey is returned once when a key is created or rotated; it is the only
+// time the plaintext key is available
+type IssuedKey struct {
+	Key    string  `json:"key"`
+	APIKey *APIKey `json:"api_key"`
+}
+
+// KeyStore persists API keys indexed by ID and by hash
+type KeyStore interface {
+	Save(ctx context.Context, key *APIKey) error
+	Get(ctx context.Context, id string) (*APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+	List(ctx context.Context, tenantID string) ([]*APIKey, error)
+}
+
+var (
+	// ErrKeyNotFound is returned when no key matches the lookup
+	ErrKeyNotFound = errors.New("api key not found")
+	// ErrKeyRevoked is returned when authenticating with a revoked key
+	ErrKeyRevoked = errors.New("api key revoked")
+)
@@ -0,0 +1,267 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/jesee-kuya/blue/internal/retry"
+)
+
+// State is the position of a circuit breaker
+type State string
+
+const (
+	// Closed lets every call through and counts consecutive failures
+	Closed State = "closed"
+	// Open rejects calls until the open timeout has passed
+	Open State = "open"
+	// HalfOpen lets a limited number of probe calls through to test the upstream
+	HalfOpen State = "half_open"
+)
+
+// ErrOpen is matched by errors returned for calls rejected by an open breaker
+var ErrOpen = errors.New("circuit breaker is open")
+
+// OpenError is returned for calls rejected by an open breaker
+type OpenError struct {
+	Name  string
+	Until time.Time
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker for %s is open", e.Name)
+}
+
+// Is makes errors.Is(err, ErrOpen) match any OpenError
+func (e *OpenError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+// Settings controls when a breaker opens and how it recovers
+type Settings struct {
+	FailureThreshold int           // consecutive failures that open the breaker
+	OpenTimeout      time.Duration // how long the breaker stays open before probing
+	HalfOpenProbes   int           // concurrent probes allowed, and successes needed to close
+}
+
+// DefaultSettings opens after five consecutive failures and probes after 30 seconds
+var DefaultSettings = Settings{
+	FailureThreshold: 5,
+	OpenTimeout:      30 * time.Second,
+	HalfOpenProbes:   1,
+}
+
+// Snapshot is the state of a breaker as kept in a Store
+type Snapshot struct {
+	State     State     `json:"state"`
+	Failures  int       `json:"failures"`
+	Successes int       `json:"successes,omitempty"` // successful half-open probes
+	OpenedAt  time.Time `json:"opened_at,omitempty"`
+}
+
+// Status reports a breaker's state for health checks
+type Status struct {
+	Name      string     `json:"name"`
+	State     State      `json:"state"`
+	Failures  int        `json:"failures"`
+	OpenUntil *time.Time `json:"open_until,omitempty"`
+}
+
+// Breaker guards calls to one upstream. Its state lives in a Store so that
+// instances sharing a Redis store trip and recover together; when the store
+// fails the breaker falls back to state held in memory.
+type Breaker struct {
+	name     string
+	settings Settings
+	store    Store
+	fallback *MemoryStore
+	degraded atomic.Bool
+	now      func() time.Time
+
+	mu     sync.Mutex
+	probes int // half-open probes in flight in this process
+}
+
+// New creates a breaker for the named upstream. A nil store keeps state in memory.
+func New(name string, settings Settings, store Store) *Breaker {
+	fallback := NewMemoryStore()
+	if store == nil {
+		store = fallback
+	}
+	return &Breaker{
+		name:     name,
+		settings: settings.withDefaults(),
+		store:    store,
+		fallback: fallback,
+		now:      time.Now,
+	}
+}
+
+// Name returns the upstream the breaker guards
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Do runs fn unless the breaker is open and records its outcome. Calls rejected
+// by an open breaker fail with a permanent OpenError so they are not retried.
+// Store round trips run on ctx so they join the caller's trace.
+func (b *Breaker) Do(ctx context.Context, fn func() error) error {
+	probe, err := b.allow(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	// The outcome is recorded even when the caller gave up on the call
+	b.record(context.WithoutCancel(ctx), err, probe)
+	return err
+}
+
+// Status returns the breaker's current state
+func (b *Breaker) Status(ctx context.Context) Status {
+	snapshot := b.load(ctx)
+	status := Status{Name: b.name, State: snapshot.State, Failures: snapshot.Failures}
+	if snapshot.State == Open {
+		until := snapshot.OpenedAt.Add(b.settings.OpenTimeout)
+		if b.now().Before(until) {
+			status.OpenUntil = &until
+		} else {
+			status.State = HalfOpen
+		}
+	}
+	return status
+}
+
+// State returns the breaker's current state
+func (b *Breaker) State(ctx context.Context) State {
+	return b.Status(ctx).State
+}
+
+// allow reports whether a call may proceed and whether it is a half-open probe.
+// The mutex only guards the probe count; store round trips happen outside it.
+func (b *Breaker) allow(ctx context.Context) (probe bool, err error) {
+	snapshot, _ := b.apply(ctx, EventAllow)
+	switch snapshot.State {
+	case Open:
+		return false, retry.MarkPermanent(&OpenError{Name: b.name, Until: snapshot.OpenedAt.Add(b.settings.OpenTimeout)})
+	case HalfOpen:
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.probes >= b.settings.HalfOpenProbes {
+			return false, retry.MarkPermanent(&OpenError{Name: b.name, Until: b.now()})
+		}
+		b.probes++
+		return true, nil
+	}
+	return false, nil
+}
+
+// record updates the breaker with the outcome of a call
+func (b *Breaker) record(ctx context.Context, err error, probe bool) {
+	if probe {
+		b.mu.Lock()
+		b.probes--
+		b.mu.Unlock()
+	}
+
+	if !isFailure(err) {
+		if snapshot, changed := b.apply(ctx, EventSuccess); changed && snapshot.State == Closed {
+			slog.Info("Circuit breaker closed", logging.KeyProvider, b.name)
+		}
+		return
+	}
+
+	if snapshot, changed := b.apply(ctx, EventFailure); changed && snapshot.State == Open {
+		slog.Warn("Circuit breaker opened", logging.KeyProvider, b.name, "failures", snapshot.Failures, logging.KeyError, err)
+	}
+}
+
+// isFailure reports whether err says the upstream is unhealthy. Successes,
+// permanent errors such as bad requests and cancelled calls do not count, but
+// timeouts do.
+func isFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrOpen) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	return !retry.IsPermanent(err)
+}
+
+// load reads the breaker's state, using the in-memory fallback when the store fails
+func (b *Breaker) load(ctx context.Context) Snapshot {
+	snapshot, ok, err := b.store.Load(ctx, b.name)
+	if err != nil {
+		b.degrade(ctx, err)
+		snapshot, ok, _ = b.fallback.Load(ctx, b.name)
+	} else {
+		b.recover()
+	}
+	if !ok || snapshot.State == "" {
+		snapshot.State = Closed
+	}
+	return snapshot
+}
+
+// apply records event in the store, mirroring the result in the in-memory
+// fallback, and applies it to the fallback alone when the store fails
+func (b *Breaker) apply(ctx context.Context, event Event) (Snapshot, bool) {
+	now := b.now()
+	if b.store == Store(b.fallback) {
+		snapshot, changed, _ := b.fallback.Apply(ctx, b.name, event, now, b.settings, 0)
+		return snapshot, changed
+	}
+
+	snapshot, changed, err := b.store.Apply(ctx, b.name, event, now, b.settings, b.ttl())
+	if err != nil {
+		b.degrade(ctx, err)
+		snapshot, changed, _ = b.fallback.Apply(ctx, b.name, event, now, b.settings, 0)
+		return snapshot, changed
+	}
+	b.recover()
+	b.fallback.set(b.name, snapshot)
+	return snapshot, changed
+}
+
+// ttl bounds how long shared state outlives the last call
+func (b *Breaker) ttl() time.Duration {
+	return max(10*b.settings.OpenTimeout, 10*time.Minute)
+}
+
+// degrade switches to the in-memory fallback. A store call that failed because
+// the caller's context ended says nothing about the store, so it is ignored.
+func (b *Breaker) degrade(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	if b.degraded.CompareAndSwap(false, true) {
+		slog.Warn("Circuit breaker falling back to in-memory state", logging.KeyProvider, b.name, logging.KeyError, err)
+	}
+}
+
+func (b *Breaker) recover() {
+	if b.degraded.CompareAndSwap(true, false) {
+		slog.Info("Circuit breaker recovered, using shared state again", logging.KeyProvider, b.name)
+	}
+}
+
+// withDefaults fills unset settings from DefaultSettings
+func (s Settings) withDefaults() Settings {
+	if s.FailureThreshold <= 0 {
+		s.FailureThreshold = DefaultSettings.FailureThreshold
+	}
+	if s.OpenTimeout <= 0 {
+		s.OpenTimeout = DefaultSettings.OpenTimeout
+	}
+	if s.HalfOpenProbes <= 0 {
+		s.HalfOpenProbes = DefaultSettings.HalfOpenProbes
+	}
+	return s
+}
@@ -0,0 +1,264 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errUpstream = &retry.StatusError{Service: "qloo", StatusCode: 503}
+
+func newTestBreaker(store Store, now *time.Time) *Breaker {
+	b := New("qloo", Settings{FailureThreshold: 3, OpenTimeout: time.Minute, HalfOpenProbes: 1}, store)
+	b.now = func() time.Time { return *now }
+	return b
+}
+
+func fail() error    { return errUpstream }
+func succeed() error { return nil }
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(nil, &now)
+
+	assert.Equal(t, errUpstream, b.Do(ctx, fail))
+	assert.Equal(t, errUpstream, b.Do(ctx, fail))
+	assert.NoError(t, b.Do(ctx, succeed)) // a success resets the count
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, errUpstream, b.Do(ctx, fail))
+	}
+
+	calls := 0
+	err := b.Do(ctx, func() error { calls++; return nil })
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.True(t, retry.IsPermanent(err))
+	assert.Equal(t, 0, calls)
+
+	status := b.Status(ctx)
+	assert.Equal(t, Open, status.State)
+	assert.Equal(t, 3, status.Failures)
+	require.NotNil(t, status.OpenUntil)
+	assert.Equal(t, now.Add(time.Minute), *status.OpenUntil)
+}
+
+func TestBreaker_IgnoresPermanentAndCancelledErrors(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	b := newTestBreaker(nil, &now)
+
+	for i := 0; i < 5; i++ {
+		b.Do(ctx, func() error { return &retry.StatusError{Service: "qloo", StatusCode: 400} })
+		b.Do(ctx, func() error { return context.Canceled })
+	}
+	assert.Equal(t, Closed, b.State(ctx))
+
+	for i := 0; i < 3; i++ {
+		b.Do(ctx, func() error { return context.DeadlineExceeded })
+	}
+	assert.Equal(t, Open, b.State(ctx))
+}
+
+func TestBreaker_HalfOpenProbe(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(nil, &now)
+	for i := 0; i < 3; i++ {
+		b.Do(ctx, fail)
+	}
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, HalfOpen, b.State(ctx))
+
+	// A failed probe opens the breaker again for another timeout
+	assert.Equal(t, errUpstream, b.Do(ctx, fail))
+	assert.ErrorIs(t, b.Do(ctx, succeed), ErrOpen)
+
+	now = now.Add(time.Minute)
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- b.Do(ctx, func() error { <-release; return nil })
+	}()
+	require.Eventually(t, func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return b.probes == 1
+	}, time.Second, time.Millisecond)
+
+	// Only one probe at a time
+	assert.ErrorIs(t, b.Do(ctx, succeed), ErrOpen)
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, Closed, b.State(ctx))
+	assert.NoError(t, b.Do(ctx, succeed))
+}
+
+func TestBreaker_SharesStateThroughRedis(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+
+	now := time.Now()
+	first := newTestBreaker(NewRedisStore(redisClient), &now)
+	second := newTestBreaker(NewRedisStore(redisClient), &now)
+
+	first.Do(ctx, fail)
+	second.Do(ctx, fail)
+	first.Do(ctx, fail)
+
+	assert.Equal(t, Open, second.State(ctx))
+	assert.ErrorIs(t, second.Do(ctx, succeed), ErrOpen)
+	assert.True(t, mr.Exists("breaker:qloo"))
+}
+
+func TestBreaker_RecordsOutcomeAfterCallerCancels(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+
+	now := time.Now()
+	b := newTestBreaker(NewRedisStore(redisClient), &now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := b.Do(ctx, func() error {
+		cancel()
+		return errUpstream
+	})
+	assert.Equal(t, errUpstream, err)
+	assert.Equal(t, "1", mr.HGet("breaker:qloo", "failures"))
+	assert.False(t, b.degraded.Load())
+
+	// A store call cut short by the caller does not switch to in-memory state
+	assert.Equal(t, Closed, b.State(ctx))
+	assert.False(t, b.degraded.Load())
+}
+
+func TestBreaker_CountsConcurrentFailuresAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+
+	settings := Settings{FailureThreshold: 40, OpenTimeout: time.Minute, HalfOpenProbes: 1}
+	instances := []*Breaker{New("qloo", settings, NewRedisStore(redisClient)), New("qloo", settings, NewRedisStore(redisClient))}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(b *Breaker) {
+			defer wg.Done()
+			b.Do(ctx, fail)
+		}(instances[i%2])
+	}
+	wg.Wait()
+
+	// No failure is lost to another instance's write
+	status := instances[0].Status(ctx)
+	assert.Equal(t, Open, status.State)
+	assert.Equal(t, 40, status.Failures)
+}
+
+func TestBreaker_RecoversThroughRedis(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	first := newTestBreaker(NewRedisStore(redisClient), &now)
+	second := newTestBreaker(NewRedisStore(redisClient), &now)
+	for i := 0; i < 3; i++ {
+		first.Do(ctx, fail)
+	}
+	assert.Equal(t, "open", mr.HGet("breaker:qloo", "state"))
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, second.Do(ctx, succeed))
+	assert.Equal(t, Closed, first.State(ctx))
+	assert.Equal(t, "0", mr.HGet("breaker:qloo", "failures"))
+
+	// A failed probe reopens the breaker for every instance
+	for i := 0; i < 3; i++ {
+		second.Do(ctx, fail)
+	}
+	now = now.Add(time.Minute)
+	assert.Equal(t, errUpstream, first.Do(ctx, fail))
+	assert.ErrorIs(t, second.Do(ctx, succeed), ErrOpen)
+	assert.Equal(t, 4, second.Status(ctx).Failures)
+}
+
+func TestBreaker_FallsBackToMemoryWhenRedisFails(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+	mr.Close()
+
+	now := time.Now()
+	b := newTestBreaker(NewRedisStore(redisClient), &now)
+	for i := 0; i < 3; i++ {
+		b.Do(ctx, fail)
+	}
+
+	assert.Equal(t, Open, b.State(ctx))
+	assert.ErrorIs(t, b.Do(ctx, succeed), ErrOpen)
+}
+
+func TestGroup_Statuses(t *testing.T) {
+	ctx := context.Background()
+	group := NewGroup(Settings{FailureThreshold: 1}, nil)
+	group.Overrides["ebay"] = Settings{FailureThreshold: 2}
+
+	group.Do(ctx, "qloo", fail)
+	group.Do(ctx, "ebay", fail)
+	group.Get("amazon")
+
+	statuses := group.Statuses(ctx)
+	require.Len(t, statuses, 3)
+	assert.Equal(t, []string{"amazon", "ebay", "qloo"}, []string{statuses[0].Name, statuses[1].Name, statuses[2].Name})
+	assert.Equal(t, Closed, statuses[1].State)
+	assert.Equal(t, Open, statuses[2].State)
+	assert.False(t, group.Healthy(ctx))
+}
+
+func TestNewGroupWithOptions(t *testing.T) {
+	ctx := context.Background()
+	settings := Settings{FailureThreshold: 4, OpenTimeout: 10 * time.Second, HalfOpenProbes: 1}
+	qloo := Settings{FailureThreshold: 2, OpenTimeout: 10 * time.Second, HalfOpenProbes: 1}
+
+	group, err := NewGroupWithOptions(Options{Settings: settings, Overrides: map[string]Settings{"qloo": qloo}}, nil, "qloo", "ebay")
+	require.NoError(t, err)
+
+	assert.Equal(t, settings, group.Default)
+	assert.Equal(t, qloo, group.Overrides["qloo"])
+	assert.NotContains(t, group.Overrides, "ebay")
+	assert.Len(t, group.Statuses(ctx), 2)
+
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+	group, err = NewGroupWithOptions(Options{Store: StoreRedis, Settings: Settings{FailureThreshold: 1}}, redisClient)
+	require.NoError(t, err)
+	group.Do(ctx, "qloo", fail)
+	assert.True(t, mr.Exists("breaker:qloo"))
+
+	_, err = NewGroupWithOptions(Options{Store: "etcd"}, nil)
+	assert.ErrorContains(t, err, `unknown circuit breaker store "etcd"`)
+}
+
+func TestIsFailure(t *testing.T) {
+	assert.False(t, isFailure(nil))
+	assert.False(t, isFailure(errors.Join(ErrOpen)))
+	assert.True(t, isFailure(errors.New("connection refused")))
+}
@@ -0,0 +1,116 @@
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+
+	"github.com/jesee-kuya/blue/internal/cache"
+)
+
+// Group holds one breaker per upstream, sharing a store
+type Group struct {
+	Default   Settings
+	Overrides map[string]Settings // keyed by upstream name
+
+	store    Store
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewGroup creates a group whose breakers use settings unless overridden. A nil
+// store keeps each breaker's state in memory.
+func NewGroup(settings Settings, store Store) *Group {
+	return &Group{
+		Default:   settings,
+		Overrides: make(map[string]Settings),
+		store:     store,
+		breakers:  make(map[string]*Breaker),
+	}
+}
+
+// Stores that NewGroupWithOptions can use
+const (
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+)
+
+// Options configures a group of breakers
+type Options struct {
+	Store     string              // memory or redis; redis shares state between instances
+	Settings  Settings            // defaults for every upstream
+	Overrides map[string]Settings // keyed by upstream name
+}
+
+// NewGroupWithOptions creates the group described by opts, keeping shared state
+// in redisClient when the store is redis. Breakers for the named upstreams are
+// created up front so health checks list them.
+func NewGroupWithOptions(opts Options, redisClient *cache.RedisClient, names ...string) (*Group, error) {
+	var store Store
+	switch opts.Store {
+	case "", StoreMemory:
+	case StoreRedis:
+		store = NewRedisStore(redisClient)
+	default:
+		return nil, fmt.Errorf("unknown circuit breaker store %q", opts.Store)
+	}
+
+	group := NewGroup(opts.Settings, store)
+	maps.Copy(group.Overrides, opts.Overrides)
+	for _, name := range names {
+		group.Get(name)
+	}
+	return group, nil
+}
+
+// Get returns the breaker for the named upstream, creating it on first use
+func (g *Group) Get(name string) *Breaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if b, ok := g.breakers[name]; ok {
+		return b
+	}
+
+	settings := g.Default
+	if override, ok := g.Overrides[name]; ok {
+		settings = override
+	}
+	b := New(name, settings, g.store)
+	g.breakers[name] = b
+	return b
+}
+
+// Do runs fn through the named upstream's breaker
+func (g *Group) Do(ctx context.Context, name string, fn func() error) error {
+	return g.Get(name).Do(ctx, fn)
+}
+
+// Statuses returns the state of every breaker in the group, sorted by name
+func (g *Group) Statuses(ctx context.Context) []Status {
+	g.mu.Lock()
+	breakers := make([]*Breaker, 0, len(g.breakers))
+	for _, b := range g.breakers {
+		breakers = append(breakers, b)
+	}
+	g.mu.Unlock()
+
+	statuses := make([]Status, len(breakers))
+	for i, b := range breakers {
+		statuses[i] = b.Status(ctx)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Healthy reports whether no breaker in the group is open
+func (g *Group) Healthy(ctx context.Context) bool {
+	for _, status := range g.Statuses(ctx) {
+		if status.State == Open {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,226 @@
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces shared breaker state in Redis
+const keyPrefix = "breaker:"
+
+// Event is something that happened to a breaker
+type Event string
+
+const (
+	// EventAllow moves an open breaker whose timeout has passed to half-open
+	EventAllow Event = "allow"
+	// EventSuccess records a successful call
+	EventSuccess Event = "success"
+	// EventFailure records a failed call
+	EventFailure Event = "failure"
+)
+
+// Store keeps breaker state, optionally shared between instances. Apply updates
+// the state atomically so that instances sharing a store never overwrite each
+// other's failure counts.
+type Store interface {
+	Load(ctx context.Context, name string) (Snapshot, bool, error)
+	Apply(ctx context.Context, name string, event Event, now time.Time, settings Settings, ttl time.Duration) (snapshot Snapshot, changed bool, err error)
+}
+
+// apply returns the state after event and whether the breaker changed state.
+// transitionScript mirrors it for Redis.
+func (s Snapshot) apply(event Event, now time.Time, settings Settings) (Snapshot, bool) {
+	if s.State == "" {
+		s.State = Closed
+	}
+
+	switch event {
+	case EventAllow:
+		if s.State == Open && !now.Before(s.OpenedAt.Add(settings.OpenTimeout)) {
+			s.State = HalfOpen
+			s.Successes = 0
+			return s, true
+		}
+	case EventSuccess:
+		switch s.State {
+		case HalfOpen:
+			s.Successes++
+			if s.Successes >= settings.HalfOpenProbes {
+				return Snapshot{State: Closed}, true
+			}
+		case Closed:
+			s.Failures = 0
+		}
+	case EventFailure:
+		switch s.State {
+		case HalfOpen:
+			return Snapshot{State: Open, Failures: s.Failures + 1, OpenedAt: now}, true
+		case Closed:
+			s.Failures++
+			if s.Failures >= settings.FailureThreshold {
+				return Snapshot{State: Open, Failures: s.Failures, OpenedAt: now}, true
+			}
+		}
+	}
+	return s, false
+}
+
+// MemoryStore keeps breaker state in process memory
+type MemoryStore struct {
+	mu        sync.Mutex
+	snapshots map[string]Snapshot
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{snapshots: make(map[string]Snapshot)}
+}
+
+// Load returns the state saved for the named breaker
+func (s *MemoryStore) Load(_ context.Context, name string) (Snapshot, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot, ok := s.snapshots[name]
+	return snapshot, ok, nil
+}
+
+// Apply records event for the named breaker. In-memory state does not expire.
+func (s *MemoryStore) Apply(_ context.Context, name string, event Event, now time.Time, settings Settings, _ time.Duration) (Snapshot, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot, changed := s.snapshots[name].apply(event, now, settings)
+	s.snapshots[name] = snapshot
+	return snapshot, changed, nil
+}
+
+// set replaces the state of the named breaker
+func (s *MemoryStore) set(name string, snapshot Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots[name] = snapshot
+}
+
+// RedisStore shares breaker state between instances through a Redis hash
+type RedisStore struct {
+	redisClient *cache.RedisClient
+}
+
+// NewRedisStore creates a Redis-backed store
+func NewRedisStore(redisClient *cache.RedisClient) *RedisStore {
+	return &RedisStore{redisClient: redisClient}
+}
+
+// Load returns the state saved for the named breaker
+func (s *RedisStore) Load(ctx context.Context, name string) (Snapshot, bool, error) {
+	fields, err := s.redisClient.HGetAll(ctx, keyPrefix+name)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	if len(fields) == 0 {
+		return Snapshot{}, false, nil
+	}
+
+	failures, _ := strconv.Atoi(fields["failures"])
+	successes, _ := strconv.Atoi(fields["successes"])
+	openedAt, _ := strconv.ParseInt(fields["opened_at"], 10, 64)
+	return newSnapshot(fields["state"], failures, successes, openedAt), true, nil
+}
+
+// Apply records event for the named breaker in a single script, keeping the
+// state until ttl passes without updates
+func (s *RedisStore) Apply(ctx context.Context, name string, event Event, now time.Time, settings Settings, ttl time.Duration) (Snapshot, bool, error) {
+	result, err := s.redisClient.RunScript(ctx, transitionScript, []string{keyPrefix + name},
+		string(event), now.UnixMilli(), settings.FailureThreshold, settings.OpenTimeout.Milliseconds(),
+		settings.HalfOpenProbes, ttl.Milliseconds())
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 5 {
+		return Snapshot{}, false, fmt.Errorf("unexpected circuit breaker script result: %v", result)
+	}
+	changed, _ := values[0].(int64)
+	state, _ := values[1].(string)
+	failures, _ := values[2].(int64)
+	successes, _ := values[3].(int64)
+	openedAt, _ := values[4].(int64)
+	return newSnapshot(state, int(failures), int(successes), openedAt), changed == 1, nil
+}
+
+// newSnapshot builds a snapshot from stored fields; openedAt is in Unix milliseconds
+func newSnapshot(state string, failures, successes int, openedAt int64) Snapshot {
+	snapshot := Snapshot{State: State(state), Failures: failures, Successes: successes}
+	if openedAt > 0 {
+		snapshot.OpenedAt = time.UnixMilli(openedAt)
+	}
+	return snapshot
+}
+
+// transitionScript applies an event to a breaker atomically, mirroring Snapshot.apply.
+// KEYS[1] = breaker hash
+// ARGV[1] = event, ARGV[2] = now (ms), ARGV[3] = failure threshold,
+// ARGV[4] = open timeout (ms), ARGV[5] = half-open probes, ARGV[6] = ttl (ms)
+// Returns {changed, state, failures, successes, opened_at}
+var transitionScript = redis.NewScript(`
+local event = ARGV[1]
+local now = tonumber(ARGV[2])
+local threshold = tonumber(ARGV[3])
+local timeout = tonumber(ARGV[4])
+local probes = tonumber(ARGV[5])
+
+local fields = redis.call('HMGET', KEYS[1], 'state', 'failures', 'successes', 'opened_at')
+local state = fields[1] or 'closed'
+local failures = tonumber(fields[2] or '0')
+local successes = tonumber(fields[3] or '0')
+local openedAt = tonumber(fields[4] or '0')
+local changed = 0
+local dirty = false
+
+if event == 'allow' then
+	if state == 'open' and now >= openedAt + timeout then
+		state, successes = 'half_open', 0
+		changed, dirty = 1, true
+	end
+elseif event == 'success' then
+	if state == 'half_open' then
+		successes = successes + 1
+		dirty = true
+		if successes >= probes then
+			state, failures, successes, openedAt = 'closed', 0, 0, 0
+			changed = 1
+		end
+	elseif state == 'closed' and failures > 0 then
+		failures = 0
+		dirty = true
+	end
+elseif event == 'failure' then
+	if state == 'half_open' then
+		state, failures, successes, openedAt = 'open', failures + 1, 0, now
+		changed, dirty = 1, true
+	elseif state == 'closed' then
+		failures = redis.call('HINCRBY', KEYS[1], 'failures', 1)
+		dirty = true
+		if failures >= threshold then
+			state, openedAt = 'open', now
+			changed = 1
+		end
+	end
+end
+
+if dirty then
+	redis.call('HSET', KEYS[1], 'state', state, 'failures', failures, 'successes', successes, 'opened_at', openedAt)
+	redis.call('PEXPIRE', KEYS[1], ARGV[6])
+end
+return {changed, state, failures, successes, openedAt}
+`)
@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Connection modes accepted by Options.Mode
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+// DefaultAddr is used when no address is configured
+const DefaultAddr = "localhost:6379"
+
+// Options configures the Redis connection. URL accepts redis:// and rediss://
+// URLs carrying credentials and a database, or bare host:port addresses
+// separated by commas. Explicit fields override what the URL sets.
+type Options struct {
+	URL        string
+	Mode       string   // standalone, sentinel or cluster; empty picks from the addresses
+	Addrs      []string // sentinel or cluster seed addresses, overriding the URL's
+	MasterName string   // sentinel master
+	Username   string
+	Password   string
+	DB         int
+	TLS        bool
+	// TLSInsecureSkipVerify disables certificate checks, for development only
+	TLSInsecureSkipVerify bool
+
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolTimeout  time.Duration
+}
+
+// Validate reports settings that cannot produce a connection
+func (o Options) Validate() error {
+	if o.DB < 0 {
+		return errors.New("Redis database must not be negative")
+	}
+	_, err := o.universalOptions()
+	return err
+}
+
+// universalOptions converts the options to go-redis options
+func (o Options) universalOptions() (*redis.UniversalOptions, error) {
+	opts := &redis.UniversalOptions{
+		MasterName:   o.MasterName,
+		PoolSize:     o.PoolSize,
+		MinIdleConns: o.MinIdleConns,
+		DialTimeout:  o.DialTimeout,
+		ReadTimeout:  o.ReadTimeout,
+		WriteTimeout: o.WriteTimeout,
+		PoolTimeout:  o.PoolTimeout,
+	}
+
+	raw := strings.TrimSpace(o.URL)
+	switch {
+	case strings.Contains(raw, "://"):
+		parsed, err := redis.ParseURL(raw)
+		if err != nil {
+			// URL parse errors quote the URL, password included
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return nil, fmt.Errorf("invalid Redis URL %s: %w", redactURL(raw), err)
+		}
+		opts.Addrs = []string{parsed.Addr}
+		opts.Username = parsed.Username
+		opts.Password = parsed.Password
+		opts.DB = parsed.DB
+		opts.TLSConfig = parsed.TLSConfig
+	case raw != "":
+		for _, addr := range strings.Split(raw, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				opts.Addrs = append(opts.Addrs, addr)
+			}
+		}
+	}
+
+	if len(o.Addrs) > 0 {
+		opts.Addrs = o.Addrs
+	}
+	if len(opts.Addrs) == 0 {
+		opts.Addrs = []string{DefaultAddr}
+	}
+	if o.Username != "" {
+		opts.Username = o.Username
+	}
+	if o.Password != "" {
+		opts.Password = o.Password
+	}
+	if o.DB != 0 {
+		opts.DB = o.DB
+	}
+	if o.TLS && opts.TLSConfig == nil {
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if opts.TLSConfig != nil && o.TLSInsecureSkipVerify {
+		opts.TLSConfig.InsecureSkipVerify = true
+	}
+
+	switch o.mode() {
+	case ModeStandalone:
+		if len(opts.Addrs) > 1 {
+			return nil, fmt.Errorf("standalone Redis takes one address, got %d", len(opts.Addrs))
+		}
+	case ModeSentinel:
+		if opts.MasterName == "" {
+			return nil, errors.New("Redis sentinel mode requires a master name")
+		}
+	case ModeCluster:
+		if opts.DB != 0 {
+			return nil, errors.New("Redis cluster mode only supports database 0")
+		}
+	default:
+		return nil, fmt.Errorf("unknown Redis mode %q", o.Mode)
+	}
+
+	return opts, nil
+}
+
+// mode returns the configured mode, inferring it the way go-redis does when unset
+func (o Options) mode() string {
+	if o.Mode != "" {
+		return o.Mode
+	}
+	if o.MasterName != "" {
+		return ModeSentinel
+	}
+	if len(o.Addrs) > 1 || strings.Contains(o.URL, ",") {
+		return ModeCluster
+	}
+	return ModeStandalone
+}
+
+// newUniversalClient connects in the configured mode. Cluster mode is honoured
+// even with a single seed address, which go-redis would treat as standalone.
+func (o Options) newUniversalClient() (redis.UniversalClient, error) {
+	opts, err := o.universalOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.mode() {
+	case ModeSentinel:
+		return redis.NewFailoverClient(opts.Failover()), nil
+	case ModeCluster:
+		return redis.NewClusterClient(opts.Cluster()), nil
+	default:
+		return redis.NewClient(opts.Simple()), nil
+	}
+}
+
+// redactURL hides the password in a Redis URL so it can be logged
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "(unparseable URL)"
+	}
+	return parsed.Redacted()
+}
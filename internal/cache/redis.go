@@ -3,39 +3,77 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
 // RedisClient wraps the Redis client with caching functionality
 type RedisClient struct {
-	client *redis.Client
-	ctx    context.Context
+	client redis.UniversalClient
 }
 
-// NewRedisClient creates a new Redis client using REDIS_URL environment variable
+var (
+	sharedOnce   sync.Once
+	sharedClient *RedisClient
+)
+
+// Shared returns the process-wide client configured from REDIS_URL, created on
+// first use. Constructors that are not handed a client use it so the process
+// keeps a single connection pool.
+func Shared() *RedisClient {
+	sharedOnce.Do(func() {
+		sharedClient = NewRedisClient()
+	})
+	return sharedClient
+}
+
+// NewRedisClient creates a new Redis client using the REDIS_URL environment
+// variable, falling back to the default address when it is invalid
 func NewRedisClient() *RedisClient {
-	redisURL := os.Getenv("REDIS_URL")
-	if redisURL == "" {
-		redisURL = "localhost:6379"
+	client, err := NewRedisClientWithOptions(Options{URL: os.Getenv("REDIS_URL")})
+	if err != nil {
+		slog.Warn("Invalid REDIS_URL, using the default address", logging.KeyError, err)
+		return NewRedisClientWithAddr(DefaultAddr)
 	}
+	return client
+}
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr: redisURL,
-	})
+// NewRedisClientWithAddr creates a new Redis client for a specific address (for testing)
+func NewRedisClientWithAddr(addr string) *RedisClient {
+	return newRedisClient(redis.NewClient(&redis.Options{
+		Addr: addr,
+	}))
+}
+
+// NewRedisClientWithOptions creates a Redis client in standalone, sentinel or cluster mode
+func NewRedisClientWithOptions(opts Options) (*RedisClient, error) {
+	client, err := opts.newUniversalClient()
+	if err != nil {
+		return nil, err
+	}
+	return newRedisClient(client), nil
+}
 
-	return &RedisClient{
-		client: rdb,
-		ctx:    context.Background(),
+func newRedisClient(rdb redis.UniversalClient) *RedisClient {
+	// Commands get spans from the global tracer provider, a no-op until tracing is set up
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		slog.Warn("Failed to instrument Redis tracing", logging.KeyError, err)
 	}
+
+	return &RedisClient{client: rdb}
 }
 
 // Get retrieves a value from Redis and unmarshals it into the provided interface
-func (r *RedisClient) Get(key string, dest interface{}) error {
-	val, err := r.client.Get(r.ctx, key).Result()
+func (r *RedisClient) Get(ctx context.Context, key string, dest interface{}) error {
+	val, err := r.client.Get(ctx, key).Result()
 	if err != nil {
 		return err
 	}
@@ -44,31 +82,100 @@ func (r *RedisClient) Get(key string, dest interface{}) error {
 }
 
 // Set stores a value in Redis with default expiration
-func (r *RedisClient) Set(key string, value interface{}) error {
-	return r.SetWithTTL(key, value, 0)
+func (r *RedisClient) Set(ctx context.Context, key string, value interface{}) error {
+	return r.SetWithTTL(ctx, key, value, 0)
 }
 
 // SetWithTTL stores a value in Redis with specified TTL
-func (r *RedisClient) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+func (r *RedisClient) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	data, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal value: %w", err)
 	}
 
-	return r.client.Set(r.ctx, key, data, ttl).Err()
+	return r.client.Set(ctx, key, data, ttl).Err()
 }
 
 // Incr increments a counter and returns the new value
-func (r *RedisClient) Incr(key string) (int64, error) {
-	return r.client.Incr(r.ctx, key).Result()
+func (r *RedisClient) Incr(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, key).Result()
 }
 
 // Expire sets TTL on an existing key
-func (r *RedisClient) Expire(key string, ttl time.Duration) error {
-	return r.client.Expire(r.ctx, key, ttl).Err()
+func (r *RedisClient) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return r.client.Expire(ctx, key, ttl).Err()
+}
+
+// Del removes the given keys
+func (r *RedisClient) Del(ctx context.Context, keys ...string) error {
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// SAdd adds members to a set
+func (r *RedisClient) SAdd(ctx context.Context, key string, members ...interface{}) error {
+	return r.client.SAdd(ctx, key, members...).Err()
+}
+
+// SRem removes members from a set
+func (r *RedisClient) SRem(ctx context.Context, key string, members ...interface{}) error {
+	return r.client.SRem(ctx, key, members...).Err()
+}
+
+// SMembers returns all members of a set
+func (r *RedisClient) SMembers(ctx context.Context, key string) ([]string, error) {
+	return r.client.SMembers(ctx, key).Result()
+}
+
+// HGetAll returns all fields of a hash
+func (r *RedisClient) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return r.client.HGetAll(ctx, key).Result()
+}
+
+// RunScript executes a Lua script atomically, loading it into the script cache on first use
+func (r *RedisClient) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return script.Run(ctx, r.client, keys, args...).Result()
+}
+
+// Tx queues writes that are applied together by TxPipelined
+type Tx struct {
+	pipe redis.Pipeliner
+}
+
+// Set queues storing a value without expiration
+func (t *Tx) Set(ctx context.Context, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	return t.pipe.Set(ctx, key, data, 0).Err()
+}
+
+// SAdd queues adding members to a set
+func (t *Tx) SAdd(ctx context.Context, key string, members ...interface{}) error {
+	return t.pipe.SAdd(ctx, key, members...).Err()
+}
+
+// TxPipelined applies the writes queued by fn in a MULTI/EXEC transaction, so a
+// failed connection cannot apply only some of them. Nothing is sent when fn returns an error.
+func (r *RedisClient) TxPipelined(ctx context.Context, fn func(tx *Tx) error) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		return fn(&Tx{pipe: pipe})
+	})
+	return err
+}
+
+// Ping checks that Redis is reachable
+func (r *RedisClient) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
 }
 
 // Close closes the Redis connection
 func (r *RedisClient) Close() error {
 	return r.client.Close()
 }
+
+// IsMiss reports whether err means the requested key does not exist
+func IsMiss(err error) bool {
+	return errors.Is(err, redis.Nil)
+}
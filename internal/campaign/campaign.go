@@ -0,0 +1,125 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/cache"
+)
+
+// Status is the stage a campaign has reached in review
+type Status string
+
+const (
+	StatusDraft    Status = "draft"
+	StatusApproved Status = "approved"
+	StatusArchived Status = "archived"
+)
+
+// Valid reports whether s is a known status
+func (s Status) Valid() bool {
+	switch s {
+	case StatusDraft, StatusApproved, StatusArchived:
+		return true
+	}
+	return false
+}
+
+// DefaultChannel is used when the request did not name a marketing channel
+const DefaultChannel = "general"
+
+// Variant is one generated ad: a headline with its description and call to action
+type Variant struct {
+	Headline     string `json:"headline"`
+	Description  string `json:"description,omitempty"`
+	CallToAction string `json:"call_to_action,omitempty"`
+}
+
+// Campaign is generated marketing copy saved for review and reuse
+type Campaign struct {
+	ID             string    `json:"id"`
+	TenantID       string    `json:"tenant_id,omitempty"`
+	Product        string    `json:"product"`
+	Segments       []string  `json:"segments"`
+	SegmentSource  string    `json:"segment_source,omitempty"`
+	Variants       []Variant `json:"variants"`
+	Channel        string    `json:"channel"`
+	Status         Status    `json:"status"`
+	DuplicatedFrom string    `json:"duplicated_from,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Filter narrows the campaigns returned by List; zero values match everything
+type Filter struct {
+	Status  Status
+	Channel string
+	Limit   int
+}
+
+// Matches reports whether the campaign passes the filter's status and channel
+func (f Filter) Matches(c *Campaign) bool {
+	return (f.Status == "" || c.Status == f.Status) && (f.Channel == "" || c.Channel == f.Channel)
+}
+
+// Store persists campaigns, scoped to the tenant in ctx. List returns the
+// newest campaigns first.
+type Store interface {
+	Save(ctx context.Context, c *Campaign) error
+	Get(ctx context.Context, id string) (*Campaign, error)
+	List(ctx context.Context, filter Filter) ([]*Campaign, error)
+	Delete(ctx context.Context, id string) error
+}
+
+var (
+	// ErrCampaignNotFound is returned when no campaign matches the ID for the tenant
+	ErrCampaignNotFound = errors.New("campaign not found")
+	// ErrInvalidStatus is returned when a status is not draft, approved or archived
+	ErrInvalidStatus = errors.New("invalid campaign status")
+)
+
+// Variants pairs generated headlines with descriptions, reusing the shorter
+// list when the model returned more of one than the other
+func Variants(headlines, descriptions []string, callToAction string) []Variant {
+	n := max(len(headlines), len(descriptions))
+	variants := make([]Variant, n)
+	for i := range variants {
+		variants[i].CallToAction = callToAction
+		if len(headlines) > 0 {
+			variants[i].Headline = headlines[i%len(headlines)]
+		}
+		if len(descriptions) > 0 {
+			variants[i].Description = descriptions[i%len(descriptions)]
+		}
+	}
+	return variants
+}
+
+// Stores that NewStore can create
+const (
+	StoreRedis    = "redis"
+	StoreSQLite   = DialectSQLite
+	StorePostgres = DialectPostgres
+)
+
+// Options selects where campaigns are persisted
+type Options struct {
+	Store       string // redis, sqlite or postgres
+	DatabaseURL string // file path or DSN for the SQL stores
+}
+
+// NewStore creates the store selected by opts; the Redis store shares redisClient
+func NewStore(ctx context.Context, opts Options, redisClient *cache.RedisClient) (Store, error) {
+	switch opts.Store {
+	case "", StoreRedis:
+		return NewRedisStore(redisClient), nil
+	case StoreSQLite, StorePostgres:
+		if opts.DatabaseURL == "" {
+			return nil, fmt.Errorf("a database URL is required for the %s campaign store", opts.Store)
+		}
+		return OpenSQLStore(ctx, opts.Store, opts.DatabaseURL)
+	}
+	return nil, fmt.Errorf("unknown campaign store %q", opts.Store)
+}
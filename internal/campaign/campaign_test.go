@@ -0,0 +1,190 @@
+package campaign
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStores(t *testing.T) map[string]Store {
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	t.Cleanup(func() { redisClient.Close() })
+
+	sqlStore, err := OpenSQLStore(context.Background(), DialectSQLite, filepath.Join(t.TempDir(), "campaigns.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlStore.Close() })
+
+	return map[string]Store{
+		"redis":  NewRedisStore(redisClient),
+		"sqlite": sqlStore,
+	}
+}
+
+// testService returns a service whose clock advances a second per call so
+// that listing order is deterministic
+func testService(store Store) *Service {
+	service := NewService(store)
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	service.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	return service
+}
+
+func newCampaign() *Campaign {
+	return &Campaign{
+		Product:       "Trail running shoes",
+		Segments:      []string{"Outdoor Enthusiasts", "Fitness Fans"},
+		SegmentSource: "qloo",
+		Variants:      Variants([]string{"Run further", "Grip every trail"}, []string{"Lightweight and tough"}, "Shop now"),
+		Channel:       "instagram",
+	}
+}
+
+func TestService_CreateAndGet(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"})
+			service := testService(store)
+
+			created, err := service.Create(ctx, newCampaign())
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(created.ID, "cmp_"))
+			assert.Equal(t, "acme", created.TenantID)
+			assert.Equal(t, StatusDraft, created.Status)
+			assert.Equal(t, created.CreatedAt, created.UpdatedAt)
+
+			got, err := service.Get(ctx, created.ID)
+			require.NoError(t, err)
+			assert.Equal(t, created, got)
+
+			other := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "globex"})
+			_, err = service.Get(other, created.ID)
+			assert.ErrorIs(t, err, ErrCampaignNotFound)
+		})
+	}
+}
+
+func TestService_ListFiltersAndOrders(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"})
+			service := testService(store)
+
+			first, err := service.Create(ctx, newCampaign())
+			require.NoError(t, err)
+			email := newCampaign()
+			email.Channel = "email"
+			second, err := service.Create(ctx, email)
+			require.NoError(t, err)
+			_, err = service.SetStatus(ctx, first.ID, StatusApproved)
+			require.NoError(t, err)
+
+			list, err := service.List(ctx, Filter{})
+			require.NoError(t, err)
+			require.Len(t, list, 2)
+			assert.Equal(t, second.ID, list[0].ID)
+			assert.Equal(t, first.ID, list[1].ID)
+
+			list, err = service.List(ctx, Filter{Status: StatusApproved})
+			require.NoError(t, err)
+			require.Len(t, list, 1)
+			assert.Equal(t, first.ID, list[0].ID)
+
+			list, err = service.List(ctx, Filter{Channel: "email"})
+			require.NoError(t, err)
+			require.Len(t, list, 1)
+			assert.Equal(t, second.ID, list[0].ID)
+
+			list, err = service.List(ctx, Filter{Limit: 1})
+			require.NoError(t, err)
+			assert.Len(t, list, 1)
+
+			list, err = service.List(tenant.WithTenant(context.Background(), tenant.Tenant{ID: "globex"}), Filter{})
+			require.NoError(t, err)
+			assert.Empty(t, list)
+
+			_, err = service.List(ctx, Filter{Status: "published"})
+			assert.ErrorIs(t, err, ErrInvalidStatus)
+		})
+	}
+}
+
+func TestService_StatusDuplicateAndDelete(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"})
+			service := testService(store)
+
+			original, err := service.Create(ctx, newCampaign())
+			require.NoError(t, err)
+
+			approved, err := service.SetStatus(ctx, original.ID, StatusApproved)
+			require.NoError(t, err)
+			assert.Equal(t, StatusApproved, approved.Status)
+			assert.True(t, approved.UpdatedAt.After(approved.CreatedAt))
+
+			_, err = service.SetStatus(ctx, original.ID, "published")
+			assert.ErrorIs(t, err, ErrInvalidStatus)
+			_, err = service.SetStatus(ctx, "cmp_missing", StatusArchived)
+			assert.ErrorIs(t, err, ErrCampaignNotFound)
+
+			duplicate, err := service.Duplicate(ctx, original.ID)
+			require.NoError(t, err)
+			assert.NotEqual(t, original.ID, duplicate.ID)
+			assert.Equal(t, original.ID, duplicate.DuplicatedFrom)
+			assert.Equal(t, StatusDraft, duplicate.Status)
+			assert.Equal(t, original.Variants, duplicate.Variants)
+
+			require.NoError(t, service.Delete(ctx, original.ID))
+			_, err = service.Get(ctx, original.ID)
+			assert.ErrorIs(t, err, ErrCampaignNotFound)
+			assert.ErrorIs(t, service.Delete(ctx, original.ID), ErrCampaignNotFound)
+
+			list, err := service.List(ctx, Filter{})
+			require.NoError(t, err)
+			require.Len(t, list, 1)
+			assert.Equal(t, duplicate.ID, list[0].ID)
+		})
+	}
+}
+
+func TestVariants(t *testing.T) {
+	variants := Variants([]string{"A", "B", "C"}, []string{"x"}, "Buy")
+	assert.Equal(t, []Variant{
+		{Headline: "A", Description: "x", CallToAction: "Buy"},
+		{Headline: "B", Description: "x", CallToAction: "Buy"},
+		{Headline: "C", Description: "x", CallToAction: "Buy"},
+	}, variants)
+	assert.Empty(t, Variants(nil, nil, "Buy"))
+}
+
+func TestSQLStore_Rebind(t *testing.T) {
+	s := &SQLStore{dialect: DialectPostgres}
+	assert.Equal(t, "SELECT 1 WHERE a = $1 AND b = $2", s.rebind("SELECT 1 WHERE a = ? AND b = ?"))
+
+	s.dialect = DialectSQLite
+	assert.Equal(t, "SELECT 1 WHERE a = ?", s.rebind("SELECT 1 WHERE a = ?"))
+}
+
+func TestNewStore(t *testing.T) {
+	store, err := NewStore(context.Background(), Options{Store: StoreSQLite, DatabaseURL: ":memory:"}, nil)
+	require.NoError(t, err)
+	assert.NoError(t, NewService(store).Close())
+
+	_, err = NewStore(context.Background(), Options{Store: StorePostgres}, nil)
+	assert.ErrorContains(t, err, "database URL is required")
+
+	_, err = NewStore(context.Background(), Options{Store: "mongo"}, nil)
+	assert.ErrorContains(t, err, `unknown campaign store "mongo"`)
+}
@@ -0,0 +1,94 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/tenant"
+)
+
+// RedisStore stores campaigns in Redis with a per-tenant index of IDs
+type RedisStore struct {
+	redisClient *cache.RedisClient
+}
+
+// NewRedisStore creates a new Redis-backed campaign store
+func NewRedisStore(redisClient *cache.RedisClient) *RedisStore {
+	return &RedisStore{redisClient: redisClient}
+}
+
+// Save stores the campaign and adds it to the tenant's index
+func (s *RedisStore) Save(ctx context.Context, c *Campaign) error {
+	if err := s.redisClient.Set(ctx, campaignKey(ctx, c.ID), c); err != nil {
+		return err
+	}
+	return s.redisClient.SAdd(ctx, indexKey(ctx), c.ID)
+}
+
+// Get returns the campaign with the given ID
+func (s *RedisStore) Get(ctx context.Context, id string) (*Campaign, error) {
+	var c Campaign
+	if err := s.redisClient.Get(ctx, campaignKey(ctx, id), &c); err != nil {
+		if cache.IsMiss(err) {
+			return nil, ErrCampaignNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// List returns the tenant's campaigns that match the filter, newest first
+func (s *RedisStore) List(ctx context.Context, filter Filter) ([]*Campaign, error) {
+	ids, err := s.redisClient.SMembers(ctx, indexKey(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var campaigns []*Campaign
+	for _, id := range ids {
+		c, err := s.Get(ctx, id)
+		if errors.Is(err, ErrCampaignNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if filter.Matches(c) {
+			campaigns = append(campaigns, c)
+		}
+	}
+
+	sort.Slice(campaigns, func(i, j int) bool {
+		if !campaigns[i].CreatedAt.Equal(campaigns[j].CreatedAt) {
+			return campaigns[i].CreatedAt.After(campaigns[j].CreatedAt)
+		}
+		return campaigns[i].ID < campaigns[j].ID
+	})
+	if filter.Limit > 0 && len(campaigns) > filter.Limit {
+		campaigns = campaigns[:filter.Limit]
+	}
+	return campaigns, nil
+}
+
+// Delete removes the campaign and its index entry
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	if err := s.redisClient.Del(ctx, campaignKey(ctx, id)); err != nil {
+		return err
+	}
+	return s.redisClient.SRem(ctx, indexKey(ctx), id)
+}
+
+// campaignKey is the Redis key holding one campaign
+func campaignKey(ctx context.Context, id string) string {
+	return tenant.ScopedKey(ctx, "campaign:"+id)
+}
+
+// indexKey is the Redis set of the tenant's campaign IDs
+func indexKey(ctx context.Context) string {
+	return tenant.ScopedKey(ctx, "campaigns")
+}
@@ -0,0 +1,121 @@
+package campaign
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/tenant"
+)
+
+// Service saves, lists and manages campaigns for the tenant in ctx
+type Service struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewService creates a new campaign service backed by the given store
+func NewService(store Store) *Service {
+	return &Service{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Create saves a new draft campaign for the tenant in ctx
+func (s *Service) Create(ctx context.Context, c *Campaign) (*Campaign, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	created := *c
+	created.ID = id
+	created.TenantID = tenant.ID(ctx)
+	created.Status = StatusDraft
+	if created.Channel == "" {
+		created.Channel = DefaultChannel
+	}
+	created.CreatedAt = s.now().UTC()
+	created.UpdatedAt = created.CreatedAt
+
+	if err := s.store.Save(ctx, &created); err != nil {
+		return nil, fmt.Errorf("failed to save campaign: %w", err)
+	}
+	return &created, nil
+}
+
+// Get returns the campaign with the given ID
+func (s *Service) Get(ctx context.Context, id string) (*Campaign, error) {
+	return s.store.Get(ctx, id)
+}
+
+// List returns the tenant's campaigns, newest first
+func (s *Service) List(ctx context.Context, filter Filter) ([]*Campaign, error) {
+	if filter.Status != "" && !filter.Status.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, filter.Status)
+	}
+	return s.store.List(ctx, filter)
+}
+
+// SetStatus moves a campaign to draft, approved or archived
+func (s *Service) SetStatus(ctx context.Context, id string, status Status) (*Campaign, error) {
+	if !status.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, status)
+	}
+
+	c, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status == status {
+		return c, nil
+	}
+
+	c.Status = status
+	c.UpdatedAt = s.now().UTC()
+	if err := s.store.Save(ctx, c); err != nil {
+		return nil, fmt.Errorf("failed to save campaign: %w", err)
+	}
+	return c, nil
+}
+
+// Duplicate copies a campaign into a new draft so it can be edited without
+// touching the original
+func (s *Service) Duplicate(ctx context.Context, id string) (*Campaign, error) {
+	original, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := *original
+	copied.Segments = append([]string(nil), original.Segments...)
+	copied.Variants = append([]Variant(nil), original.Variants...)
+	copied.DuplicatedFrom = original.ID
+	return s.Create(ctx, &copied)
+}
+
+// Delete removes a campaign permanently
+func (s *Service) Delete(ctx context.Context, id string) error {
+	return s.store.Delete(ctx, id)
+}
+
+// Close releases the store's connections when it holds any
+func (s *Service) Close() error {
+	if closer, ok := s.store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// newID returns a random campaign ID
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate campaign id: %w", err)
+	}
+	return "cmp_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,246 @@
+package campaign
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	_ "modernc.org/sqlite"
+)
+
+// SQL dialects supported by SQLStore
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// drivers maps each dialect to its registered database/sql driver
+var drivers = map[string]string{
+	DialectSQLite:   "sqlite",
+	DialectPostgres: "pgx",
+}
+
+// SQLStore stores campaigns in a SQLite or Postgres table
+type SQLStore struct {
+	db      *sql.DB
+	dialect string
+}
+
+// OpenSQLStore connects to the database at dsn and creates the campaigns
+// table when it does not exist
+func OpenSQLStore(ctx context.Context, dialect, dsn string) (*SQLStore, error) {
+	driver, ok := drivers[dialect]
+	if !ok {
+		return nil, fmt.Errorf("unknown campaign database dialect %q", dialect)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open campaign database: %w", err)
+	}
+	if dialect == DialectSQLite {
+		// SQLite allows one writer; a single connection also keeps :memory: databases shared
+		db.SetMaxOpenConns(1)
+	}
+
+	store, err := NewSQLStore(ctx, db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// NewSQLStore creates a store over an open database, creating the campaigns
+// table when it does not exist
+func NewSQLStore(ctx context.Context, db *sql.DB, dialect string) (*SQLStore, error) {
+	if _, ok := drivers[dialect]; !ok {
+		return nil, fmt.Errorf("unknown campaign database dialect %q", dialect)
+	}
+
+	s := &SQLStore{db: db, dialect: dialect}
+	if err := s.migrate(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create campaigns table: %w", err)
+	}
+	return s, nil
+}
+
+// migrate creates the campaigns table and its listing index
+func (s *SQLStore) migrate(ctx context.Context) error {
+	timestamp := "DATETIME"
+	if s.dialect == DialectPostgres {
+		timestamp = "TIMESTAMPTZ"
+	}
+
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS campaigns (
+			tenant_id       TEXT NOT NULL,
+			id              TEXT NOT NULL,
+			product         TEXT NOT NULL,
+			segments        TEXT NOT NULL,
+			segment_source  TEXT NOT NULL,
+			variants        TEXT NOT NULL,
+			channel         TEXT NOT NULL,
+			status          TEXT NOT NULL,
+			duplicated_from TEXT NOT NULL,
+			created_at      ` + timestamp + ` NOT NULL,
+			updated_at      ` + timestamp + ` NOT NULL,
+			PRIMARY KEY (tenant_id, id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS campaigns_tenant_created ON campaigns (tenant_id, created_at)`,
+	}
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save inserts the campaign or replaces the stored copy
+func (s *SQLStore) Save(ctx context.Context, c *Campaign) error {
+	segments, err := json.Marshal(c.Segments)
+	if err != nil {
+		return err
+	}
+	variants, err := json.Marshal(c.Variants)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, s.rebind(`
+		INSERT INTO campaigns (tenant_id, id, product, segments, segment_source, variants, channel, status, duplicated_from, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tenant_id, id) DO UPDATE SET
+			product = excluded.product,
+			segments = excluded.segments,
+			segment_source = excluded.segment_source,
+			variants = excluded.variants,
+			channel = excluded.channel,
+			status = excluded.status,
+			duplicated_from = excluded.duplicated_from,
+			updated_at = excluded.updated_at`),
+		tenant.ID(ctx), c.ID, c.Product, string(segments), c.SegmentSource, string(variants),
+		c.Channel, string(c.Status), c.DuplicatedFrom, c.CreatedAt.UTC(), c.UpdatedAt.UTC(),
+	)
+	return err
+}
+
+// Get returns the campaign with the given ID
+func (s *SQLStore) Get(ctx context.Context, id string) (*Campaign, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(selectCampaigns+` WHERE tenant_id = ? AND id = ?`), tenant.ID(ctx), id)
+	c, err := scanCampaign(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCampaignNotFound
+	}
+	return c, err
+}
+
+// List returns the tenant's campaigns that match the filter, newest first
+func (s *SQLStore) List(ctx context.Context, filter Filter) ([]*Campaign, error) {
+	query := selectCampaigns + ` WHERE tenant_id = ?`
+	args := []any{tenant.ID(ctx)}
+	if filter.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, string(filter.Status))
+	}
+	if filter.Channel != "" {
+		query += ` AND channel = ?`
+		args = append(args, filter.Channel)
+	}
+	query += ` ORDER BY created_at DESC, id`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var campaigns []*Campaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, c)
+	}
+	return campaigns, rows.Err()
+}
+
+// Delete removes the campaign with the given ID
+func (s *SQLStore) Delete(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM campaigns WHERE tenant_id = ? AND id = ?`), tenant.ID(ctx), id)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrCampaignNotFound
+	}
+	return nil
+}
+
+// Close closes the database connection pool
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// rebind rewrites ? placeholders as $1, $2... for Postgres
+func (s *SQLStore) rebind(query string) string {
+	if s.dialect != DialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+const selectCampaigns = `SELECT tenant_id, id, product, segments, segment_source, variants, channel, status, duplicated_from, created_at, updated_at FROM campaigns`
+
+// scanCampaign reads one row selected by selectCampaigns
+func scanCampaign(row interface{ Scan(...any) error }) (*Campaign, error) {
+	var (
+		c                  Campaign
+		segments, variants string
+		status             string
+		createdAt          time.Time
+		updatedAt          time.Time
+	)
+	if err := row.Scan(&c.TenantID, &c.ID, &c.Product, &segments, &c.SegmentSource, &variants,
+		&c.Channel, &status, &c.DuplicatedFrom, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(segments), &c.Segments); err != nil {
+		return nil, fmt.Errorf("failed to parse campaign segments: %w", err)
+	}
+	if err := json.Unmarshal([]byte(variants), &c.Variants); err != nil {
+		return nil, fmt.Errorf("failed to parse campaign variants: %w", err)
+	}
+	c.Status = Status(status)
+	c.CreatedAt = createdAt.UTC()
+	c.UpdatedAt = updatedAt.UTC()
+	return &c, nil
+}
@@ -0,0 +1,559 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/breaker"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/campaign"
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/marketplace/amazon"
+	"github.com/jesee-kuya/blue/internal/marketplace/ebay"
+	"github.com/jesee-kuya/blue/internal/middleware"
+	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/jesee-kuya/blue/internal/usage"
+)
+
+// Config holds every setting the service reads at startup
+type Config struct {
+	Server         Server               `json:"server"`
+	Redis          Redis                `json:"redis"`
+	Auth           Auth                 `json:"auth"`
+	LLM            LLM                  `json:"llm"`
+	Qloo           Qloo                 `json:"qloo"`
+	Amazon         Amazon               `json:"amazon"`
+	Ebay           Ebay                 `json:"ebay"`
+	RateLimits     map[string]RateLimit `json:"rate_limits"` // keyed by policy name
+	Plans          map[string]Plan      `json:"plans"`       // keyed by plan name
+	Segments       Segments             `json:"segments"`
+	Campaigns      Campaigns            `json:"campaigns"`
+	Breakers       Breakers             `json:"breakers"`
+	ToolPolicyFile string               `json:"tool_policy_file"`
+}
+
+// Server configures the HTTP listener. Zero timeouts disable the limit.
+type Server struct {
+	Addr              string   `json:"addr"`
+	ReadTimeout       Duration `json:"read_timeout"`
+	ReadHeaderTimeout Duration `json:"read_header_timeout"`
+	WriteTimeout      Duration `json:"write_timeout"` // covers the whole response, including streamed ones
+	IdleTimeout       Duration `json:"idle_timeout"`
+	ShutdownTimeout   Duration `json:"shutdown_timeout"` // how long in-flight requests may drain
+	MaxHeaderBytes    int      `json:"max_header_bytes"`
+	MaxBodyBytes      int64    `json:"max_body_bytes"`
+	TLSCertFile       string   `json:"tls_cert_file"` // TLS is served when both files are set
+	TLSKeyFile        string   `json:"tls_key_file"`
+}
+
+// TLS reports whether the server should serve TLS
+func (s Server) TLS() bool {
+	return s.TLSCertFile != "" && s.TLSKeyFile != ""
+}
+
+// Redis configures the shared Redis connection. URL may be a redis:// or
+// rediss:// URL or comma separated host:port addresses.
+type Redis struct {
+	URL                   string   `json:"url"`
+	Mode                  string   `json:"mode"` // standalone, sentinel or cluster
+	Addrs                 []string `json:"addrs"`
+	MasterName            string   `json:"master_name"`
+	Username              string   `json:"username"`
+	Password              string   `json:"password"`
+	DB                    int      `json:"db"`
+	TLS                   bool     `json:"tls"`
+	TLSInsecureSkipVerify bool     `json:"tls_insecure_skip_verify"`
+	PoolSize              int      `json:"pool_size"`
+	MinIdleConns          int      `json:"min_idle_conns"`
+	DialTimeout           Duration `json:"dial_timeout"`
+	ReadTimeout           Duration `json:"read_timeout"`
+	WriteTimeout          Duration `json:"write_timeout"`
+	PoolTimeout           Duration `json:"pool_timeout"`
+}
+
+// Auth configures API key storage
+type Auth struct {
+	APIKeysFile string `json:"api_keys_file"` // keys are kept in Redis when empty
+	AdminAPIKey string `json:"admin_api_key"` // bootstraps the first admin key
+}
+
+// LLM configures the model provider, request timeout and spend tracking
+type LLM struct {
+	Provider       string       `json:"provider"`
+	APIKey         string       `json:"api_key"`
+	BaseURL        string       `json:"base_url"`
+	APIVersion     string       `json:"api_version"`
+	Model          string       `json:"model"`
+	FakeScript     string       `json:"fake_script"`
+	Timeout        Duration     `json:"timeout"`
+	PriceTableFile string       `json:"price_table_file"`
+	Budget         usage.Budget `json:"budget"`
+}
+
+// Qloo configures the Qloo client
+type Qloo struct {
+	APIKey      string   `json:"api_key"`
+	BaseURL     string   `json:"base_url"`
+	Timeout     Duration `json:"timeout"`
+	CacheTTL    Duration `json:"cache_ttl"`
+	MaxSegments int      `json:"max_segments"`
+	MinAffinity float64  `json:"min_affinity"` // between 0 and 1
+	MaxEntities int      `json:"max_entities"` // per entity search or insights request
+}
+
+// Segments configures local audience segmentation, used when Qloo is unavailable
+type Segments struct {
+	TaxonomyFile string `json:"taxonomy_file"` // built-in taxonomy when empty
+	LLMInference bool   `json:"llm_inference"` // infer segments with the model when no category matches
+	Personas     bool   `json:"personas"`      // build marketing personas from the segments with the model
+}
+
+// Campaigns configures where generated campaigns are persisted
+type Campaigns struct {
+	Store       string `json:"store"`        // redis, sqlite or postgres
+	DatabaseURL string `json:"database_url"` // file path or DSN for sqlite and postgres
+}
+
+// Breakers configures the circuit breakers guarding upstreams
+type Breakers struct {
+	Store            string                     `json:"store"` // memory, or redis to share state between instances
+	FailureThreshold int                        `json:"failure_threshold"`
+	OpenTimeout      Duration                   `json:"open_timeout"`
+	HalfOpenProbes   int                        `json:"half_open_probes"`
+	Upstreams        map[string]BreakerOverride `json:"upstreams"` // keyed by upstream name
+}
+
+// BreakerOverride replaces the breaker settings for one upstream; zero values
+// keep the defaults
+type BreakerOverride struct {
+	FailureThreshold int      `json:"failure_threshold"`
+	OpenTimeout      Duration `json:"open_timeout"`
+	HalfOpenProbes   int      `json:"half_open_probes"`
+}
+
+// Amazon configures the Amazon client
+type Amazon struct {
+	AccessKey string   `json:"access_key"`
+	SecretKey string   `json:"secret_key"`
+	Region    string   `json:"region"`
+	Mock      bool     `json:"mock"`
+	CacheTTL  Duration `json:"cache_ttl"`
+}
+
+// Ebay configures the eBay client
+type Ebay struct {
+	APIKey   string   `json:"api_key"`
+	BaseURL  string   `json:"base_url"`
+	Timeout  Duration `json:"timeout"`
+	CacheTTL Duration `json:"cache_ttl"`
+}
+
+// RateLimit configures a rate limit policy
+type RateLimit struct {
+	Algorithm middleware.Algorithm `json:"algorithm"`
+	Limit     int                  `json:"limit"`
+	Window    Duration             `json:"window"`
+	Burst     int                  `json:"burst,omitempty"`
+}
+
+// Plan configures a subscription tier's quotas; zero means unlimited
+type Plan struct {
+	PerMinute int `json:"per_minute"`
+	PerDay    int `json:"per_day"`
+}
+
+// Duration is a time.Duration written as a string such as "30s" in config files
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return errors.New(`duration must be a string such as "30s"`)
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Default returns the configuration used when nothing is overridden
+func Default() *Config {
+	cfg := &Config{
+		Server: Server{
+			Addr:              ":8080",
+			ReadTimeout:       Duration(30 * time.Second),
+			ReadHeaderTimeout: Duration(10 * time.Second),
+			WriteTimeout:      Duration(2 * time.Minute),
+			IdleTimeout:       Duration(2 * time.Minute),
+			ShutdownTimeout:   Duration(30 * time.Second),
+			MaxHeaderBytes:    1 << 20,
+			MaxBodyBytes:      1 << 20,
+		},
+		Redis: Redis{
+			URL:          cache.DefaultAddr,
+			DialTimeout:  Duration(5 * time.Second),
+			ReadTimeout:  Duration(3 * time.Second),
+			WriteTimeout: Duration(3 * time.Second),
+		},
+		LLM: LLM{
+			Provider: llm.ProviderOpenAI,
+			Model:    llm.DefaultModel,
+			Timeout:  Duration(30 * time.Second),
+		},
+		Qloo: Qloo{
+			BaseURL:     qloo.DefaultBaseURL,
+			Timeout:     Duration(qloo.DefaultTimeout),
+			CacheTTL:    Duration(qloo.DefaultCacheTTL),
+			MaxSegments: qloo.DefaultMaxSegments,
+			MaxEntities: qloo.DefaultMaxEntities,
+		},
+		Campaigns: Campaigns{
+			Store: campaign.StoreRedis,
+		},
+		Breakers: Breakers{
+			Store:            breaker.StoreMemory,
+			FailureThreshold: breaker.DefaultSettings.FailureThreshold,
+			OpenTimeout:      Duration(breaker.DefaultSettings.OpenTimeout),
+			HalfOpenProbes:   breaker.DefaultSettings.HalfOpenProbes,
+		},
+		Amazon: Amazon{
+			Region:   amazon.DefaultRegion,
+			Mock:     true,
+			CacheTTL: Duration(amazon.DefaultCacheTTL),
+		},
+		Ebay: Ebay{
+			BaseURL:  ebay.DefaultBaseURL,
+			Timeout:  Duration(ebay.DefaultTimeout),
+			CacheTTL: Duration(ebay.DefaultCacheTTL),
+		},
+		RateLimits: make(map[string]RateLimit),
+		Plans:      make(map[string]Plan),
+	}
+	for _, policy := range []middleware.RateLimitPolicy{middleware.DefaultRateLimitPolicy, middleware.MarketingRateLimitPolicy} {
+		cfg.RateLimits[policy.Name] = RateLimit{
+			Algorithm: policy.Algorithm,
+			Limit:     policy.Limit,
+			Window:    Duration(policy.Window),
+			Burst:     policy.Burst,
+		}
+	}
+	for name, plan := range middleware.DefaultPlans {
+		cfg.Plans[name] = Plan{PerMinute: plan.PerMinute, PerDay: plan.PerDay}
+	}
+	return cfg
+}
+
+// Load reads the configuration from the JSON file at path, when set, over the
+// defaults, applies environment overrides and validates the result. Rate limit
+// policies and plans in the file are merged with the defaults by name.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Server.Addr == "" {
+		invalid("server.addr", "must not be empty")
+	}
+	for field, d := range map[string]Duration{
+		"server.read_timeout":        c.Server.ReadTimeout,
+		"server.read_header_timeout": c.Server.ReadHeaderTimeout,
+		"server.write_timeout":       c.Server.WriteTimeout,
+		"server.idle_timeout":        c.Server.IdleTimeout,
+	} {
+		if d < 0 {
+			invalid(field, "must not be negative")
+		}
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		invalid("server.shutdown_timeout", "must be a positive duration")
+	}
+	if c.Server.MaxHeaderBytes <= 0 {
+		invalid("server.max_header_bytes", "must be positive")
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		invalid("server.max_body_bytes", "must be positive")
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		invalid("server.tls_cert_file", "must be set together with server.tls_key_file")
+	}
+	for field, path := range map[string]string{
+		"server.tls_cert_file": c.Server.TLSCertFile,
+		"server.tls_key_file":  c.Server.TLSKeyFile,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			invalid(field, "%v", err)
+		}
+	}
+	if c.Redis.URL == "" && len(c.Redis.Addrs) == 0 {
+		invalid("redis.url", "must not be empty")
+	}
+	if err := c.RedisOptions().Validate(); err != nil {
+		invalid("redis", "%v", err)
+	}
+	if c.Redis.PoolSize < 0 || c.Redis.MinIdleConns < 0 {
+		invalid("redis.pool_size", "pool sizes must not be negative")
+	}
+
+	switch c.LLM.Provider {
+	case llm.ProviderOpenAI, llm.ProviderFake:
+	case llm.ProviderOpenAICompatible, llm.ProviderAzure:
+		if c.LLM.BaseURL == "" {
+			invalid("llm.base_url", "is required for the %s provider", c.LLM.Provider)
+		}
+	default:
+		invalid("llm.provider", "unknown provider %q", c.LLM.Provider)
+	}
+	if c.LLM.Model == "" {
+		invalid("llm.model", "must not be empty")
+	}
+	if c.LLM.Budget.DailyUSD < 0 || c.LLM.Budget.MonthlyUSD < 0 {
+		invalid("llm.budget", "must not be negative")
+	}
+
+	for field, d := range map[string]Duration{
+		"llm.timeout":      c.LLM.Timeout,
+		"qloo.timeout":     c.Qloo.Timeout,
+		"qloo.cache_ttl":   c.Qloo.CacheTTL,
+		"amazon.cache_ttl": c.Amazon.CacheTTL,
+		"ebay.timeout":     c.Ebay.Timeout,
+		"ebay.cache_ttl":   c.Ebay.CacheTTL,
+	} {
+		if d <= 0 {
+			invalid(field, "must be a positive duration")
+		}
+	}
+	if c.Qloo.MaxSegments <= 0 {
+		invalid("qloo.max_segments", "must be positive")
+	}
+	if c.Qloo.MinAffinity < 0 || c.Qloo.MinAffinity > 1 {
+		invalid("qloo.min_affinity", "must be between 0 and 1")
+	}
+	if c.Qloo.MaxEntities <= 0 {
+		invalid("qloo.max_entities", "must be positive")
+	}
+	switch c.Campaigns.Store {
+	case campaign.StoreRedis:
+	case campaign.StoreSQLite, campaign.StorePostgres:
+		if c.Campaigns.DatabaseURL == "" {
+			invalid("campaigns.database_url", "is required for the %s store", c.Campaigns.Store)
+		}
+	default:
+		invalid("campaigns.store", "unknown store %q", c.Campaigns.Store)
+	}
+	switch c.Breakers.Store {
+	case breaker.StoreMemory, breaker.StoreRedis:
+	default:
+		invalid("breakers.store", "unknown store %q", c.Breakers.Store)
+	}
+	if c.Breakers.FailureThreshold <= 0 {
+		invalid("breakers.failure_threshold", "must be positive")
+	}
+	if c.Breakers.OpenTimeout <= 0 {
+		invalid("breakers.open_timeout", "must be a positive duration")
+	}
+	if c.Breakers.HalfOpenProbes <= 0 {
+		invalid("breakers.half_open_probes", "must be positive")
+	}
+	for name, override := range c.Breakers.Upstreams {
+		if override.FailureThreshold < 0 || override.OpenTimeout < 0 || override.HalfOpenProbes < 0 {
+			invalid("breakers.upstreams."+name, "settings must not be negative")
+		}
+	}
+	if !c.Amazon.Mock && (c.Amazon.AccessKey == "" || c.Amazon.SecretKey == "") {
+		invalid("amazon", "access and secret keys are required unless mock is enabled")
+	}
+
+	for _, name := range []string{middleware.DefaultRateLimitPolicy.Name, middleware.MarketingRateLimitPolicy.Name} {
+		if _, ok := c.RateLimits[name]; !ok {
+			invalid("rate_limits", "missing the %s policy", name)
+		}
+	}
+	for name, limit := range c.RateLimits {
+		field := "rate_limits." + name
+		switch limit.Algorithm {
+		case middleware.AlgorithmFixedWindow, middleware.AlgorithmSlidingWindow, middleware.AlgorithmSlidingLog, middleware.AlgorithmTokenBucket:
+		default:
+			invalid(field+".algorithm", "unknown algorithm %q", limit.Algorithm)
+		}
+		if limit.Limit <= 0 {
+			invalid(field+".limit", "must be positive")
+		}
+		if limit.Window <= 0 {
+			invalid(field+".window", "must be a positive duration")
+		}
+		if limit.Burst < 0 {
+			invalid(field+".burst", "must not be negative")
+		}
+	}
+
+	if _, ok := c.Plans[middleware.AnonymousPlan]; !ok {
+		invalid("plans", "missing the %s plan", middleware.AnonymousPlan)
+	}
+	for name, plan := range c.Plans {
+		if plan.PerMinute < 0 || plan.PerDay < 0 {
+			invalid("plans."+name, "quotas must not be negative")
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// ProviderConfig returns the LLM provider settings
+func (c *Config) ProviderConfig() llm.ProviderConfig {
+	return llm.ProviderConfig{
+		Provider:   c.LLM.Provider,
+		APIKey:     c.LLM.APIKey,
+		BaseURL:    c.LLM.BaseURL,
+		APIVersion: c.LLM.APIVersion,
+		Model:      c.LLM.Model,
+		FakeScript: c.LLM.FakeScript,
+	}
+}
+
+// RedisOptions returns the Redis connection settings
+func (c *Config) RedisOptions() cache.Options {
+	return cache.Options{
+		URL:                   c.Redis.URL,
+		Mode:                  c.Redis.Mode,
+		Addrs:                 c.Redis.Addrs,
+		MasterName:            c.Redis.MasterName,
+		Username:              c.Redis.Username,
+		Password:              c.Redis.Password,
+		DB:                    c.Redis.DB,
+		TLS:                   c.Redis.TLS,
+		TLSInsecureSkipVerify: c.Redis.TLSInsecureSkipVerify,
+		PoolSize:              c.Redis.PoolSize,
+		MinIdleConns:          c.Redis.MinIdleConns,
+		DialTimeout:           time.Duration(c.Redis.DialTimeout),
+		ReadTimeout:           time.Duration(c.Redis.ReadTimeout),
+		WriteTimeout:          time.Duration(c.Redis.WriteTimeout),
+		PoolTimeout:           time.Duration(c.Redis.PoolTimeout),
+	}
+}
+
+// QlooOptions returns the Qloo client settings
+func (c *Config) QlooOptions() qloo.Options {
+	return qloo.Options{
+		APIKey:      c.Qloo.APIKey,
+		BaseURL:     c.Qloo.BaseURL,
+		Timeout:     time.Duration(c.Qloo.Timeout),
+		CacheTTL:    time.Duration(c.Qloo.CacheTTL),
+		MaxSegments: c.Qloo.MaxSegments,
+		MinAffinity: c.Qloo.MinAffinity,
+		MaxEntities: c.Qloo.MaxEntities,
+	}
+}
+
+// CampaignOptions returns the campaign store settings
+func (c *Config) CampaignOptions() campaign.Options {
+	return campaign.Options{
+		Store:       c.Campaigns.Store,
+		DatabaseURL: c.Campaigns.DatabaseURL,
+	}
+}
+
+// BreakerOptions returns the circuit breaker settings, filling each upstream
+// override's unset fields from the defaults
+func (c *Config) BreakerOptions() breaker.Options {
+	settings := breaker.Settings{
+		FailureThreshold: c.Breakers.FailureThreshold,
+		OpenTimeout:      time.Duration(c.Breakers.OpenTimeout),
+		HalfOpenProbes:   c.Breakers.HalfOpenProbes,
+	}
+	overrides := make(map[string]breaker.Settings, len(c.Breakers.Upstreams))
+	for name, override := range c.Breakers.Upstreams {
+		upstream := settings
+		if override.FailureThreshold > 0 {
+			upstream.FailureThreshold = override.FailureThreshold
+		}
+		if override.OpenTimeout > 0 {
+			upstream.OpenTimeout = time.Duration(override.OpenTimeout)
+		}
+		if override.HalfOpenProbes > 0 {
+			upstream.HalfOpenProbes = override.HalfOpenProbes
+		}
+		overrides[name] = upstream
+	}
+	return breaker.Options{Store: c.Breakers.Store, Settings: settings, Overrides: overrides}
+}
+
+// AmazonOptions returns the Amazon client settings
+func (c *Config) AmazonOptions() amazon.Options {
+	return amazon.Options{
+		AccessKey: c.Amazon.AccessKey,
+		SecretKey: c.Amazon.SecretKey,
+		Region:    c.Amazon.Region,
+		Mock:      c.Amazon.Mock,
+		CacheTTL:  time.Duration(c.Amazon.CacheTTL),
+	}
+}
+
+// EbayOptions returns the eBay client settings
+func (c *Config) EbayOptions() ebay.Options {
+	return ebay.Options{
+		APIKey:   c.Ebay.APIKey,
+		BaseURL:  c.Ebay.BaseURL,
+		Timeout:  time.Duration(c.Ebay.Timeout),
+		CacheTTL: time.Duration(c.Ebay.CacheTTL),
+	}
+}
+
+// RateLimitPolicy returns the named rate limit policy
+func (c *Config) RateLimitPolicy(name string) middleware.RateLimitPolicy {
+	limit := c.RateLimits[name]
+	return middleware.RateLimitPolicy{
+		Name:      name,
+		Algorithm: limit.Algorithm,
+		Limit:     limit.Limit,
+		Window:    time.Duration(limit.Window),
+		Burst:     limit.Burst,
+	}
+}
+
+// RateLimitPlans returns the subscription plans keyed by name
+func (c *Config) RateLimitPlans() map[string]middleware.Plan {
+	plans := make(map[string]middleware.Plan, len(c.Plans))
+	for name, plan := range c.Plans {
+		plans[name] = middleware.Plan{Name: name, PerMinute: plan.PerMinute, PerDay: plan.PerDay}
+	}
+	return plans
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyEnv overrides settings with any environment variables that are set
+func (c *Config) applyEnv() error {
+	settings := map[string]*string{
+		"SERVER_ADDR":           &c.Server.Addr,
+		"SERVER_TLS_CERT_FILE":  &c.Server.TLSCertFile,
+		"SERVER_TLS_KEY_FILE":   &c.Server.TLSKeyFile,
+		"REDIS_URL":             &c.Redis.URL,
+		"REDIS_MODE":            &c.Redis.Mode,
+		"REDIS_MASTER_NAME":     &c.Redis.MasterName,
+		"REDIS_USERNAME":        &c.Redis.Username,
+		"REDIS_PASSWORD":        &c.Redis.Password,
+		"API_KEYS_FILE":         &c.Auth.APIKeysFile,
+		"ADMIN_API_KEY":         &c.Auth.AdminAPIKey,
+		"LLM_PROVIDER":          &c.LLM.Provider,
+		"LLM_BASE_URL":          &c.LLM.BaseURL,
+		"LLM_API_VERSION":       &c.LLM.APIVersion,
+		"LLM_MODEL":             &c.LLM.Model,
+		"LLM_FAKE_SCRIPT":       &c.LLM.FakeScript,
+		"LLM_PRICE_TABLE_FILE":  &c.LLM.PriceTableFile,
+		"QLOO_API_KEY":          &c.Qloo.APIKey,
+		"QLOO_BASE_URL":         &c.Qloo.BaseURL,
+		"AMAZON_ACCESS_KEY":     &c.Amazon.AccessKey,
+		"AMAZON_SECRET_KEY":     &c.Amazon.SecretKey,
+		"AMAZON_REGION":         &c.Amazon.Region,
+		"EBAY_API_KEY":          &c.Ebay.APIKey,
+		"EBAY_BASE_URL":         &c.Ebay.BaseURL,
+		"TOOL_POLICY_FILE":      &c.ToolPolicyFile,
+		"SEGMENT_TAXONOMY_FILE": &c.Segments.TaxonomyFile,
+		"CAMPAIGN_STORE":        &c.Campaigns.Store,
+		"CAMPAIGN_DATABASE_URL": &c.Campaigns.DatabaseURL,
+		"CIRCUIT_BREAKER_STORE": &c.Breakers.Store,
+	}
+	for env, target := range settings {
+		if value := os.Getenv(env); value != "" {
+			*target = value
+		}
+	}
+
+	// OPENAI_API_KEY predates the provider settings and is still honoured
+	for _, env := range []string{"OPENAI_API_KEY", "LLM_API_KEY"} {
+		if value := os.Getenv(env); value != "" {
+			c.LLM.APIKey = value
+		}
+	}
+
+	if value := os.Getenv("REDIS_ADDRS"); value != "" {
+		c.Redis.Addrs = strings.Split(value, ",")
+	}
+
+	// PORT is set by most hosting platforms
+	if port := os.Getenv("PORT"); port != "" && os.Getenv("SERVER_ADDR") == "" {
+		c.Server.Addr = ":" + port
+	}
+
+	var errs []error
+	for env, target := range map[string]*Duration{
+		"SERVER_READ_TIMEOUT":          &c.Server.ReadTimeout,
+		"SERVER_READ_HEADER_TIMEOUT":   &c.Server.ReadHeaderTimeout,
+		"SERVER_WRITE_TIMEOUT":         &c.Server.WriteTimeout,
+		"SERVER_IDLE_TIMEOUT":          &c.Server.IdleTimeout,
+		"SERVER_SHUTDOWN_TIMEOUT":      &c.Server.ShutdownTimeout,
+		"REDIS_DIAL_TIMEOUT":           &c.Redis.DialTimeout,
+		"REDIS_READ_TIMEOUT":           &c.Redis.ReadTimeout,
+		"REDIS_WRITE_TIMEOUT":          &c.Redis.WriteTimeout,
+		"REDIS_POOL_TIMEOUT":           &c.Redis.PoolTimeout,
+		"LLM_TIMEOUT":                  &c.LLM.Timeout,
+		"QLOO_TIMEOUT":                 &c.Qloo.Timeout,
+		"QLOO_CACHE_TTL":               &c.Qloo.CacheTTL,
+		"AMAZON_CACHE_TTL":             &c.Amazon.CacheTTL,
+		"EBAY_TIMEOUT":                 &c.Ebay.Timeout,
+		"EBAY_CACHE_TTL":               &c.Ebay.CacheTTL,
+		"CIRCUIT_BREAKER_OPEN_TIMEOUT": &c.Breakers.OpenTimeout,
+	} {
+		if value := os.Getenv(env); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: must be a duration such as 30s", env))
+				continue
+			}
+			*target = Duration(parsed)
+		}
+	}
+
+	for env, target := range map[string]*float64{
+		"LLM_DAILY_BUDGET_USD":   &c.LLM.Budget.DailyUSD,
+		"LLM_MONTHLY_BUDGET_USD": &c.LLM.Budget.MonthlyUSD,
+		"QLOO_MIN_AFFINITY":      &c.Qloo.MinAffinity,
+	} {
+		if value := os.Getenv(env); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: must be a number", env))
+				continue
+			}
+			*target = parsed
+		}
+	}
+
+	for env, target := range map[string]*int{
+		"REDIS_DB":                          &c.Redis.DB,
+		"REDIS_POOL_SIZE":                   &c.Redis.PoolSize,
+		"REDIS_MIN_IDLE_CONNS":              &c.Redis.MinIdleConns,
+		"QLOO_MAX_SEGMENTS":                 &c.Qloo.MaxSegments,
+		"QLOO_MAX_ENTITIES":                 &c.Qloo.MaxEntities,
+		"CIRCUIT_BREAKER_FAILURE_THRESHOLD": &c.Breakers.FailureThreshold,
+		"CIRCUIT_BREAKER_HALF_OPEN_PROBES":  &c.Breakers.HalfOpenProbes,
+	} {
+		if value := os.Getenv(env); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: must be an integer", env))
+				continue
+			}
+			*target = parsed
+		}
+	}
+
+	if value := os.Getenv("SERVER_MAX_HEADER_BYTES"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, errors.New("invalid SERVER_MAX_HEADER_BYTES: must be a number of bytes"))
+		} else {
+			c.Server.MaxHeaderBytes = parsed
+		}
+	}
+	if value := os.Getenv("SERVER_MAX_BODY_BYTES"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			errs = append(errs, errors.New("invalid SERVER_MAX_BODY_BYTES: must be a number of bytes"))
+		} else {
+			c.Server.MaxBodyBytes = parsed
+		}
+	}
+
+	for env, target := range map[string]*bool{
+		"REDIS_TLS":                      &c.Redis.TLS,
+		"REDIS_TLS_INSECURE_SKIP_VERIFY": &c.Redis.TLSInsecureSkipVerify,
+		"AMAZON_MOCK":                    &c.Amazon.Mock,
+		"SEGMENT_LLM_INFERENCE":          &c.Segments.LLMInference,
+		"SEGMENT_PERSONAS":               &c.Segments.Personas,
+	} {
+		if value := os.Getenv(env); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: must be true or false", env))
+				continue
+			}
+			*target = parsed
+		}
+	}
+
+	errs = append(errs, c.applyBreakerEnv()...)
+
+	return errors.Join(errs...)
+}
+
+// applyBreakerEnv reads per-upstream circuit breaker overrides such as
+// CIRCUIT_BREAKER_QLOO_FAILURE_THRESHOLD
+func (c *Config) applyBreakerEnv() []error {
+	var errs []error
+	for _, entry := range os.Environ() {
+		env, value, _ := strings.Cut(entry, "=")
+		rest, ok := strings.CutPrefix(env, "CIRCUIT_BREAKER_")
+		if !ok || value == "" {
+			continue
+		}
+
+		for _, setting := range []string{"FAILURE_THRESHOLD", "OPEN_TIMEOUT", "HALF_OPEN_PROBES"} {
+			upstream, ok := strings.CutSuffix(rest, "_"+setting)
+			if !ok || upstream == "" {
+				continue
+			}
+			name := strings.ToLower(upstream)
+			override := c.Breakers.Upstreams[name]
+
+			if setting == "OPEN_TIMEOUT" {
+				parsed, err := time.ParseDuration(value)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("invalid %s: must be a duration such as 30s", env))
+					break
+				}
+				override.OpenTimeout = Duration(parsed)
+			} else {
+				parsed, err := strconv.Atoi(value)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("invalid %s: must be an integer", env))
+					break
+				}
+				if setting == "FAILURE_THRESHOLD" {
+					override.FailureThreshold = parsed
+				} else {
+					override.HalfOpenProbes = parsed
+				}
+			}
+
+			if c.Breakers.Upstreams == nil {
+				c.Breakers.Upstreams = make(map[string]BreakerOverride)
+			}
+			c.Breakers.Upstreams[name] = override
+			break
+		}
+	}
+	return errs
+}
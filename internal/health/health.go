@@ -0,0 +1,196 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/breaker"
+)
+
+// DefaultTimeout bounds each check so one slow dependency cannot stall the report
+const DefaultTimeout = 2 * time.Second
+
+// Component and report statuses
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // a non-critical component is down
+)
+
+// ErrNotConfigured is reported for upstreams that have no client
+var ErrNotConfigured = errors.New("not configured")
+
+// Pinger is implemented by dependencies that can check they are reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Configured is implemented by clients that can report missing configuration
+type Configured interface {
+	CheckConfig() error
+}
+
+// StoreConfigured is implemented by dependencies whose configuration is read
+// from a store, such as the API key service
+type StoreConfigured interface {
+	CheckConfig(ctx context.Context) error
+}
+
+// Check tests one component
+type Check struct {
+	Name     string
+	Critical bool // readiness fails while a critical component is down
+	Run      func(ctx context.Context) error
+	Breaker  *breaker.Breaker // reported with the component when set
+}
+
+// Component is the outcome of one check
+type Component struct {
+	Name      string        `json:"name"`
+	Status    string        `json:"status"`
+	Critical  bool          `json:"critical"`
+	LatencyMS float64       `json:"latency_ms"`
+	Error     string        `json:"error,omitempty"`
+	Breaker   breaker.State `json:"breaker,omitempty"`
+}
+
+// Report is the outcome of every check
+type Report struct {
+	Status     string      `json:"status"`
+	Components []Component `json:"components"`
+}
+
+// Ready reports whether every critical component is up
+func (r Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// Checker runs a set of checks concurrently
+type Checker struct {
+	Timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []Check
+}
+
+// NewChecker creates a checker for the given checks
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{Timeout: DefaultTimeout, checks: checks}
+}
+
+// Add registers another check
+func (c *Checker) Add(check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, check)
+}
+
+// Run executes every check and reports components in registration order. The
+// report is down when a critical component is down and degraded when any
+// other component is.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]Check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	components := make([]Component, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			components[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Components: components}
+	for _, component := range components {
+		if component.Status == StatusUp {
+			continue
+		}
+		if component.Critical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+// run executes one check within the checker's timeout
+func (c *Checker) run(ctx context.Context, check Check) Component {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	component := Component{
+		Name:      check.Name,
+		Status:    StatusUp,
+		Critical:  check.Critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+	}
+	if check.Breaker != nil {
+		component.Breaker = check.Breaker.State(ctx)
+	}
+	return component
+}
+
+// PingCheck checks that a dependency such as Redis is reachable
+func PingCheck(name string, critical bool, pinger Pinger) Check {
+	return Check{
+		Name:     name,
+		Critical: critical,
+		Run:      pinger.Ping,
+	}
+}
+
+// ConfigCheck checks that a dependency such as the API key store is configured
+func ConfigCheck(name string, critical bool, configured StoreConfigured) Check {
+	return Check{
+		Name:     name,
+		Critical: critical,
+		Run:      configured.CheckConfig,
+	}
+}
+
+// UpstreamCheck checks an external service. It is down when the client reports
+// missing configuration, when the upstream's circuit breaker is open, or when
+// the client can ping and the ping fails. Upstreams are not critical since
+// requests degrade without them.
+func UpstreamCheck(name string, client any, b *breaker.Breaker) Check {
+	return Check{
+		Name:    name,
+		Breaker: b,
+		Run: func(ctx context.Context) error {
+			if client == nil {
+				return ErrNotConfigured
+			}
+			if configured, ok := client.(Configured); ok {
+				if err := configured.CheckConfig(); err != nil {
+					return err
+				}
+			}
+			if b != nil && b.State(ctx) == breaker.Open {
+				return &breaker.OpenError{Name: b.Name()}
+			}
+			if pinger, ok := client.(Pinger); ok {
+				return pinger.Ping(ctx)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jesee-kuya/blue/internal/breaker"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubUpstream struct {
+	configErr error
+	pingErr   error
+	pinged    bool
+}
+
+func (s *stubUpstream) CheckConfig() error { return s.configErr }
+
+func (s *stubUpstream) Ping(ctx context.Context) error {
+	s.pinged = true
+	return s.pingErr
+}
+
+func TestChecker_Statuses(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("unreachable") }
+
+	tests := []struct {
+		name   string
+		checks []Check
+		want   string
+	}{
+		{"all up", []Check{{Name: "redis", Critical: true, Run: up}, {Name: "qloo", Run: up}}, StatusOK},
+		{"optional down", []Check{{Name: "redis", Critical: true, Run: up}, {Name: "qloo", Run: down}}, StatusDegraded},
+		{"critical down", []Check{{Name: "qloo", Run: down}, {Name: "redis", Critical: true, Run: down}}, StatusDown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := NewChecker(test.checks...).Run(context.Background())
+			assert.Equal(t, test.want, report.Status)
+			assert.Equal(t, test.want != StatusDown, report.Ready())
+			require.Len(t, report.Components, len(test.checks))
+			assert.Equal(t, test.checks[0].Name, report.Components[0].Name)
+		})
+	}
+}
+
+func TestChecker_TimesOutSlowChecks(t *testing.T) {
+	checker := NewChecker(Check{Name: "llm", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	checker.Timeout = 20 * time.Millisecond
+
+	report := checker.Run(context.Background())
+
+	component := report.Components[0]
+	assert.Equal(t, StatusDown, component.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), component.Error)
+	assert.GreaterOrEqual(t, component.LatencyMS, 20.0)
+}
+
+func TestPingCheck_Redis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+
+	checker := NewChecker(PingCheck("redis", true, redisClient))
+	assert.Equal(t, StatusOK, checker.Run(context.Background()).Status)
+
+	mr.Close()
+	report := checker.Run(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.NotEmpty(t, report.Components[0].Error)
+}
+
+func TestUpstreamCheck(t *testing.T) {
+	breakers := breaker.NewGroup(breaker.Settings{FailureThreshold: 1}, nil)
+	ctx := context.Background()
+
+	healthy := &stubUpstream{}
+	component := NewChecker(UpstreamCheck("amazon", healthy, breakers.Get("amazon"))).Run(ctx).Components[0]
+	assert.Equal(t, StatusUp, component.Status)
+	assert.Equal(t, breaker.Closed, component.Breaker)
+	assert.False(t, component.Critical)
+	assert.True(t, healthy.pinged)
+
+	unconfigured := &stubUpstream{configErr: errors.New("eBay API key not set")}
+	component = NewChecker(UpstreamCheck("ebay", unconfigured, nil)).Run(ctx).Components[0]
+	assert.Equal(t, "eBay API key not set", component.Error)
+	assert.False(t, unconfigured.pinged)
+
+	breakers.Do(context.Background(), "qloo", func() error { return errors.New("timeout") })
+	tripped := &stubUpstream{}
+	component = NewChecker(UpstreamCheck("qloo", tripped, breakers.Get("qloo"))).Run(ctx).Components[0]
+	assert.Equal(t, StatusDown, component.Status)
+	assert.Equal(t, breaker.Open, component.Breaker)
+	assert.False(t, tripped.pinged)
+
+	component = NewChecker(UpstreamCheck("jumia", nil, nil)).Run(ctx).Components[0]
+	assert.Equal(t, ErrNotConfigured.Error(), component.Error)
+
+	report := NewChecker(UpstreamCheck("llm", &stubUpstream{pingErr: errors.New("401 Unauthorized")}, nil)).Run(ctx)
+	assert.Equal(t, StatusDegraded, report.Status)
+}
@@ -0,0 +1,177 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrScriptExhausted is returned when a fake provider has replayed every scripted step
+var ErrScriptExhausted = errors.New("fake provider script exhausted")
+
+// FakeStep is one scripted reply from a FakeProvider
+type FakeStep struct {
+	Response *ChatResponse `json:"response,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// FakeProvider replays a canned sequence of responses, including tool calls,
+// so the orchestrator can run offline in tests and local development
+type FakeProvider struct {
+	mu       sync.Mutex
+	steps    []FakeStep
+	next     int
+	loop     bool
+	requests []ChatRequest
+}
+
+// NewFakeProvider creates a fake provider that replays steps in order
+func NewFakeProvider(steps ...FakeStep) *FakeProvider {
+	return &FakeProvider{steps: steps}
+}
+
+// LoadFakeProvider reads a JSON array of steps from path. The script loops so a
+// long-running offline server keeps answering.
+func LoadFakeProvider(path string) (*FakeProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fake provider script: %w", err)
+	}
+
+	var steps []FakeStep
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return nil, fmt.Errorf("failed to parse fake provider script: %w", err)
+	}
+
+	provider := NewFakeProvider(steps...)
+	provider.loop = true
+	return provider, nil
+}
+
+// FakeText returns a step that replies with plain text
+func FakeText(content string) FakeStep {
+	return FakeStep{Response: &ChatResponse{
+		Model:        "fake",
+		Message:      Message{Role: RoleAssistant, Content: content},
+		FinishReason: "stop",
+	}}
+}
+
+// FakeJSON returns a step that replies with v encoded as JSON, for structured output
+func FakeJSON(v any) FakeStep {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return FakeError(err)
+	}
+	return FakeText(string(data))
+}
+
+// FakeToolCall returns a step that asks for a single tool call
+func FakeToolCall(name string, args map[string]any) FakeStep {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return FakeError(err)
+	}
+	return FakeStep{Response: &ChatResponse{
+		Model: "fake",
+		Message: Message{
+			Role:      RoleAssistant,
+			ToolCalls: []ToolCall{{ID: "call_" + name, Name: name, Arguments: string(data)}},
+		},
+		FinishReason: "tool_calls",
+	}}
+}
+
+// FakeError returns a step that fails with err
+func FakeError(err error) FakeStep {
+	return FakeStep{Error: err.Error()}
+}
+
+// Name identifies the provider
+func (p *FakeProvider) Name() string {
+	return ProviderFake
+}
+
+// Ping always succeeds since the fake provider has no upstream
+func (p *FakeProvider) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Chat returns the next scripted response
+func (p *FakeProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests = append(p.requests, req)
+
+	if p.next >= len(p.steps) {
+		if !p.loop || len(p.steps) == 0 {
+			return nil, ErrScriptExhausted
+		}
+		p.next = 0
+	}
+
+	step := p.steps[p.next]
+	p.next++
+
+	if step.Error != "" {
+		return nil, errors.New(step.Error)
+	}
+	if step.Response == nil {
+		return nil, fmt.Errorf("fake provider step %d has no response", p.next-1)
+	}
+
+	resp := *step.Response
+	if resp.Usage == (TokenUsage{}) {
+		resp.Usage = estimateUsage(req, resp.Message)
+	}
+	return &resp, nil
+}
+
+// ChatStream returns the next scripted response, streaming its content word by word
+func (p *FakeProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(StreamDelta) error) (*ChatResponse, error) {
+	resp, err := p.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, word := range strings.SplitAfter(resp.Message.Content, " ") {
+		if word == "" {
+			continue
+		}
+		if err := onDelta(StreamDelta{Content: word}); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// Requests returns every request the provider has received
+func (p *FakeProvider) Requests() []ChatRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ChatRequest(nil), p.requests...)
+}
+
+// estimateUsage approximates token counts at four characters per token so that
+// cost accounting can be exercised offline
+func estimateUsage(req ChatRequest, reply Message) TokenUsage {
+	prompt := 0
+	for _, msg := range req.Messages {
+		prompt += len(msg.Content)
+	}
+	completion := len(reply.Content)
+	for _, call := range reply.ToolCalls {
+		completion += len(call.Name) + len(call.Arguments)
+	}
+	return TokenUsage{PromptTokens: (prompt + 3) / 4, CompletionTokens: (completion + 3) / 4}
+}
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeProvider_ReplaysStepsInOrder(t *testing.T) {
+	provider := NewFakeProvider(
+		FakeToolCall("search_marketplace", map[string]any{"query": "headphones"}),
+		FakeText("Here are some headphones"),
+		FakeError(errors.New("upstream unavailable")),
+	)
+	ctx := context.Background()
+	req := ChatRequest{Messages: []Message{{Role: RoleUser, Content: "find headphones"}}}
+
+	resp, err := provider.Chat(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, resp.Message.ToolCalls, 1)
+	assert.Equal(t, "search_marketplace", resp.Message.ToolCalls[0].Name)
+	assert.JSONEq(t, `{"query":"headphones"}`, resp.Message.ToolCalls[0].Arguments)
+	assert.Greater(t, resp.Usage.PromptTokens, 0)
+
+	resp, err = provider.Chat(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "Here are some headphones", resp.Message.Content)
+
+	_, err = provider.Chat(ctx, req)
+	assert.EqualError(t, err, "upstream unavailable")
+
+	_, err = provider.Chat(ctx, req)
+	assert.ErrorIs(t, err, ErrScriptExhausted)
+
+	assert.Len(t, provider.Requests(), 4)
+}
+
+func TestFakeProvider_ChatStream(t *testing.T) {
+	provider := NewFakeProvider(FakeText("one two three"))
+
+	var deltas []string
+	resp, err := provider.ChatStream(context.Background(), ChatRequest{}, func(delta StreamDelta) error {
+		deltas = append(deltas, delta.Content)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one ", "two ", "three"}, deltas)
+	assert.Equal(t, "one two three", resp.Message.Content)
+}
+
+func TestLoadFakeProvider_Loops(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.json")
+	script := `[{"response": {"model": "fake", "message": {"role": "assistant", "content": "hello"}}}]`
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o600))
+
+	provider, err := LoadFakeProvider(path)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		resp, err := provider.Chat(context.Background(), ChatRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "hello", resp.Message.Content)
+	}
+}
+
+func TestChatJSON(t *testing.T) {
+	type result struct {
+		Intent string `json:"intent"`
+	}
+
+	provider := NewFakeProvider(FakeJSON(result{Intent: "search"}), FakeText("not json"))
+
+	var out result
+	_, err := ChatJSON(context.Background(), provider, ChatRequest{}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, "search", out.Intent)
+
+	_, err = ChatJSON(context.Background(), provider, ChatRequest{}, &out)
+	var structuredErr *StructuredOutputError
+	require.ErrorAs(t, err, &structuredErr)
+	assert.Equal(t, "not json", structuredErr.Content)
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   ProviderConfig
+		expected string
+		wantErr  bool
+	}{
+		{"default", ProviderConfig{APIKey: "key"}, ProviderOpenAI, false},
+		{"base url", ProviderConfig{APIKey: "key", BaseURL: "http://localhost:11434/v1"}, ProviderOpenAICompatible, false},
+		{"compatible", ProviderConfig{Provider: ProviderOpenAICompatible, BaseURL: "http://localhost:8000/v1"}, ProviderOpenAICompatible, false},
+		{"compatible without url", ProviderConfig{Provider: ProviderOpenAICompatible}, "", true},
+		{"azure", ProviderConfig{Provider: ProviderAzure, APIKey: "key", BaseURL: "https://example.openai.azure.com"}, ProviderAzure, false},
+		{"fake", ProviderConfig{Provider: ProviderFake}, ProviderFake, false},
+		{"unknown", ProviderConfig{Provider: "llama"}, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider, err := NewProvider(test.config)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, provider.Name())
+		})
+	}
+}
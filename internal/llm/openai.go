@@ -0,0 +1,266 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/jesee-kuya/blue/internal/tracing"
+	"github.com/sashabaranov/go-openai"
+)
+
+// OpenAIProvider talks to the OpenAI API or any OpenAI-compatible endpoint
+// such as Azure OpenAI, vLLM or Ollama
+type OpenAIProvider struct {
+	name   string
+	client *openai.Client
+	model  string
+}
+
+// NewOpenAIProvider creates a provider for api.openai.com
+func NewOpenAIProvider(apiKey, model string) *OpenAIProvider {
+	config := openai.DefaultConfig(apiKey)
+	config.HTTPClient = tracedHTTPClient()
+
+	return &OpenAIProvider{
+		name:   ProviderOpenAI,
+		client: openai.NewClientWithConfig(config),
+		model:  model,
+	}
+}
+
+// NewOpenAICompatibleProvider creates a provider for an OpenAI-compatible base URL,
+// for example http://localhost:11434/v1 for Ollama or a vLLM server
+func NewOpenAICompatibleProvider(apiKey, baseURL, model string) *OpenAIProvider {
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = baseURL
+	config.HTTPClient = tracedHTTPClient()
+
+	return &OpenAIProvider{
+		name:   ProviderOpenAICompatible,
+		client: openai.NewClientWithConfig(config),
+		model:  model,
+	}
+}
+
+// NewAzureProvider creates a provider for an Azure OpenAI resource. The model is
+// the deployment name.
+func NewAzureProvider(apiKey, endpoint, apiVersion, deployment string) *OpenAIProvider {
+	config := openai.DefaultAzureConfig(apiKey, endpoint)
+	if apiVersion != "" {
+		config.APIVersion = apiVersion
+	}
+	config.HTTPClient = tracedHTTPClient()
+
+	return &OpenAIProvider{
+		name:   ProviderAzure,
+		client: openai.NewClientWithConfig(config),
+		model:  deployment,
+	}
+}
+
+// tracedHTTPClient propagates trace context and the request ID to the API.
+// Requests are bounded by their context rather than a client timeout, as with
+// the library default.
+func tracedHTTPClient() *http.Client {
+	return tracing.HTTPClient(0)
+}
+
+// Name identifies the provider
+func (p *OpenAIProvider) Name() string {
+	return p.name
+}
+
+// Ping checks that the API is reachable and the key is accepted by listing
+// models, which spends no tokens
+func (p *OpenAIProvider) Ping(ctx context.Context) error {
+	if _, err := p.client.ListModels(ctx); err != nil {
+		return fmt.Errorf("failed to list models: %w", err)
+	}
+	return nil
+}
+
+// Chat performs a chat completion
+func (p *OpenAIProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	openaiReq, err := p.buildRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.CreateChatCompletion(ctx, openaiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response choices returned")
+	}
+
+	choice := resp.Choices[0]
+	message := Message{
+		Role:    Role(choice.Message.Role),
+		Content: choice.Message.Content,
+	}
+	for _, toolCall := range choice.Message.ToolCalls {
+		if toolCall.Type == openai.ToolTypeFunction {
+			message.ToolCalls = append(message.ToolCalls, ToolCall{
+				ID:        toolCall.ID,
+				Name:      toolCall.Function.Name,
+				Arguments: toolCall.Function.Arguments,
+			})
+		}
+	}
+
+	return &ChatResponse{
+		Model:   resp.Model,
+		Message: message,
+		Usage: TokenUsage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		},
+		FinishReason: string(choice.FinishReason),
+	}, nil
+}
+
+// ChatStream streams a chat completion, assembling tool call fragments as they arrive
+func (p *OpenAIProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(StreamDelta) error) (*ChatResponse, error) {
+	openaiReq, err := p.buildRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	openaiReq.Stream = true
+	openaiReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	stream, err := p.client.CreateChatCompletionStream(ctx, openaiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion stream: %w", err)
+	}
+	defer stream.Close()
+
+	resp := &ChatResponse{Message: Message{Role: RoleAssistant}}
+	toolCalls := map[int]*ToolCall{}
+	var order []int
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chat completion stream: %w", err)
+		}
+
+		if chunk.Model != "" {
+			resp.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			resp.Usage = TokenUsage{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CompletionTokens: chunk.Usage.CompletionTokens,
+			}
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		choice := chunk.Choices[0]
+		if choice.FinishReason != "" {
+			resp.FinishReason = string(choice.FinishReason)
+		}
+
+		if choice.Delta.Content != "" {
+			resp.Message.Content += choice.Delta.Content
+			if err := onDelta(StreamDelta{Content: choice.Delta.Content}); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, fragment := range choice.Delta.ToolCalls {
+			index := 0
+			if fragment.Index != nil {
+				index = *fragment.Index
+			}
+			call, ok := toolCalls[index]
+			if !ok {
+				call = &ToolCall{}
+				toolCalls[index] = call
+				order = append(order, index)
+			}
+			if fragment.ID != "" {
+				call.ID = fragment.ID
+			}
+			call.Name += fragment.Function.Name
+			call.Arguments += fragment.Function.Arguments
+		}
+	}
+
+	for _, index := range order {
+		resp.Message.ToolCalls = append(resp.Message.ToolCalls, *toolCalls[index])
+	}
+
+	return resp, nil
+}
+
+// buildRequest converts a provider-neutral request to the go-openai format
+func (p *OpenAIProvider) buildRequest(req ChatRequest) (openai.ChatCompletionRequest, error) {
+	model := req.Model
+	if model == "" {
+		model = p.model
+	}
+
+	openaiReq := openai.ChatCompletionRequest{
+		Model:       model,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+
+	for _, msg := range req.Messages {
+		openaiMsg := openai.ChatCompletionMessage{
+			Role:       string(msg.Role),
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+		}
+		for _, call := range msg.ToolCalls {
+			openaiMsg.ToolCalls = append(openaiMsg.ToolCalls, openai.ToolCall{
+				ID:   call.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      call.Name,
+					Arguments: call.Arguments,
+				},
+			})
+		}
+		openaiReq.Messages = append(openaiReq.Messages, openaiMsg)
+	}
+
+	for _, tool := range req.Tools {
+		openaiReq.Tools = append(openaiReq.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	if req.ResponseFormat != nil {
+		schema, err := json.Marshal(req.ResponseFormat.Schema)
+		if err != nil {
+			return openaiReq, fmt.Errorf("failed to marshal response schema: %w", err)
+		}
+		openaiReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   req.ResponseFormat.Name,
+				Schema: json.RawMessage(schema),
+				Strict: req.ResponseFormat.Strict,
+			},
+		}
+	}
+
+	return openaiReq, nil
+}
@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIProvider_Chat(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"model": "gpt-4o-2024-08-06",
+			"choices": [{
+				"message": {
+					"role": "assistant",
+					"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "search_marketplace", "arguments": "{\"query\":\"shoes\"}"}}]
+				},
+				"finish_reason": "tool_calls"
+			}],
+			"usage": {"prompt_tokens": 50, "completion_tokens": 10, "total_tokens": 60}
+		}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAICompatibleProvider("test-key", server.URL, "gpt-4o")
+	resp, err := provider.Chat(context.Background(), ChatRequest{
+		Messages: []Message{{Role: RoleUser, Content: "find shoes"}},
+		Tools:    []Tool{{Name: "search_marketplace", Description: "Search", Parameters: map[string]any{"type": "object"}}},
+		ResponseFormat: &ResponseFormat{
+			Name:   "result",
+			Schema: map[string]any{"type": "object"},
+			Strict: true,
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o", received["model"])
+	assert.Len(t, received["tools"], 1)
+	assert.Equal(t, "json_schema", received["response_format"].(map[string]any)["type"])
+
+	assert.Equal(t, "gpt-4o-2024-08-06", resp.Model)
+	assert.Equal(t, "tool_calls", resp.FinishReason)
+	assert.Equal(t, TokenUsage{PromptTokens: 50, CompletionTokens: 10}, resp.Usage)
+	require.Len(t, resp.Message.ToolCalls, 1)
+	assert.Equal(t, ToolCall{ID: "call_1", Name: "search_marketplace", Arguments: `{"query":"shoes"}`}, resp.Message.ToolCalls[0])
+}
+
+func TestOpenAIProvider_ChatStream(t *testing.T) {
+	chunks := []string{
+		`{"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"}}]}`,
+		`{"model":"gpt-4o","choices":[{"index":0,"delta":{"content":" world"}}]}`,
+		`{"model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_taste_profile","arguments":"{\"descr"}}]}}]}`,
+		`{"model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"iption\":\"tea\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		`{"model":"gpt-4o","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":8,"total_tokens":20}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider := NewOpenAICompatibleProvider("test-key", server.URL, "gpt-4o")
+
+	var streamed string
+	resp, err := provider.ChatStream(context.Background(), ChatRequest{
+		Messages: []Message{{Role: RoleUser, Content: "hi"}},
+	}, func(delta StreamDelta) error {
+		streamed += delta.Content
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Hello world", streamed)
+	assert.Equal(t, "Hello world", resp.Message.Content)
+	assert.Equal(t, "tool_calls", resp.FinishReason)
+	assert.Equal(t, TokenUsage{PromptTokens: 12, CompletionTokens: 8}, resp.Usage)
+	require.Len(t, resp.Message.ToolCalls, 1)
+	assert.Equal(t, `{"description":"tea"}`, resp.Message.ToolCalls[0].Arguments)
+}
@@ -0,0 +1,57 @@
+package llm
+
+import "fmt"
+
+// Provider names accepted by LLM_PROVIDER
+const (
+	ProviderOpenAI           = "openai"
+	ProviderOpenAICompatible = "openai-compatible"
+	ProviderAzure            = "azure"
+	ProviderFake             = "fake"
+)
+
+// DefaultModel is used when no model is configured
+const DefaultModel = "gpt-4o"
+
+// ProviderConfig selects and configures a provider
+type ProviderConfig struct {
+	Provider   string
+	APIKey     string
+	BaseURL    string
+	APIVersion string
+	Model      string
+	FakeScript string
+}
+
+// NewProvider creates the provider described by config
+func NewProvider(config ProviderConfig) (Provider, error) {
+	model := config.Model
+	if model == "" {
+		model = DefaultModel
+	}
+
+	switch config.Provider {
+	case ProviderOpenAI, "":
+		if config.BaseURL != "" {
+			return NewOpenAICompatibleProvider(config.APIKey, config.BaseURL, model), nil
+		}
+		return NewOpenAIProvider(config.APIKey, model), nil
+	case ProviderOpenAICompatible:
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("LLM base URL is required for the %s provider", config.Provider)
+		}
+		return NewOpenAICompatibleProvider(config.APIKey, config.BaseURL, model), nil
+	case ProviderAzure:
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("LLM base URL is required for the %s provider", config.Provider)
+		}
+		return NewAzureProvider(config.APIKey, config.BaseURL, config.APIVersion, model), nil
+	case ProviderFake:
+		if config.FakeScript == "" {
+			return NewFakeProvider(), nil
+		}
+		return LoadFakeProvider(config.FakeScript)
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", config.Provider)
+	}
+}
@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Role identifies the author of a chat message
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// Message is a single chat message
+type Message struct {
+	Role       Role       `json:"role"`
+	Content    string     `json:"content,omitempty"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// ToolCall is a request from the model to invoke a tool
+type ToolCall struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // raw JSON object
+}
+
+// Tool describes a function the model may call. Parameters is a JSON schema.
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Parameters  any    `json:"parameters"`
+}
+
+// ResponseFormat requests structured output matching a JSON schema
+type ResponseFormat struct {
+	Name   string `json:"name"`
+	Schema any    `json:"schema"`
+	Strict bool   `json:"strict,omitempty"`
+}
+
+// ChatRequest is a provider-neutral chat completion request
+type ChatRequest struct {
+	Model          string          `json:"model,omitempty"`
+	Messages       []Message       `json:"messages"`
+	Tools          []Tool          `json:"tools,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	Temperature    float32         `json:"temperature,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+}
+
+// TokenUsage reports the tokens consumed by a completion
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// ChatResponse is a provider-neutral chat completion response
+type ChatResponse struct {
+	Model        string     `json:"model"`
+	Message      Message    `json:"message"`
+	Usage        TokenUsage `json:"usage"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+}
+
+// StreamDelta is an incremental piece of a streamed completion
+type StreamDelta struct {
+	Content string `json:"content"`
+}
+
+// Provider is a chat model backend
+type Provider interface {
+	// Name identifies the provider for logs and metrics
+	Name() string
+	// Chat performs a single chat completion, possibly returning tool calls
+	Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+	// ChatStream streams content deltas to onDelta and returns the assembled response
+	ChatStream(ctx context.Context, req ChatRequest, onDelta func(StreamDelta) error) (*ChatResponse, error)
+}
+
+// ChatJSON performs a chat completion with structured output and decodes the
+// response content into dest
+func ChatJSON(ctx context.Context, provider Provider, req ChatRequest, dest any) (*ChatResponse, error) {
+	resp, err := provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(resp.Message.Content), dest); err != nil {
+		return resp, &StructuredOutputError{Content: resp.Message.Content, Err: err}
+	}
+
+	return resp, nil
+}
+
+// StructuredOutputError is returned when a model's structured output cannot be decoded
+type StructuredOutputError struct {
+	Content string
+	Err     error
+}
+
+func (e *StructuredOutputError) Error() string {
+	return "failed to parse structured output: " + e.Err.Error()
+}
+
+func (e *StructuredOutputError) Unwrap() error {
+	return e.Err
+}
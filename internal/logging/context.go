@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/jesee-kuya/blue/internal/tenant"
+)
+
+// RequestIDHeader carries the request ID in and out of the service
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+type attrsKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, if any
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// With returns a copy of ctx whose logger adds the given attributes, such as
+// the tool being called, to every entry
+func With(ctx context.Context, args ...any) context.Context {
+	attrs, _ := ctx.Value(attrsKey{}).([]any)
+	return context.WithValue(ctx, attrsKey{}, append(attrs[:len(attrs):len(attrs)], args...))
+}
+
+// FromContext returns the default logger annotated with the request ID, the
+// tenant and any attributes added with With
+func FromContext(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if ctx == nil {
+		return logger
+	}
+
+	var args []any
+	if id := RequestID(ctx); id != "" {
+		args = append(args, slog.String(KeyRequestID, id))
+	}
+	if id := tenant.ID(ctx); id != "" {
+		args = append(args, slog.String(KeyTenant, id))
+	}
+	if attrs, ok := ctx.Value(attrsKey{}).([]any); ok {
+		args = append(args, attrs...)
+	}
+	if len(args) == 0 {
+		return logger
+	}
+	return logger.With(args...)
+}
+
+// Transport wraps base so outbound requests carry the request ID from their
+// context. A nil base uses http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return requestIDTransport{base: base}
+}
+
+type requestIDTransport struct {
+	base http.RoundTripper
+}
+
+func (t requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := RequestID(req.Context())
+	if id == "" || req.Header.Get(RequestIDHeader) != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	req.Header.Set(RequestIDHeader, id)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Field names shared by every log line so entries can be filtered consistently
+const (
+	KeyRequestID = "request_id"
+	KeyTenant    = "tenant"
+	KeyTool      = "tool"
+	KeyProvider  = "provider"
+	KeyAttempt   = "attempt"
+	KeyLatency   = "latency_ms"
+	KeyError     = "error"
+)
+
+// Output formats accepted by LOG_FORMAT
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New creates a logger writing redacted entries in the given format
+func New(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch format {
+	case "", FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+	return slog.New(NewRedactingHandler(handler)), nil
+}
+
+// Setup installs the default logger configured by LOG_FORMAT (json or text)
+// and LOG_LEVEL (debug, info, warn or error). Output from the standard log
+// package is routed through it as well.
+func Setup() error {
+	var level slog.Level
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		if err := level.UnmarshalText([]byte(strings.ToUpper(value))); err != nil {
+			return fmt.Errorf("invalid LOG_LEVEL %q", value)
+		}
+	}
+
+	logger, err := New(os.Stderr, os.Getenv("LOG_FORMAT"), level)
+	if err != nil {
+		return fmt.Errorf("invalid LOG_FORMAT: %w", err)
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// Latency logs a duration in milliseconds under KeyLatency
+func Latency(d time.Duration) slog.Attr {
+	return slog.Float64(KeyLatency, float64(d.Microseconds())/1000)
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureDefault installs a JSON default logger writing to the returned buffer
+func captureDefault(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatJSON, slog.LevelDebug)
+	require.NoError(t, err)
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	return entry
+}
+
+func TestFromContext_AddsRequestFields(t *testing.T) {
+	buf := captureDefault(t)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = tenant.WithTenant(ctx, tenant.Tenant{ID: "acme"})
+	ctx = With(ctx, KeyTool, "search_marketplace")
+	FromContext(ctx).Info("Tool call failed, retrying", KeyAttempt, 2)
+
+	entry := decode(t, buf)
+	assert.Equal(t, "req-1", entry[KeyRequestID])
+	assert.Equal(t, "acme", entry[KeyTenant])
+	assert.Equal(t, "search_marketplace", entry[KeyTool])
+	assert.Equal(t, float64(2), entry[KeyAttempt])
+}
+
+func TestRedactingHandler(t *testing.T) {
+	buf := captureDefault(t)
+
+	slog.With("api_key", "blue_0123456789abcdef").Warn("Signup from jane@example.com failed",
+		"authorization", "Bearer secret-token",
+		"phone", "555-123-4567",
+		KeyError, errors.New("qloo rejected key=abc123 for +1 (555) 123-4567"),
+		slog.Group("request", "query", "call me at 555.123.4567"),
+		"product", "headphones under 50",
+	)
+
+	entry := decode(t, buf)
+	assert.Equal(t, "Signup from "+Redacted+" failed", entry["msg"])
+	assert.Equal(t, Redacted, entry["api_key"])
+	assert.Equal(t, Redacted, entry["authorization"])
+	assert.Equal(t, Redacted, entry["phone"])
+	assert.NotContains(t, entry[KeyError], "abc123")
+	assert.NotContains(t, entry[KeyError], "123-4567")
+	assert.Equal(t, "call me at "+Redacted, entry["request"].(map[string]any)["query"])
+	assert.Equal(t, "headphones under 50", entry["product"])
+}
+
+func TestRedactString(t *testing.T) {
+	assert.Equal(t, "using "+Redacted, RedactString("using sk-abcdefghijklmnopqrstuvwx"))
+	assert.Equal(t, "price 1299.99 in 3 days", RedactString("price 1299.99 in 3 days"))
+}
+
+func TestNew_RejectsUnknownFormat(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, "xml", slog.LevelInfo)
+	assert.Error(t, err)
+
+	t.Setenv("LOG_LEVEL", "loud")
+	assert.ErrorContains(t, Setup(), "LOG_LEVEL")
+}
+
+func TestTransport_PropagatesRequestID(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(RequestIDHeader)
+	}))
+	defer server.Close()
+
+	ctx := WithRequestID(context.Background(), "req-42")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "req-42", got)
+	assert.Empty(t, req.Header.Get(RequestIDHeader))
+}
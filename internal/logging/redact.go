@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces sensitive values in log entries
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute names whose values are never logged
+var sensitiveKeys = map[string]bool{
+	"api_key":       true,
+	"apikey":        true,
+	"x-api-key":     true,
+	"authorization": true,
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+	"email":         true,
+	"phone":         true,
+}
+
+// sensitivePatterns find credentials and personal details inside free text
+var sensitivePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`),
+	regexp.MustCompile(`\bblue_[0-9a-f]{8,}\b`),              // keys issued by this service
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}\b`),          // OpenAI keys
+	regexp.MustCompile(`(?i)\b(api_?key|key|token)=[^&\s]+`), // credentials in query strings
+	regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	regexp.MustCompile(`\+?\(?\b\d{3}\)?[\s.-]?\d{3}[\s.-]?\d{4}\b`),
+}
+
+// RedactString masks credentials, email addresses and phone numbers in s
+func RedactString(s string) string {
+	for _, pattern := range sensitivePatterns {
+		s = pattern.ReplaceAllString(s, Redacted)
+	}
+	return s
+}
+
+// RedactingHandler masks API keys and user PII before entries reach the
+// wrapped handler. Attributes with sensitive names are dropped to Redacted and
+// string values and messages are scanned for keys, emails and phone numbers.
+type RedactingHandler struct {
+	next slog.Handler
+}
+
+// NewRedactingHandler wraps next with redaction
+func NewRedactingHandler(next slog.Handler) *RedactingHandler {
+	return &RedactingHandler{next: next}
+}
+
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RedactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, RedactString(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = redactAttr(attr)
+	}
+	return &RedactingHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{next: h.next.WithGroup(name)}
+}
+
+// redactAttr masks one attribute, descending into groups
+func redactAttr(attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, Redacted)
+	}
+
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, RedactString(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = redactAttr(member)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return slog.String(attr.Key, RedactString(err.Error()))
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
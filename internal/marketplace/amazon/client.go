@@ -1,7 +1,9 @@
 package amazon
 
 import (
+	"context"
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -9,38 +11,84 @@ import (
 
 	"github.com/jesee-kuya/blue/internal/cache"
 	"github.com/jesee-kuya/blue/internal/marketplace"
+	"github.com/jesee-kuya/blue/internal/metrics"
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/jesee-kuya/blue/internal/tenant"
 )
 
+// Defaults used for unset options
+const (
+	DefaultRegion   = "us-east-1"
+	DefaultCacheTTL = 10 * time.Minute
+)
+
+// Options configures an Amazon client
+type Options struct {
+	AccessKey string
+	SecretKey string
+	Region    string
+	Mock      bool          // serve generated products instead of calling the API
+	CacheTTL  time.Duration // how long search results are cached
+}
+
 // Client represents an Amazon Product Advertising API client
 type Client struct {
 	accessKey   string
 	secretKey   string
 	region      string
 	mockMode    bool
+	cacheTTL    time.Duration
 	redisClient *cache.RedisClient
 }
 
 // NewClient creates a new Amazon client
 func NewClient(accessKey, secretKey, region string) *Client {
+	return NewClientWithOptions(Options{
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Region:    region,
+		Mock:      true,
+	}, cache.Shared())
+}
+
+// NewClientWithOptions creates a new Amazon client caching in redisClient
+func NewClientWithOptions(opts Options, redisClient *cache.RedisClient) *Client {
+	if opts.Region == "" {
+		opts.Region = DefaultRegion
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
 	return &Client{
-		accessKey:   accessKey,
-		secretKey:   secretKey,
-		region:      region,
-		mockMode:    true,
-		redisClient: cache.NewRedisClient(),
+		accessKey:   opts.AccessKey,
+		secretKey:   opts.SecretKey,
+		region:      opts.Region,
+		mockMode:    opts.Mock,
+		cacheTTL:    opts.CacheTTL,
+		redisClient: redisClient,
+	}
+}
+
+// CheckConfig reports missing configuration. Mock mode needs no credentials.
+func (c *Client) CheckConfig() error {
+	if !c.mockMode && (c.accessKey == "" || c.secretKey == "") {
+		return errors.New("Amazon access and secret keys not set")
 	}
+	return nil
 }
 
 // Search searches for products on Amazon with Redis caching
-func (c *Client) Search(query string, minPrice, maxPrice float64) ([]marketplace.Product, error) {
+func (c *Client) Search(ctx context.Context, query string, minPrice, maxPrice float64) ([]marketplace.Product, error) {
 	// Generate cache key
-	cacheKey := c.generateCacheKey(query, minPrice, maxPrice)
+	cacheKey := tenant.ScopedKey(ctx, c.generateCacheKey(query, minPrice, maxPrice))
 
 	// Try to get from cache first
 	var cachedProducts []marketplace.Product
-	if err := c.redisClient.Get(cacheKey, &cachedProducts); err == nil {
+	if err := c.redisClient.Get(ctx, cacheKey, &cachedProducts); err == nil {
+		metrics.ObserveCacheLookup("amazon", true)
 		return cachedProducts, nil
 	}
+	metrics.ObserveCacheLookup("amazon", false)
 
 	// Cache miss - fetch fresh data
 	var products []marketplace.Product
@@ -49,15 +97,14 @@ func (c *Client) Search(query string, minPrice, maxPrice float64) ([]marketplace
 	if c.mockMode {
 		products, err = c.mockSearch(query, minPrice, maxPrice)
 	} else {
-		return nil, fmt.Errorf("Amazon API integration not yet implemented")
+		return nil, retry.MarkPermanent(fmt.Errorf("Amazon API integration not yet implemented"))
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	// Cache the results for 10 minutes
-	c.redisClient.SetWithTTL(cacheKey, products, 10*time.Minute)
+	c.redisClient.SetWithTTL(ctx, cacheKey, products, c.cacheTTL)
 
 	return products, nil
 }
@@ -1,6 +1,7 @@
 package amazon
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,7 +10,7 @@ import (
 func TestAmazonClient_Search_MockMode(t *testing.T) {
 	client := NewClient("test-access-key", "test-secret-key", "us-east-1")
 
-	products, err := client.Search("laptop", 20, 50)
+	products, err := client.Search(context.Background(), "laptop", 20, 50)
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, products)
@@ -27,7 +28,7 @@ func TestAmazonClient_Search_MockMode(t *testing.T) {
 func TestAmazonClient_Search_NoPriceFilter(t *testing.T) {
 	client := NewClient("test-access-key", "test-secret-key", "us-east-1")
 
-	products, err := client.Search("phone", 0, 0)
+	products, err := client.Search(context.Background(), "phone", 0, 0)
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, products)
@@ -1,8 +1,10 @@
 package ebay
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,51 +14,95 @@ import (
 
 	"github.com/jesee-kuya/blue/internal/cache"
 	"github.com/jesee-kuya/blue/internal/marketplace"
+	"github.com/jesee-kuya/blue/internal/metrics"
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/jesee-kuya/blue/internal/tracing"
 )
 
+// Defaults used for unset options
+const (
+	DefaultBaseURL  = "https://api.ebay.com/buy/browse/v1"
+	DefaultTimeout  = 30 * time.Second
+	DefaultCacheTTL = 10 * time.Minute
+)
+
+// Options configures an eBay client
+type Options struct {
+	APIKey   string
+	BaseURL  string
+	Timeout  time.Duration // per request
+	CacheTTL time.Duration // how long search results are cached
+}
+
 // Client represents an eBay API client
 type Client struct {
 	apiKey      string
 	baseURL     string
+	cacheTTL    time.Duration
 	httpClient  *http.Client
 	redisClient *cache.RedisClient
 }
 
 // NewClient creates a new eBay client
 func NewClient(apiKey string) *Client {
+	return NewClientWithOptions(Options{APIKey: apiKey}, cache.Shared())
+}
+
+// NewClientWithOptions creates a new eBay client caching in redisClient
+func NewClientWithOptions(opts Options, redisClient *cache.RedisClient) *Client {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
 	return &Client{
-		apiKey:      apiKey,
-		baseURL:     "https://api.ebay.com/buy/browse/v1",
-		httpClient:  &http.Client{Timeout: 30 * time.Second},
-		redisClient: cache.NewRedisClient(),
+		apiKey:      opts.APIKey,
+		baseURL:     opts.BaseURL,
+		cacheTTL:    opts.CacheTTL,
+		httpClient:  tracing.HTTPClient(opts.Timeout),
+		redisClient: redisClient,
+	}
+}
+
+// CheckConfig reports missing configuration
+func (c *Client) CheckConfig() error {
+	if c.apiKey == "" {
+		return errors.New("eBay API key not set")
 	}
+	return nil
 }
 
 // Search searches for products on eBay with Redis caching
-func (c *Client) Search(query string, minPrice, maxPrice float64) ([]marketplace.Product, error) {
+func (c *Client) Search(ctx context.Context, query string, minPrice, maxPrice float64) ([]marketplace.Product, error) {
 	// Generate cache key
-	cacheKey := c.generateCacheKey(query, minPrice, maxPrice)
+	cacheKey := tenant.ScopedKey(ctx, c.generateCacheKey(query, minPrice, maxPrice))
 
 	// Try to get from cache first
 	var cachedProducts []marketplace.Product
-	if err := c.redisClient.Get(cacheKey, &cachedProducts); err == nil {
+	if err := c.redisClient.Get(ctx, cacheKey, &cachedProducts); err == nil {
+		metrics.ObserveCacheLookup("ebay", true)
 		return cachedProducts, nil
 	}
+	metrics.ObserveCacheLookup("ebay", false)
 
 	// Cache miss - fetch fresh data
-	products, err := c.searchAPI(query, minPrice, maxPrice)
+	products, err := c.searchAPI(ctx, query, minPrice, maxPrice)
 	if err != nil {
 		return nil, err
 	}
 
-	// Cache the results for 10 minutes
-	c.redisClient.SetWithTTL(cacheKey, products, 10*time.Minute)
+	c.redisClient.SetWithTTL(ctx, cacheKey, products, c.cacheTTL)
 
 	return products, nil
 }
 
 // searchAPI performs the actual API call
-func (c *Client) searchAPI(query string, minPrice, maxPrice float64) ([]marketplace.Product, error) {
+func (c *Client) searchAPI(ctx context.Context, query string, minPrice, maxPrice float64) ([]marketplace.Product, error) {
 	// Build query parameters
 	params := url.Values{}
 	params.Set("q", query)
@@ -70,9 +116,9 @@ func (c *Client) searchAPI(query string, minPrice, maxPrice float64) ([]marketpl
 
 	// Create and execute request
 	reqURL := fmt.Sprintf("%s/item_summary/search?%s", c.baseURL, params.Encode())
-	req, err := http.NewRequest("GET", reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, retry.MarkPermanent(fmt.Errorf("failed to create request: %w", err))
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
@@ -85,7 +131,7 @@ func (c *Client) searchAPI(query string, minPrice, maxPrice float64) ([]marketpl
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("eBay API returned status %d", resp.StatusCode)
+		return nil, retry.NewStatusError("eBay", resp)
 	}
 
 	// Parse response
@@ -1,6 +1,7 @@
 package ebay
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -46,7 +47,7 @@ func TestEbayClient_Search(t *testing.T) {
 	client.baseURL = server.URL
 
 	// Test search
-	products, err := client.Search("laptop", 0, 100)
+	products, err := client.Search(context.Background(), "laptop", 0, 100)
 
 	assert.NoError(t, err)
 	assert.Len(t, products, 2)
@@ -65,7 +66,7 @@ func TestEbayClient_Search_APIError(t *testing.T) {
 	client := NewClient("test-api-key")
 	client.baseURL = server.URL
 
-	products, err := client.Search("laptop", 0, 100)
+	products, err := client.Search(context.Background(), "laptop", 0, 100)
 
 	assert.Error(t, err)
 	assert.Nil(t, products)
@@ -1,7 +1,9 @@
 package jumia
 
 import (
+	"context"
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -9,6 +11,9 @@ import (
 
 	"github.com/jesee-kuya/blue/internal/cache"
 	"github.com/jesee-kuya/blue/internal/marketplace"
+	"github.com/jesee-kuya/blue/internal/metrics"
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/jesee-kuya/blue/internal/tenant"
 )
 
 // Client represents a Jumia API client
@@ -25,29 +30,39 @@ func NewClient(apiKey string) *Client {
 		apiKey:      apiKey,
 		baseURL:     "https://api.jumia.com/v1",
 		mockMode:    true, // Mock mode for now
-		redisClient: cache.NewRedisClient(),
+		redisClient: cache.Shared(),
 	}
 }
 
+// CheckConfig reports missing configuration. Mock mode needs no credentials.
+func (c *Client) CheckConfig() error {
+	if !c.mockMode && c.apiKey == "" {
+		return errors.New("Jumia API key not set")
+	}
+	return nil
+}
+
 // Search searches for products on Jumia with Redis caching
-func (c *Client) Search(query string, minPrice, maxPrice float64) ([]marketplace.Product, error) {
+func (c *Client) Search(ctx context.Context, query string, minPrice, maxPrice float64) ([]marketplace.Product, error) {
 	// Generate cache key
-	cacheKey := c.generateCacheKey(query, minPrice, maxPrice)
-	
+	cacheKey := tenant.ScopedKey(ctx, c.generateCacheKey(query, minPrice, maxPrice))
+
 	// Try to get from cache first
 	var cachedProducts []marketplace.Product
-	if err := c.redisClient.Get(cacheKey, &cachedProducts); err == nil {
+	if err := c.redisClient.Get(ctx, cacheKey, &cachedProducts); err == nil {
+		metrics.ObserveCacheLookup("jumia", true)
 		return cachedProducts, nil
 	}
+	metrics.ObserveCacheLookup("jumia", false)
 
 	// Cache miss - fetch fresh data
 	var products []marketplace.Product
 	var err error
-	
+
 	if c.mockMode {
 		products, err = c.mockSearch(query, minPrice, maxPrice)
 	} else {
-		return nil, fmt.Errorf("jumia API integration not yet implemented")
+		return nil, retry.MarkPermanent(fmt.Errorf("jumia API integration not yet implemented"))
 	}
 
 	if err != nil {
@@ -55,8 +70,8 @@ func (c *Client) Search(query string, minPrice, maxPrice float64) ([]marketplace
 	}
 
 	// Cache the results for 10 minutes
-	c.redisClient.SetWithTTL(cacheKey, products, 10*time.Minute)
-	
+	c.redisClient.SetWithTTL(ctx, cacheKey, products, 10*time.Minute)
+
 	return products, nil
 }
 
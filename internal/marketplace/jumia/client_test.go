@@ -1,6 +1,7 @@
 package jumia
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -33,9 +34,8 @@ func TestJumiaClient_Search_PriceFilter(t *testing.T) {
 	client.baseURL = server.URL
 
 	// Test with price filter
-	products, err := client.Search("laptop", 100000, 300000)
+	products, err := client.Search(context.Background(), "laptop", 100000, 300000)
 
 	assert.NoError(t, err)
 	assert.Empty(t, products) // Both products should be filtered out
 }
-
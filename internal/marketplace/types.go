@@ -1,5 +1,7 @@
 package marketplace
 
+import "context"
+
 // Product represents a standardized product from any marketplace
 type Product struct {
 	Title string  `json:"title"`
@@ -9,5 +11,5 @@ type Product struct {
 
 // Client defines the interface that all marketplace clients must implement
 type Client interface {
-	Search(query string, minPrice, maxPrice float64) ([]Product, error)
+	Search(ctx context.Context, query string, minPrice, maxPrice float64) ([]Product, error)
 }
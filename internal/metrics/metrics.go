@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name
+const namespace = "blue"
+
+// Outcomes recorded for tool and upstream calls
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// Registry holds every metric exposed on /metrics, including Go runtime and
+// process metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	intents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "intent_classifications_total",
+		Help:      "Classified messages by intent and the classifier that decided it.",
+	}, []string{"intent", "source"})
+
+	toolCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_calls_total",
+		Help:      "Tool calls by tool and outcome, counting each call once however often it was retried.",
+	}, []string{"tool", "outcome"})
+
+	toolDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tool_call_duration_seconds",
+		Help:      "Tool call latency including retries.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"tool"})
+
+	toolRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_call_retries_total",
+		Help:      "Tool call retries by tool.",
+	}, []string{"tool"})
+
+	marketplaceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "marketplace_request_duration_seconds",
+		Help:      "Marketplace search latency by provider and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"provider", "outcome"})
+
+	marketplaceResults = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "marketplace_results",
+		Help:      "Products returned per successful marketplace search.",
+		Buckets:   []float64{0, 1, 5, 10, 25, 50, 100},
+	}, []string{"provider"})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Cache lookups by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+
+	rateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Requests rejected by rate limiting, by policy.",
+	}, []string{"policy"})
+
+	llmTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "LLM tokens consumed by model and type (prompt or completion).",
+	}, []string{"model", "type"})
+
+	llmCost = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_cost_usd_total",
+		Help:      "Estimated LLM spend in US dollars by model.",
+	}, []string{"model"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		intents,
+		toolCalls, toolDuration, toolRetries,
+		marketplaceDuration, marketplaceResults,
+		cacheLookups,
+		rateLimitRejections,
+		llmTokens, llmCost,
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveHTTPRequest records a served request. Route is the matched route
+// pattern rather than the raw path so that IDs do not explode cardinality.
+func ObserveHTTPRequest(route, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(route, method, code).Inc()
+	httpDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// ObserveIntent records how a message was classified and by which classifier
+func ObserveIntent(intent, source string) {
+	intents.WithLabelValues(intent, source).Inc()
+}
+
+// ObserveToolCall records a finished tool call, including its retries
+func ObserveToolCall(tool string, duration time.Duration, err error) {
+	toolCalls.WithLabelValues(tool, outcome(err)).Inc()
+	toolDuration.WithLabelValues(tool).Observe(duration.Seconds())
+}
+
+// ObserveToolRetry records one retry of a tool call
+func ObserveToolRetry(tool string) {
+	toolRetries.WithLabelValues(tool).Inc()
+}
+
+// ObserveMarketplaceSearch records one marketplace search and how many products it returned
+func ObserveMarketplaceSearch(provider string, duration time.Duration, results int, err error) {
+	marketplaceDuration.WithLabelValues(provider, outcome(err)).Observe(duration.Seconds())
+	if err == nil {
+		marketplaceResults.WithLabelValues(provider).Observe(float64(results))
+	}
+}
+
+// ObserveCacheLookup records a cache hit or miss
+func ObserveCacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// ObserveRateLimitRejection records a request rejected by a rate limit policy
+func ObserveRateLimitRejection(policy string) {
+	rateLimitRejections.WithLabelValues(policy).Inc()
+}
+
+// ObserveLLMUsage records the tokens and estimated cost of a model call
+func ObserveLLMUsage(model string, promptTokens, completionTokens int, costUSD float64) {
+	llmTokens.WithLabelValues(model, "prompt").Add(float64(promptTokens))
+	llmTokens.WithLabelValues(model, "completion").Add(float64(completionTokens))
+	llmCost.WithLabelValues(model).Add(costUSD)
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
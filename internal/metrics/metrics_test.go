@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObservers(t *testing.T) {
+	ObserveToolCall("metrics_test_tool", 10*time.Millisecond, nil)
+	ObserveToolCall("metrics_test_tool", 10*time.Millisecond, errors.New("boom"))
+	ObserveToolRetry("metrics_test_tool")
+	assert.Equal(t, 1.0, testutil.ToFloat64(toolCalls.WithLabelValues("metrics_test_tool", OutcomeSuccess)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(toolCalls.WithLabelValues("metrics_test_tool", OutcomeError)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(toolRetries.WithLabelValues("metrics_test_tool")))
+
+	ObserveCacheLookup("metrics_test_cache", true)
+	ObserveCacheLookup("metrics_test_cache", false)
+	ObserveCacheLookup("metrics_test_cache", false)
+	assert.Equal(t, 1.0, testutil.ToFloat64(cacheLookups.WithLabelValues("metrics_test_cache", "hit")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(cacheLookups.WithLabelValues("metrics_test_cache", "miss")))
+
+	ObserveLLMUsage("metrics-test-model", 100, 20, 0.5)
+	assert.Equal(t, 100.0, testutil.ToFloat64(llmTokens.WithLabelValues("metrics-test-model", "prompt")))
+	assert.Equal(t, 20.0, testutil.ToFloat64(llmTokens.WithLabelValues("metrics-test-model", "completion")))
+	assert.Equal(t, 0.5, testutil.ToFloat64(llmCost.WithLabelValues("metrics-test-model")))
+
+	ObserveMarketplaceSearch("metrics_test_market", time.Millisecond, 0, errors.New("down"))
+	ObserveMarketplaceSearch("metrics_test_market", time.Millisecond, 3, nil)
+	assert.Equal(t, 2, testutil.CollectAndCount(marketplaceDuration, "blue_marketplace_request_duration_seconds"))
+	assert.Equal(t, 1, testutil.CollectAndCount(marketplaceResults, "blue_marketplace_results"))
+}
+
+func TestHandler_ExposesMetrics(t *testing.T) {
+	ObserveHTTPRequest("/search", "GET", 200, 5*time.Millisecond)
+	ObserveIntent("search", "pattern")
+	ObserveRateLimitRejection("marketing")
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `blue_http_requests_total{method="GET",route="/search",status="200"}`)
+	assert.Contains(t, body, `blue_http_request_duration_seconds_bucket{method="GET",route="/search",status="200"`)
+	assert.Contains(t, body, `blue_intent_classifications_total{intent="search",source="pattern"}`)
+	assert.Contains(t, body, `blue_rate_limit_rejections_total{policy="marketing"}`)
+	assert.Contains(t, body, "go_goroutines")
+}
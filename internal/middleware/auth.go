@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/auth"
+	"github.com/jesee-kuya/blue/internal/tenant"
+)
+
+// ContextKeyAdmin is set on the gin context when the caller authenticated with an admin key
+const ContextKeyAdmin = "admin"
+
+// AuthMiddleware validates the API key sent as a bearer token or X-API-Key header
+// and attaches the key's tenant to the gin and request contexts
+func AuthMiddleware(keys *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plaintext := apiKeyFromRequest(c.Request)
+		if plaintext == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="blue"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
+			c.Abort()
+			return
+		}
+
+		key, err := keys.Authenticate(c.Request.Context(), plaintext)
+		if err != nil {
+			status := http.StatusUnauthorized
+			message := "Invalid API key"
+			if errors.Is(err, auth.ErrKeyRevoked) {
+				message = "API key has been revoked"
+			} else if !errors.Is(err, auth.ErrKeyNotFound) {
+				status = http.StatusServiceUnavailable
+				message = "Unable to verify API key"
+			}
+			c.JSON(status, gin.H{"error": message})
+			c.Abort()
+			return
+		}
+
+		c.Set(ContextKeyTenantID, key.TenantID)
+		c.Set(ContextKeyAPIKeyID, key.ID)
+		c.Set(ContextKeyAdmin, key.Admin)
+		if key.Plan != "" {
+			c.Set(ContextKeyPlan, key.Plan)
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), tenant.Tenant{
+			ID:   key.TenantID,
+			Plan: key.Plan,
+		}))
+
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects requests that were not authenticated with an admin key
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool(ContextKeyAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin API key required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// apiKeyFromRequest extracts the API key from the Authorization or X-API-Key headers
+func apiKeyFromRequest(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
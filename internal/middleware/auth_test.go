@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/auth"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyService(t *testing.T) *auth.Service {
+	store, err := auth.NewFileKeyStore(filepath.Join(t.TempDir(), "keys.json"))
+	require.NoError(t, err)
+	return auth.NewService(store)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+	keys := newTestKeyService(t)
+
+	issued, err := keys.Create(ctx, auth.CreateKeyRequest{TenantID: "acme", Plan: "pro"})
+	require.NoError(t, err)
+	revoked, err := keys.Create(ctx, auth.CreateKeyRequest{TenantID: "acme"})
+	require.NoError(t, err)
+	require.NoError(t, keys.Revoke(ctx, revoked.APIKey.ID))
+
+	r := gin.New()
+	r.Use(AuthMiddleware(keys))
+	r.GET("/whoami", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"tenant":         c.GetString(ContextKeyTenantID),
+			"plan":           c.GetString(ContextKeyPlan),
+			"request_tenant": tenant.ID(c.Request.Context()),
+		})
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+	}{
+		{"bearer token", "Authorization", "Bearer " + issued.Key, http.StatusOK},
+		{"api key header", "X-API-Key", issued.Key, http.StatusOK},
+		{"missing key", "", "", http.StatusUnauthorized},
+		{"unknown key", "X-API-Key", "blue_unknown", http.StatusUnauthorized},
+		{"revoked key", "Authorization", "Bearer " + revoked.Key, http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			if test.header != "" {
+				req.Header.Set(test.header, test.value)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.status, w.Code)
+			if test.status == http.StatusOK {
+				assert.JSONEq(t, `{"tenant":"acme","plan":"pro","request_tenant":"acme"}`, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+	keys := newTestKeyService(t)
+
+	user, err := keys.Create(ctx, auth.CreateKeyRequest{TenantID: "acme"})
+	require.NoError(t, err)
+	admin, err := keys.Create(ctx, auth.CreateKeyRequest{TenantID: "ops", Admin: true})
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(AuthMiddleware(keys), RequireAdmin())
+	r.GET("/admin", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for key, status := range map[string]int{user.Key: http.StatusForbidden, admin.Key: http.StatusOK} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("X-API-Key", key)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, status, w.Code)
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/jesee-kuya/blue/internal/usage"
+)
+
+// BudgetMiddleware rejects requests from tenants that have exhausted their model spend budget
+func BudgetMiddleware(tracker *usage.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := tracker.CheckBudget(c.Request.Context())
+
+		var budgetErr *usage.BudgetExceededError
+		if errors.As(err, &budgetErr) {
+			c.JSON(http.StatusPaymentRequired, gin.H{
+				"error":     budgetErr.Error(),
+				"period":    budgetErr.Period,
+				"spent_usd": budgetErr.SpentUSD,
+				"limit_usd": budgetErr.LimitUSD,
+			})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Warn("Budget check failed, allowing request", logging.KeyError, err)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/metrics"
+)
+
+// MetricsMiddleware records the count and latency of every request by route
+// pattern, method and status. Requests that match no route share one label.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTPRequest(route, c.Request.Method, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsMiddleware_RecordsRoutePattern(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(MetricsMiddleware())
+	r.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/items/1", "/items/2", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	assert.Contains(t, body, `blue_http_requests_total{method="GET",route="/items/:id",status="204"} 2`)
+	assert.Contains(t, body, `blue_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.NotContains(t, body, `route="/items/1"`)
+}
@@ -1,13 +1,21 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/jesee-kuya/blue/internal/metrics"
 )
 
 const (
@@ -16,55 +24,384 @@ const (
 	RateLimitPrefix = "ratelimit:"
 )
 
-// RateLimitMiddleware creates a rate limiting middleware using Redis
-func RateLimitMiddleware(redisClient *cache.RedisClient) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
-		key := fmt.Sprintf("%s%s", RateLimitPrefix, clientIP)
+// Algorithm identifies the strategy a rate limit policy uses to count requests
+type Algorithm string
 
-		// Get current timestamp for rate limit window
-		now := time.Now()
-		windowStart := now.Truncate(RateLimitWindow).Unix()
-		rateLimitKey := fmt.Sprintf("%s:%d", key, windowStart)
+const (
+	AlgorithmFixedWindow   Algorithm = "fixed_window"
+	AlgorithmSlidingWindow Algorithm = "sliding_window"
+	AlgorithmSlidingLog    Algorithm = "sliding_log"
+	AlgorithmTokenBucket   Algorithm = "token_bucket"
+)
 
-		// Increment counter for this IP in current window
-		count, err := redisClient.Incr(rateLimitKey)
-		if err != nil {
-			// If Redis is down, allow the request but log error
-			c.Header("X-RateLimit-Limit", strconv.Itoa(RateLimitMax))
-			c.Header("X-RateLimit-Remaining", "0")
-			c.Header("X-RateLimit-Reset", strconv.FormatInt(windowStart+int64(RateLimitWindow.Seconds()), 10))
-			c.Next()
-			return
-		}
+// RateLimitPolicy describes how requests to a route group are limited
+type RateLimitPolicy struct {
+	Name      string
+	Algorithm Algorithm
+	Limit     int           // requests allowed per window (refill rate for token buckets)
+	Window    time.Duration // window length, or refill period for token buckets
+	Burst     int           // token bucket capacity, defaults to Limit
+}
+
+// DefaultRateLimitPolicy applies to general API routes
+var DefaultRateLimitPolicy = RateLimitPolicy{
+	Name:      "default",
+	Algorithm: AlgorithmSlidingWindow,
+	Limit:     RateLimitMax,
+	Window:    RateLimitWindow,
+}
+
+// MarketingRateLimitPolicy is stricter because every marketing request spends OpenAI tokens
+var MarketingRateLimitPolicy = RateLimitPolicy{
+	Name:      "marketing",
+	Algorithm: AlgorithmTokenBucket,
+	Limit:     20,
+	Window:    RateLimitWindow,
+	Burst:     5,
+}
+
+// Plan describes the request quotas granted to an identity's subscription tier
+type Plan struct {
+	Name      string
+	PerMinute int // requests per minute across all routes, 0 means unlimited
+	PerDay    int // requests per UTC day across all routes, 0 means unlimited
+}
+
+// AnonymousPlan applies to requests without an authenticated identity
+const AnonymousPlan = "anonymous"
+
+// DefaultPlans maps plan names to their quotas
+var DefaultPlans = map[string]Plan{
+	AnonymousPlan: {Name: AnonymousPlan, PerMinute: 60, PerDay: 1000},
+	"free":        {Name: "free", PerMinute: 120, PerDay: 5000},
+	"pro":         {Name: "pro", PerMinute: 600, PerDay: 100000},
+	"enterprise":  {Name: "enterprise", PerMinute: 3000},
+}
+
+// Gin context keys populated by authentication and read when deriving rate limit identities
+const (
+	ContextKeyTenantID = "tenant_id"
+	ContextKeyAPIKeyID = "api_key_id"
+	ContextKeyUserID   = "user_id"
+	ContextKeyPlan     = "plan"
+)
+
+// RateLimitResult reports the outcome of a rate limit check
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+// RateLimiter checks policies against Redis using atomic Lua scripts, falling back
+// to an in-memory limiter when Redis is unavailable
+type RateLimiter struct {
+	Plans       map[string]Plan
+	redisClient *cache.RedisClient
+	fallback    *MemoryLimiter
+	degraded    atomic.Bool
+	now         func() time.Time
+}
+
+// NewRateLimiter creates a new Redis-backed rate limiter using the default plans
+func NewRateLimiter(redisClient *cache.RedisClient) *RateLimiter {
+	return &RateLimiter{
+		Plans:       DefaultPlans,
+		redisClient: redisClient,
+		fallback:    NewMemoryLimiter(),
+		now:         time.Now,
+	}
+}
 
-		// Set expiration on first increment
-		if count == 1 {
-			redisClient.Expire(rateLimitKey, RateLimitWindow)
+// Allow records a request for the given identity and reports whether it is within the policy
+func (l *RateLimiter) Allow(ctx context.Context, identity string, policy RateLimitPolicy) (RateLimitResult, error) {
+	result, err := l.allowRedis(ctx, identity, policy)
+	if err == nil {
+		if l.degraded.CompareAndSwap(true, false) {
+			slog.Info("Rate limiter recovered, using Redis again")
 		}
+		return result, nil
+	}
+
+	if errors.Is(err, errInvalidPolicy) {
+		return RateLimitResult{}, err
+	}
+
+	// A request that ended mid-check says nothing about Redis
+	if ctx.Err() == nil && l.degraded.CompareAndSwap(false, true) {
+		slog.Warn("Rate limiter falling back to in-memory limits", logging.KeyError, err)
+	}
+	return l.fallback.allowAt(identity, policy, l.now())
+}
+
+// Refund returns a request previously allowed by Allow to a fixed or sliding window
+// policy, for requests that a later check rejected
+func (l *RateLimiter) Refund(ctx context.Context, identity string, policy RateLimitPolicy) error {
+	if err := policy.validateRefund(); err != nil {
+		return err
+	}
+	if l.degraded.Load() {
+		return l.fallback.refundAt(identity, policy, l.now())
+	}
+
+	key := fmt.Sprintf("%s{%s}:%s", RateLimitPrefix, identity, policy.Name)
+	now := l.now().UnixMilli()
+	windowStart := now - now%policy.Window.Milliseconds()
+	_, err := l.redisClient.RunScript(ctx, refundScript, []string{fmt.Sprintf("%s:%d", key, windowStart)})
+	return err
+}
+
+var errInvalidPolicy = errors.New("invalid rate limit policy")
+
+// validate checks that a policy can be enforced
+func (p RateLimitPolicy) validate() error {
+	if p.Limit <= 0 || p.Window <= 0 {
+		return fmt.Errorf("%w %q", errInvalidPolicy, p.Name)
+	}
+	switch p.Algorithm {
+	case AlgorithmFixedWindow, AlgorithmSlidingWindow, AlgorithmSlidingLog, AlgorithmTokenBucket, "":
+		return nil
+	}
+	return fmt.Errorf("%w %q: unknown rate limit algorithm %q", errInvalidPolicy, p.Name, p.Algorithm)
+}
+
+// validateRefund checks that requests allowed by a policy can be refunded
+func (p RateLimitPolicy) validateRefund() error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	switch p.Algorithm {
+	case AlgorithmFixedWindow, AlgorithmSlidingWindow, "":
+		return nil
+	}
+	return fmt.Errorf("%w %q: %s requests cannot be refunded", errInvalidPolicy, p.Name, p.Algorithm)
+}
+
+// allowRedis evaluates the policy's Lua script in Redis
+func (l *RateLimiter) allowRedis(ctx context.Context, identity string, policy RateLimitPolicy) (RateLimitResult, error) {
+	if err := policy.validate(); err != nil {
+		return RateLimitResult{}, err
+	}
 
-		// Calculate remaining requests and reset time
-		remaining := RateLimitMax - int(count)
-		if remaining < 0 {
-			remaining = 0
+	// The hash tag keeps every key for one identity in the same cluster slot
+	key := fmt.Sprintf("%s{%s}:%s", RateLimitPrefix, identity, policy.Name)
+	now := l.now().UnixMilli()
+	window := policy.Window.Milliseconds()
+
+	var raw interface{}
+	var err error
+
+	switch policy.Algorithm {
+	case AlgorithmFixedWindow:
+		windowStart := now - now%window
+		raw, err = l.redisClient.RunScript(ctx, fixedWindowScript,
+			[]string{fmt.Sprintf("%s:%d", key, windowStart)},
+			policy.Limit, window)
+	case AlgorithmSlidingLog:
+		member := fmt.Sprintf("%d-%d", now, rand.Int63())
+		raw, err = l.redisClient.RunScript(ctx, slidingLogScript,
+			[]string{key},
+			policy.Limit, window, now, member)
+	case AlgorithmTokenBucket:
+		raw, err = l.redisClient.RunScript(ctx, tokenBucketScript,
+			[]string{key},
+			policy.burst(), float64(policy.Limit)/float64(window), now)
+	default:
+		windowStart := now - now%window
+		raw, err = l.redisClient.RunScript(ctx, slidingWindowScript,
+			[]string{fmt.Sprintf("%s:%d", key, windowStart), fmt.Sprintf("%s:%d", key, windowStart-window)},
+			policy.Limit, window, now-windowStart)
+	}
+
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	return parseScriptResult(raw, policy)
+}
+
+// PlanFor returns the quotas for the named plan, defaulting unknown plans to the anonymous tier
+func (l *RateLimiter) PlanFor(name string) Plan {
+	if plan, ok := l.Plans[name]; ok {
+		return plan
+	}
+	return l.Plans[AnonymousPlan]
+}
+
+// burst returns the token bucket capacity for the policy
+func (p RateLimitPolicy) burst() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// parseScriptResult converts the {allowed, remaining, retry_ms, reset_ms} script reply
+func parseScriptResult(raw interface{}, policy RateLimitPolicy) (RateLimitResult, error) {
+	values, ok := raw.([]interface{})
+	if !ok || len(values) != 4 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script reply: %v", raw)
+	}
+
+	ints := make([]int64, len(values))
+	for i, v := range values {
+		n, ok := v.(int64)
+		if !ok {
+			return RateLimitResult{}, fmt.Errorf("unexpected rate limit script value at %d: %v", i, v)
 		}
-		resetTime := windowStart + int64(RateLimitWindow.Seconds())
+		ints[i] = n
+	}
+
+	limit := policy.Limit
+	if policy.Algorithm == AlgorithmTokenBucket {
+		limit = policy.burst()
+	}
 
-		// Set rate limit headers
-		c.Header("X-RateLimit-Limit", strconv.Itoa(RateLimitMax))
-		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
-		c.Header("X-RateLimit-Reset", strconv.FormatInt(resetTime, 10))
+	return RateLimitResult{
+		Allowed:    ints[0] == 1,
+		Limit:      limit,
+		Remaining:  int(max(ints[1], 0)),
+		RetryAfter: time.Duration(ints[2]) * time.Millisecond,
+		ResetAfter: time.Duration(ints[3]) * time.Millisecond,
+	}, nil
+}
 
-		// Check if rate limit exceeded
-		if count > RateLimitMax {
+// RateLimitIdentity derives the rate limit key for a request from its authenticated
+// identity, falling back to the client IP for anonymous requests
+func RateLimitIdentity(c *gin.Context) string {
+	if tenantID := c.GetString(ContextKeyTenantID); tenantID != "" {
+		return "tenant:" + tenantID
+	}
+	if keyID := c.GetString(ContextKeyAPIKeyID); keyID != "" {
+		return "key:" + keyID
+	}
+	if userID := c.GetString(ContextKeyUserID); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimitMiddleware creates a rate limiting middleware enforcing the route policy
+// together with the per-minute and per-day quotas of the caller's plan
+func RateLimitMiddleware(limiter *RateLimiter, policy RateLimitPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := logging.FromContext(ctx)
+		identity := RateLimitIdentity(c)
+		planName := c.GetString(ContextKeyPlan)
+		if planName == "" {
+			planName = AnonymousPlan
+		}
+		plan := limiter.PlanFor(planName)
+
+		// Quotas are charged first and refunded when a later check turns the request
+		// away, so a rejected request never uses up any of the caller's limits
+		var charged []RateLimitPolicy
+		refund := func() {
+			for _, quota := range charged {
+				if err := limiter.Refund(ctx, identity, quota); err != nil {
+					logger.Warn("Failed to refund rate limit quota", "policy", quota.Name, logging.KeyError, err)
+				}
+			}
+		}
+		reject := func(name string, result RateLimitResult) {
+			refund()
+			metrics.ObserveRateLimitRejection(name)
+			retryAfter := retryAfterSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error":       "Rate limit exceeded",
-				"retry_after": int64(RateLimitWindow.Seconds()) - (now.Unix() - windowStart),
+				"policy":      name,
+				"plan":        plan.Name,
+				"retry_after": retryAfter,
 			})
 			c.Abort()
+		}
+
+		if plan.PerDay > 0 {
+			quota := RateLimitPolicy{
+				Name:      "quota:day",
+				Algorithm: AlgorithmFixedWindow,
+				Limit:     plan.PerDay,
+				Window:    24 * time.Hour,
+			}
+			daily, err := limiter.Allow(ctx, identity, quota)
+			if err != nil {
+				logger.Warn("Daily quota check failed, skipping it", "policy", quota.Name, logging.KeyError, err)
+			} else {
+				c.Header("X-Quota-Limit", strconv.Itoa(daily.Limit))
+				c.Header("X-Quota-Remaining", strconv.Itoa(daily.Remaining))
+				c.Header("X-Quota-Reset", strconv.FormatInt(time.Now().Add(daily.ResetAfter).Unix(), 10))
+				if !daily.Allowed {
+					reject(quota.Name, daily)
+					return
+				}
+				charged = append(charged, quota)
+			}
+		}
+
+		// The per-minute plan quota is shared by every route
+		var minute *RateLimitResult
+		if plan.PerMinute > 0 {
+			quota := RateLimitPolicy{
+				Name:      "quota:minute",
+				Algorithm: AlgorithmSlidingWindow,
+				Limit:     plan.PerMinute,
+				Window:    time.Minute,
+			}
+			result, err := limiter.Allow(ctx, identity, quota)
+			if err != nil {
+				logger.Warn("Per-minute quota check failed, skipping it", "policy", quota.Name, logging.KeyError, err)
+			} else {
+				if !result.Allowed {
+					setRateLimitHeaders(c, result)
+					reject(quota.Name, result)
+					return
+				}
+				charged = append(charged, quota)
+				minute = &result
+			}
+		}
+
+		result, err := limiter.Allow(ctx, identity, policy)
+		if err != nil {
+			refund()
+			logger.Error("Rate limit check failed", "policy", policy.Name, logging.KeyError, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Rate limit check failed"})
+			c.Abort()
+			return
+		}
+
+		// Report whichever of the route policy and the minute quota is closer to its limit
+		if result.Allowed && minute != nil && minute.Remaining < result.Remaining {
+			setRateLimitHeaders(c, *minute)
+		} else {
+			setRateLimitHeaders(c, result)
+		}
+
+		if !result.Allowed {
+			reject(policy.Name, result)
 			return
 		}
 
 		c.Next()
 	}
 }
+
+// setRateLimitHeaders reports a rate limit result in the X-RateLimit headers
+func setRateLimitHeaders(c *gin.Context, result RateLimitResult) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.ResetAfter).Unix(), 10))
+}
+
+// retryAfterSeconds rounds a delay up to whole seconds as required by the Retry-After header (RFC 9110)
+func retryAfterSeconds(d time.Duration) int64 {
+	seconds := int64(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// memoryEntry holds the per-key state for every algorithm
+type memoryEntry struct {
+	windowStart int64   // fixed and sliding windows: start of the current window (ms)
+	current     int     // fixed and sliding windows: requests in the current window
+	previous    int     // sliding window: requests in the previous window
+	log         []int64 // sliding log: request timestamps (ms)
+	tokens      float64 // token bucket: available tokens
+	updated     int64   // token bucket: last refill (ms)
+	expires     int64   // entry can be discarded after this time (ms)
+}
+
+// MemoryLimiter is a process-local rate limiter used when Redis is unavailable.
+// Limits are enforced per instance, so a fleet of N instances admits up to N times
+// the configured rate while degraded.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep int64
+}
+
+// NewMemoryLimiter creates a new in-memory rate limiter
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+// Allow records a request for the given identity and reports whether it is within the policy
+func (m *MemoryLimiter) Allow(identity string, policy RateLimitPolicy) (RateLimitResult, error) {
+	return m.allowAt(identity, policy, time.Now())
+}
+
+// allowAt evaluates the policy at a given instant, mirroring the Redis scripts
+func (m *MemoryLimiter) allowAt(identity string, policy RateLimitPolicy, at time.Time) (RateLimitResult, error) {
+	if err := policy.validate(); err != nil {
+		return RateLimitResult{}, err
+	}
+
+	now := at.UnixMilli()
+	window := policy.Window.Milliseconds()
+	key := fmt.Sprintf("%s:%s", identity, policy.Name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	entry, ok := m.entries[key]
+	if !ok {
+		entry = &memoryEntry{tokens: float64(policy.burst()), updated: now}
+		m.entries[key] = entry
+	}
+
+	result := RateLimitResult{Limit: policy.Limit}
+
+	switch policy.Algorithm {
+	case AlgorithmFixedWindow:
+		windowStart := now - now%window
+		if entry.windowStart != windowStart {
+			entry.windowStart = windowStart
+			entry.current = 0
+		}
+		entry.current++
+		entry.expires = windowStart + window
+		result.ResetAfter = time.Duration(windowStart+window-now) * time.Millisecond
+		if entry.current > policy.Limit {
+			result.RetryAfter = result.ResetAfter
+		} else {
+			result.Allowed = true
+			result.Remaining = policy.Limit - entry.current
+		}
+
+	case AlgorithmSlidingLog:
+		kept := entry.log[:0]
+		for _, ts := range entry.log {
+			if ts > now-window {
+				kept = append(kept, ts)
+			}
+		}
+		entry.log = kept
+		if len(entry.log) < policy.Limit {
+			entry.log = append(entry.log, now)
+			result.Allowed = true
+			result.Remaining = policy.Limit - len(entry.log)
+		} else {
+			result.RetryAfter = time.Duration(max(window-(now-entry.log[0]), 1)) * time.Millisecond
+		}
+		entry.expires = now + window
+		result.ResetAfter = time.Duration(window-(now-entry.log[0])) * time.Millisecond
+
+	case AlgorithmTokenBucket:
+		capacity := float64(policy.burst())
+		rate := float64(policy.Limit) / float64(window)
+		entry.tokens = math.Min(capacity, entry.tokens+float64(max(now-entry.updated, 0))*rate)
+		entry.updated = now
+		result.Limit = policy.burst()
+		if entry.tokens >= 1 {
+			entry.tokens--
+			result.Allowed = true
+		} else {
+			result.RetryAfter = time.Duration(math.Ceil((1-entry.tokens)/rate)) * time.Millisecond
+		}
+		result.Remaining = int(entry.tokens)
+		entry.expires = now + int64(math.Ceil(capacity/rate))
+		result.ResetAfter = time.Duration(math.Ceil((capacity-entry.tokens)/rate)) * time.Millisecond
+
+	default:
+		windowStart := now - now%window
+		switch {
+		case entry.windowStart == windowStart-window:
+			entry.previous, entry.current = entry.current, 0
+		case entry.windowStart != windowStart:
+			entry.previous, entry.current = 0, 0
+		}
+		entry.windowStart = windowStart
+		elapsed := now - windowStart
+		estimated := float64(entry.previous)*float64(window-elapsed)/float64(window) + float64(entry.current)
+		entry.expires = windowStart + 2*window
+		result.ResetAfter = time.Duration(window-elapsed) * time.Millisecond
+		if estimated+1 > float64(policy.Limit) {
+			result.RetryAfter = result.ResetAfter
+		} else {
+			entry.current++
+			result.Allowed = true
+			result.Remaining = int(math.Floor(float64(policy.Limit) - estimated - 1))
+		}
+	}
+
+	return result, nil
+}
+
+// refundAt gives back one request counted in the policy's current window
+func (m *MemoryLimiter) refundAt(identity string, policy RateLimitPolicy, at time.Time) error {
+	if err := policy.validateRefund(); err != nil {
+		return err
+	}
+
+	now := at.UnixMilli()
+	windowStart := now - now%policy.Window.Milliseconds()
+	key := fmt.Sprintf("%s:%s", identity, policy.Name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.entries[key]; ok && entry.windowStart == windowStart && entry.current > 0 {
+		entry.current--
+	}
+	return nil
+}
+
+// sweep drops expired entries at most once per second so memory stays bounded
+func (m *MemoryLimiter) sweep(now int64) {
+	if now-m.lastSweep < 1000 {
+		return
+	}
+	m.lastSweep = now
+	for key, entry := range m.entries {
+		if entry.expires > 0 && entry.expires < now {
+			delete(m.entries, key)
+		}
+	}
+}
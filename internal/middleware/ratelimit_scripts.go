@@ -0,0 +1,135 @@
+package middleware
+
+import "github.com/redis/go-redis/v9"
+
+// Every script returns {allowed, remaining, retry_after_ms, reset_after_ms} so that
+// all algorithms can share the same result handling. The current time is passed in
+// by the caller (in milliseconds) to keep the scripts deterministic.
+
+// fixedWindowScript counts requests in a single window key.
+// KEYS[1] = window key
+// ARGV[1] = limit, ARGV[2] = window (ms)
+var fixedWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], window)
+end
+
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	redis.call('PEXPIRE', KEYS[1], window)
+	ttl = window
+end
+
+if count > limit then
+	return {0, 0, ttl, ttl}
+end
+return {1, limit - count, 0, ttl}
+`)
+
+// slidingWindowScript approximates a rolling window by weighting the previous
+// window's count by how much of it still overlaps the rolling window.
+// KEYS[1] = current window key, KEYS[2] = previous window key
+// ARGV[1] = limit, ARGV[2] = window (ms), ARGV[3] = elapsed time in current window (ms)
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+local weight = (window - elapsed) / window
+local estimated = previous * weight + current
+
+if estimated + 1 > limit then
+	local retry = window - elapsed
+	local budget = limit - current - 1
+	if previous > 0 and budget >= 0 then
+		retry = math.ceil(window * (1 - budget / previous)) - elapsed
+	end
+	if retry < 1 then
+		retry = 1
+	end
+	return {0, 0, retry, window - elapsed}
+end
+
+redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {1, math.floor(limit - estimated - 1), 0, window - elapsed}
+`)
+
+// slidingLogScript keeps a timestamped log of requests in a sorted set for an
+// exact rolling window at the cost of one member per request.
+// KEYS[1] = log key
+// ARGV[1] = limit, ARGV[2] = window (ms), ARGV[3] = now (ms), ARGV[4] = unique member
+var slidingLogScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	redis.call('PEXPIRE', KEYS[1], window)
+	local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+	return {1, limit - count - 1, 0, window - (now - tonumber(oldest[2]))}
+end
+
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+local retry = window - (now - tonumber(oldest[2]))
+if retry < 1 then
+	retry = 1
+end
+return {0, 0, retry, retry}
+`)
+
+// tokenBucketScript refills tokens continuously at limit/window and allows bursts
+// up to the bucket capacity.
+// KEYS[1] = bucket key
+// ARGV[1] = capacity, ARGV[2] = refill rate (tokens per ms), ARGV[3] = now (ms)
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity / rate))
+
+local reset = math.ceil((capacity - tokens) / rate)
+return {allowed, math.floor(tokens), retry, reset}
+`)
+
+// refundScript gives back one request counted in a fixed or sliding window,
+// leaving windows that have already expired or been emptied alone.
+// KEYS[1] = current window key
+var refundScript = redis.NewScript(`
+local count = tonumber(redis.call('GET', KEYS[1]) or '0')
+if count > 0 then
+	redis.call('DECR', KEYS[1])
+end
+return count
+`)
@@ -0,0 +1,376 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(t *testing.T, now time.Time) (*RateLimiter, *time.Time) {
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	t.Cleanup(func() { redisClient.Close() })
+
+	clock := now
+	limiter := NewRateLimiter(redisClient)
+	limiter.now = func() time.Time { return clock }
+	return limiter, &clock
+}
+
+func TestRateLimiter_Algorithms_EnforceLimit(t *testing.T) {
+	algorithms := []Algorithm{AlgorithmFixedWindow, AlgorithmSlidingWindow, AlgorithmSlidingLog, AlgorithmTokenBucket}
+
+	for _, algorithm := range algorithms {
+		t.Run(string(algorithm), func(t *testing.T) {
+			limiter, _ := newTestLimiter(t, time.UnixMilli(1_700_000_010_000))
+			policy := RateLimitPolicy{Name: "test", Algorithm: algorithm, Limit: 3, Window: time.Minute}
+
+			for i := 0; i < 3; i++ {
+				result, err := limiter.Allow(context.Background(), "client", policy)
+				require.NoError(t, err)
+				assert.True(t, result.Allowed, "request %d should be allowed", i+1)
+				assert.Equal(t, 2-i, result.Remaining)
+			}
+
+			result, err := limiter.Allow(context.Background(), "client", policy)
+			require.NoError(t, err)
+			assert.False(t, result.Allowed)
+			assert.Equal(t, 0, result.Remaining)
+			assert.Greater(t, result.RetryAfter, time.Duration(0))
+
+			// Other identities have their own budget
+			result, err = limiter.Allow(context.Background(), "other-client", policy)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+		})
+	}
+}
+
+func TestRateLimiter_SlidingWindow_NoBurstAcrossBoundary(t *testing.T) {
+	windowStart := time.UnixMilli(1_700_000_040_000).Truncate(time.Minute)
+	limiter, clock := newTestLimiter(t, windowStart.Add(59*time.Second))
+	policy := RateLimitPolicy{Name: "test", Algorithm: AlgorithmSlidingWindow, Limit: 10, Window: time.Minute}
+
+	for i := 0; i < 10; i++ {
+		result, err := limiter.Allow(context.Background(), "client", policy)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	// A fixed window would reset here and allow another 10 requests immediately
+	*clock = windowStart.Add(61 * time.Second)
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		result, err := limiter.Allow(context.Background(), "client", policy)
+		require.NoError(t, err)
+		if result.Allowed {
+			allowed++
+		}
+	}
+	assert.LessOrEqual(t, allowed, 1)
+}
+
+func TestRateLimiter_SlidingLog_RetryAfterOldestEntry(t *testing.T) {
+	start := time.UnixMilli(1_700_000_000_000)
+	limiter, clock := newTestLimiter(t, start)
+	policy := RateLimitPolicy{Name: "test", Algorithm: AlgorithmSlidingLog, Limit: 2, Window: 10 * time.Second}
+
+	limiter.Allow(context.Background(), "client", policy)
+	*clock = start.Add(4 * time.Second)
+	limiter.Allow(context.Background(), "client", policy)
+
+	result, err := limiter.Allow(context.Background(), "client", policy)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 6*time.Second, result.RetryAfter)
+
+	*clock = start.Add(10*time.Second + time.Millisecond)
+	result, err = limiter.Allow(context.Background(), "client", policy)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestRateLimiter_TokenBucket_BurstAndRefill(t *testing.T) {
+	start := time.UnixMilli(1_700_000_000_000)
+	limiter, clock := newTestLimiter(t, start)
+	policy := RateLimitPolicy{Name: "test", Algorithm: AlgorithmTokenBucket, Limit: 60, Window: time.Minute, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		result, err := limiter.Allow(context.Background(), "client", policy)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2, result.Limit)
+	}
+
+	result, err := limiter.Allow(context.Background(), "client", policy)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	// One token refills per second
+	*clock = start.Add(time.Second)
+	result, err = limiter.Allow(context.Background(), "client", policy)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestRateLimiter_InvalidPolicy(t *testing.T) {
+	limiter, _ := newTestLimiter(t, time.Now())
+
+	_, err := limiter.Allow(context.Background(), "client", RateLimitPolicy{Name: "broken", Algorithm: "leaky", Limit: 1, Window: time.Second})
+	assert.ErrorContains(t, err, "unknown rate limit algorithm")
+
+	_, err = limiter.Allow(context.Background(), "client", RateLimitPolicy{Name: "empty"})
+	assert.ErrorContains(t, err, "invalid rate limit policy")
+}
+
+func TestRateLimitMiddleware_SetsRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+
+	policy := RateLimitPolicy{Name: "strict", Algorithm: AlgorithmFixedWindow, Limit: 1, Window: time.Minute}
+	r := gin.New()
+	r.Use(RateLimitMiddleware(NewRateLimiter(redisClient), policy))
+	r.GET("/limited", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), `"policy":"strict"`)
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	assert.Equal(t, int64(1), retryAfterSeconds(0))
+	assert.Equal(t, int64(1), retryAfterSeconds(200*time.Millisecond))
+	assert.Equal(t, int64(3), retryAfterSeconds(2100*time.Millisecond))
+}
+
+func TestRateLimiter_FallsBackToMemoryWhenRedisDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+	mr.Close()
+
+	limiter := NewRateLimiter(redisClient)
+	policy := RateLimitPolicy{Name: "test", Algorithm: AlgorithmSlidingWindow, Limit: 2, Window: time.Minute}
+
+	for i := 0; i < 2; i++ {
+		result, err := limiter.Allow(context.Background(), "client", policy)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	result, err := limiter.Allow(context.Background(), "client", policy)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.True(t, limiter.degraded.Load())
+}
+
+func TestMemoryLimiter_Algorithms_EnforceLimit(t *testing.T) {
+	algorithms := []Algorithm{AlgorithmFixedWindow, AlgorithmSlidingWindow, AlgorithmSlidingLog, AlgorithmTokenBucket}
+	now := time.UnixMilli(1_700_000_010_000)
+
+	for _, algorithm := range algorithms {
+		t.Run(string(algorithm), func(t *testing.T) {
+			limiter := NewMemoryLimiter()
+			policy := RateLimitPolicy{Name: "test", Algorithm: algorithm, Limit: 3, Window: time.Minute}
+
+			for i := 0; i < 3; i++ {
+				result, err := limiter.allowAt("client", policy, now)
+				require.NoError(t, err)
+				assert.True(t, result.Allowed, "request %d should be allowed", i+1)
+				assert.Equal(t, 2-i, result.Remaining)
+			}
+
+			result, err := limiter.allowAt("client", policy, now)
+			require.NoError(t, err)
+			assert.False(t, result.Allowed)
+			assert.Greater(t, result.RetryAfter, time.Duration(0))
+
+			result, err = limiter.allowAt("client", policy, now.Add(2*time.Minute))
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+		})
+	}
+}
+
+func TestRateLimitIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		values   map[string]string
+		expected string
+	}{
+		{"tenant", map[string]string{ContextKeyTenantID: "acme", ContextKeyAPIKeyID: "k1"}, "tenant:acme"},
+		{"api key", map[string]string{ContextKeyAPIKeyID: "k1", ContextKeyUserID: "u1"}, "key:k1"},
+		{"user", map[string]string{ContextKeyUserID: "u1"}, "user:u1"},
+		{"anonymous", map[string]string{}, "ip:192.0.2.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range test.values {
+				c.Set(k, v)
+			}
+			assert.Equal(t, test.expected, RateLimitIdentity(c))
+		})
+	}
+}
+
+func TestRateLimitMiddleware_DailyQuotaPerPlan(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+
+	limiter := NewRateLimiter(redisClient)
+	limiter.Plans = map[string]Plan{
+		AnonymousPlan: {Name: AnonymousPlan, PerMinute: 100, PerDay: 1},
+		"pro":         {Name: "pro", PerMinute: 100, PerDay: 2},
+	}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if tenant := c.GetHeader("X-Test-Tenant"); tenant != "" {
+			c.Set(ContextKeyTenantID, tenant)
+			c.Set(ContextKeyPlan, "pro")
+		}
+	})
+	r.Use(RateLimitMiddleware(limiter, DefaultRateLimitPolicy))
+	r.GET("/limited", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	send := func(tenant string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		if tenant != "" {
+			req.Header.Set("X-Test-Tenant", tenant)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Quota-Limit"))
+	assert.Equal(t, "0", w.Header().Get("X-Quota-Remaining"))
+	assert.Equal(t, http.StatusTooManyRequests, send("").Code)
+
+	// The tenant shares the NAT'd IP but has its own, larger quota
+	w = send("acme")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-Quota-Limit"))
+	assert.Equal(t, http.StatusOK, send("acme").Code)
+
+	w = send("acme")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), `"plan":"pro"`)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestRateLimitMiddleware_RefundsQuotasOnRejection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+
+	limiter := NewRateLimiter(redisClient)
+	limiter.Plans = map[string]Plan{AnonymousPlan: {Name: AnonymousPlan, PerMinute: 2, PerDay: 3}}
+
+	strict := RateLimitPolicy{Name: "strict", Algorithm: AlgorithmFixedWindow, Limit: 1, Window: time.Minute}
+	r := gin.New()
+	r.GET("/strict", RateLimitMiddleware(limiter, strict), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/open", RateLimitMiddleware(limiter, DefaultRateLimitPolicy), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/broken", RateLimitMiddleware(limiter, RateLimitPolicy{Name: "broken"}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	send := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, send("/strict").Code)
+	for i := 0; i < 3; i++ {
+		w := send("/strict")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), `"policy":"strict"`)
+	}
+
+	// A failed route check neither leaks its error nor spends the quotas
+	w := send("/broken")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error":"Rate limit check failed"}`, w.Body.String())
+
+	// Only the request that got through counted against the quotas
+	w = send("/open")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Quota-Remaining"))
+
+	w = send("/open")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), `"policy":"quota:minute"`)
+	assert.Equal(t, "0", w.Header().Get("X-Quota-Remaining"))
+
+	// The request rejected by the minute quota gave its daily charge back
+	limiter.Plans[AnonymousPlan] = Plan{Name: AnonymousPlan, PerMinute: 100, PerDay: 3}
+	assert.Equal(t, http.StatusOK, send("/open").Code)
+	w = send("/open")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), `"policy":"quota:day"`)
+}
+
+func TestRateLimiter_Refund(t *testing.T) {
+	now := time.UnixMilli(1_700_000_010_000)
+	policies := []RateLimitPolicy{
+		{Name: "fixed", Algorithm: AlgorithmFixedWindow, Limit: 1, Window: time.Minute},
+		{Name: "sliding", Algorithm: AlgorithmSlidingWindow, Limit: 1, Window: time.Minute},
+	}
+
+	for _, policy := range policies {
+		t.Run(policy.Name, func(t *testing.T) {
+			ctx := context.Background()
+			limiter, _ := newTestLimiter(t, now)
+
+			result, err := limiter.Allow(ctx, "client", policy)
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+			require.NoError(t, limiter.Refund(ctx, "client", policy))
+
+			result, err = limiter.Allow(ctx, "client", policy)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+
+			memory := NewMemoryLimiter()
+			_, err = memory.allowAt("client", policy, now)
+			require.NoError(t, err)
+			require.NoError(t, memory.refundAt("client", policy, now))
+			result, err = memory.allowAt("client", policy, now)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+		})
+	}
+
+	limiter, _ := newTestLimiter(t, now)
+	err := limiter.Refund(context.Background(), "client", MarketingRateLimitPolicy)
+	assert.ErrorContains(t, err, "cannot be refunded")
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContextKeyRequestID is set on the gin context to the request's ID
+const ContextKeyRequestID = "request_id"
+
+// validRequestID limits caller-supplied IDs to something safe to log and echo back
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestIDMiddleware accepts the caller's X-Request-ID, or generates one,
+// echoes it on the response and carries it in the request context so that log
+// entries, spans and outbound calls made for the request share it
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(logging.RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+
+		c.Set(ContextKeyRequestID, id)
+		c.Header(logging.RequestIDHeader, id)
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("request.id", id))
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
+
+// LoggerMiddleware logs one structured entry per request, replacing gin's
+// default logger. Server errors log at error level and client errors at warn.
+func LoggerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.String("client_ip", c.ClientIP()),
+			logging.Latency(time.Since(start)),
+		}
+		if errs := c.Errors.String(); errs != "" {
+			attrs = append(attrs, slog.String(logging.KeyError, errs))
+		}
+		logging.FromContext(c.Request.Context()).LogAttrs(c.Request.Context(), level, "request completed", attrs...)
+	}
+}
+
+// newRequestID returns a random 16 byte hex ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
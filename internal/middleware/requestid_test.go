@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatJSON, slog.LevelInfo)
+	require.NoError(t, err)
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var seen string
+	r := gin.New()
+	r.Use(RequestIDMiddleware(), LoggerMiddleware())
+	r.GET("/items/:id", func(c *gin.Context) {
+		seen = logging.RequestID(c.Request.Context())
+		c.Status(http.StatusNotFound)
+	})
+
+	// A valid caller ID is kept and echoed back
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/items/1?email=jane@example.com", nil)
+	req.Header.Set(logging.RequestIDHeader, "upstream-123")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "upstream-123", w.Header().Get(logging.RequestIDHeader))
+	assert.Equal(t, "upstream-123", seen)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "upstream-123", entry[logging.KeyRequestID])
+	assert.Equal(t, "/items/:id", entry["route"])
+	assert.Equal(t, float64(http.StatusNotFound), entry["status"])
+	assert.Contains(t, entry, logging.KeyLatency)
+	assert.NotContains(t, buf.String(), "jane@example.com")
+
+	// Missing or unsafe IDs are replaced
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/items/2", nil)
+	req.Header.Set(logging.RequestIDHeader, "bad id\nwith newline")
+	r.ServeHTTP(w, req)
+	assert.Len(t, w.Header().Get(logging.RequestIDHeader), 32)
+	assert.Equal(t, w.Header().Get(logging.RequestIDHeader), seen)
+}
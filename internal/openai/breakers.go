@@ -0,0 +1,35 @@
+package openai
+
+import (
+	"context"
+
+	"github.com/jesee-kuya/blue/internal/breaker"
+)
+
+// Upstream names used for circuit breakers
+const (
+	UpstreamAmazon = "amazon"
+	UpstreamEbay   = "ebay"
+	UpstreamQloo   = "qloo"
+	UpstreamLLM    = "llm"
+)
+
+// Upstreams lists every upstream the client guards with a circuit breaker
+var Upstreams = []string{UpstreamAmazon, UpstreamEbay, UpstreamQloo, UpstreamLLM}
+
+// guard runs fn through the upstream's circuit breaker
+func (c *Client) guard(ctx context.Context, upstream string, fn func() error) error {
+	if c.Breakers == nil {
+		return fn()
+	}
+	return c.Breakers.Do(ctx, upstream, fn)
+}
+
+// breakerState returns the state of the upstream's circuit breaker, or an empty
+// state when breakers are disabled
+func (c *Client) breakerState(ctx context.Context, upstream string) breaker.State {
+	if c.Breakers == nil {
+		return ""
+	}
+	return c.Breakers.Get(upstream).State(ctx)
+}
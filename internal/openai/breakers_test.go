@@ -0,0 +1,82 @@
+package openai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jesee-kuya/blue/internal/breaker"
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteSearchMarketplace_ReportsSources(t *testing.T) {
+	client, amazon := newClarifyingClient()
+	client.Breakers = breaker.NewGroup(breaker.Settings{FailureThreshold: 2}, nil)
+	ebay := &stubMarketplace{err: &retry.StatusError{Service: "eBay", StatusCode: 503}}
+	client.EbayClient = ebay
+	ctx := context.Background()
+
+	result, err := client.executeSearchMarketplace(ctx, SearchMarketplaceArgs{Query: "headphones"})
+	require.NoError(t, err)
+	assert.Equal(t, []SourceStatus{
+		{Name: "Amazon", Status: SourceOK, Count: 1, Breaker: breaker.Closed},
+		{Name: "eBay", Status: SourceError, Error: "eBay API returned status 503", Breaker: breaker.Closed},
+	}, result.Sources)
+
+	// The second failure opens eBay's breaker and later searches skip it
+	result, err = client.executeSearchMarketplace(ctx, SearchMarketplaceArgs{Query: "headphones"})
+	require.NoError(t, err)
+	assert.Equal(t, breaker.Open, result.Sources[1].Breaker)
+
+	result, err = client.executeSearchMarketplace(ctx, SearchMarketplaceArgs{Query: "headphones"})
+	require.NoError(t, err)
+	assert.Equal(t, SourceUnavailable, result.Sources[1].Status)
+	assert.Equal(t, "circuit breaker for ebay is open", result.Sources[1].Error)
+	assert.Len(t, ebay.queries, 2)
+	assert.Len(t, amazon.queries, 3)
+	assert.Equal(t, 1, result.Count)
+}
+
+func TestProcessMessage_SearchResponseIncludesSources(t *testing.T) {
+	client, _ := newClarifyingClient(llm.FakeJSON(MessageIntent{Type: IntentSearch, Product: "headphones", Confidence: 0.9}))
+
+	response, err := client.ProcessMessage(context.Background(), "find headphones")
+
+	require.NoError(t, err)
+	require.NotNil(t, response.SearchResults)
+	require.Len(t, response.SearchResults.Sources, 2)
+	assert.Equal(t, "Amazon", response.SearchResults.Sources[0].Name)
+	assert.Equal(t, SourceOK, response.SearchResults.Sources[0].Status)
+}
+
+func TestExecuteGetTasteProfile_OpenBreakerIsNotRetried(t *testing.T) {
+	client, _ := newClarifyingClient()
+	client.QlooClient = qloo.NewClientWithConfig("test-api-key", "http://127.0.0.1:1")
+	client.Breakers = breaker.NewGroup(breaker.Settings{FailureThreshold: 1}, nil)
+	client.Tools.Retries["get_taste_profile"] = retry.Policy{MaxAttempts: 3}
+	ctx := context.Background()
+
+	_, err := client.executeWithRetry(ctx, FunctionCall{
+		Name:      "get_taste_profile",
+		Arguments: map[string]any{"description": "breaker description"},
+	})
+
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.NotContains(t, err.Error(), "attempts")
+	assert.Equal(t, breaker.Open, client.breakerState(ctx, UpstreamQloo))
+}
+
+func TestChat_OpenBreakerSkipsProvider(t *testing.T) {
+	ctx := context.Background()
+	client, provider := newFakeClassifierClient(llm.FakeText("Hello!"))
+	client.Breakers = breaker.NewGroup(breaker.Settings{FailureThreshold: 1}, nil)
+	client.Breakers.Do(ctx, UpstreamLLM, func() error { return assert.AnError })
+
+	_, _, err := client.SendMessage(ctx, "hi")
+
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.Empty(t, provider.Requests())
+}
@@ -0,0 +1,197 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/jesee-kuya/blue/internal/queryparser"
+)
+
+// Slots the orchestrator can ask the user to fill
+const (
+	SlotIntent   = "intent"
+	SlotProduct  = "product"
+	SlotProducts = "products"
+)
+
+// ClarifyConfidence is the classification confidence below which the user is
+// asked what they want instead of guessing
+const ClarifyConfidence = 0.4
+
+// MaxClarifications bounds how many questions are asked about one request before
+// the orchestrator proceeds with what it has
+const MaxClarifications = 2
+
+// Clarification asks the user for information needed to complete their request
+type Clarification struct {
+	Question     string   `json:"question"`
+	MissingSlots []string `json:"missing_slots"`
+	Options      []string `json:"options,omitempty"`
+}
+
+// intentOptions are the quick replies offered when the intent is unclear
+var intentOptions = []struct {
+	Label  string
+	Intent IntentType
+}{
+	{"Search for products", IntentSearch},
+	{"Compare products", IntentCompare},
+	{"Create marketing copy", IntentMarketing},
+	{"Both", IntentCombined},
+}
+
+// DefaultProductSuggestions are the quick replies offered when no product was named
+var DefaultProductSuggestions = []string{"Headphones", "Laptops", "Smartphones", "Sneakers"}
+
+// missingSlots lists the slots that must be filled before the intent can be
+// handled. Ambiguous messages are missing the intent itself; messages confidently
+// classified as unknown go to the model as they are.
+func (c *Client) missingSlots(intent MessageIntent) []string {
+	var missing []string
+	if intent.Confidence < ClarifyConfidence {
+		missing = append(missing, SlotIntent)
+	}
+
+	switch intent.Type {
+	case IntentSearch, IntentCombined:
+		if intent.Product == "" {
+			missing = append(missing, SlotProduct)
+		}
+	case IntentMarketing:
+		if intent.Product == "" && intent.Description == "" {
+			missing = append(missing, SlotProduct)
+		}
+	case IntentCompare:
+		if len(intent.Products) < 2 {
+			missing = append(missing, SlotProducts)
+		}
+	}
+
+	return missing
+}
+
+// buildClarification phrases a question for the first missing slot
+func (c *Client) buildClarification(intent MessageIntent, missing []string) *Clarification {
+	clarification := &Clarification{MissingSlots: missing}
+
+	switch missing[0] {
+	case SlotIntent:
+		clarification.Question = "Would you like me to search for products, compare products, or create marketing copy?"
+		for _, option := range intentOptions {
+			clarification.Options = append(clarification.Options, option.Label)
+		}
+	case SlotProduct:
+		switch intent.Type {
+		case IntentMarketing:
+			clarification.Question = "Which product should I create marketing copy for?"
+		case IntentCombined:
+			clarification.Question = "Which product should I search for and create marketing copy for?"
+		default:
+			clarification.Question = "What product are you looking for?"
+		}
+		clarification.Options = DefaultProductSuggestions
+	case SlotProducts:
+		if len(intent.Products) == 1 {
+			clarification.Question = fmt.Sprintf("What would you like to compare %s with?", intent.Products[0])
+		} else {
+			clarification.Question = "Which products would you like me to compare?"
+		}
+	}
+
+	return clarification
+}
+
+// fillSlots applies the user's answer to the slots a pending intent was missing
+func (c *Client) fillSlots(pending PendingIntent, answer string) MessageIntent {
+	intent := pending.Intent
+	answered := c.classifyIntent(answer)
+	normalized := strings.ToLower(strings.TrimSpace(answer))
+
+	// An intent quick reply answers only the intent slot
+	for _, option := range intentOptions {
+		if normalized == strings.ToLower(option.Label) {
+			intent.Type = option.Intent
+			intent.Confidence = 1
+			return intent
+		}
+	}
+
+	for _, slot := range pending.MissingSlots {
+		switch slot {
+		case SlotIntent:
+			if answered.Type != IntentUnknown {
+				intent.Type = answered.Type
+				intent.Confidence = 1
+			}
+		case SlotProduct:
+			product := answered.Product
+			if product == "" {
+				product = strings.Trim(strings.TrimSpace(answer), ".!?")
+			}
+			intent.Product = product
+			if intent.Description == "" && answered.Description != "" {
+				intent.Description = answered.Description
+			}
+		case SlotProducts:
+			products := c.extractProducts(answer)
+			if len(products) == 0 {
+				products = []string{strings.Trim(strings.TrimSpace(answer), ".!?")}
+			}
+			intent.Products = mergeProducts(intent.Products, products)
+		}
+	}
+
+	// Constraints mentioned in the answer apply when the original request had none
+	c.applyConstraints(&intent, queryparser.Parse(answer))
+
+	return intent
+}
+
+// resumeIntent returns the intent for a turn, filling slots of a pending intent
+// when the conversation is waiting for an answer. The second value counts the
+// clarifications already asked.
+func (c *Client) resumeIntent(ctx context.Context, conversationID, message string) (MessageIntent, int) {
+	if conversationID != "" && c.Conversations != nil {
+		pending, err := c.Conversations.Load(ctx, conversationID)
+		if err == nil {
+			if err := c.Conversations.Delete(ctx, conversationID); err != nil {
+				logging.FromContext(ctx).Warn("Failed to delete conversation", logging.KeyError, err)
+			}
+			return c.fillSlots(*pending, message), pending.Attempts
+		}
+		if !errors.Is(err, ErrConversationNotFound) {
+			logging.FromContext(ctx).Warn("Failed to load conversation", logging.KeyError, err)
+		}
+	}
+
+	return c.detectIntent(ctx, message), 0
+}
+
+// clarify stores the pending intent and returns a clarification response
+func (c *Client) clarify(ctx context.Context, conversationID string, intent MessageIntent, missing []string, attempts int) *OrchestratorResponse {
+	if conversationID == "" {
+		id, err := newConversationID()
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to create conversation", logging.KeyError, err)
+		}
+		conversationID = id
+	}
+
+	if c.Conversations != nil && conversationID != "" {
+		pending := &PendingIntent{Intent: intent, MissingSlots: missing, Attempts: attempts + 1}
+		if err := c.Conversations.Save(ctx, conversationID, pending); err != nil {
+			logging.FromContext(ctx).Warn("Failed to save conversation", logging.KeyError, err)
+		}
+	}
+
+	clarification := c.buildClarification(intent, missing)
+
+	return &OrchestratorResponse{
+		Message:        clarification.Question,
+		ConversationID: conversationID,
+		Clarification:  clarification,
+	}
+}
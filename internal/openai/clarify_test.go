@@ -0,0 +1,194 @@
+package openai
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/marketplace"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubMarketplace records searches and returns fixed products
+type stubMarketplace struct {
+	mu        sync.Mutex
+	products  []marketplace.Product
+	byQuery   map[string][]marketplace.Product
+	err       error
+	queries   []string
+	maxPrices []float64
+}
+
+func (s *stubMarketplace) Search(ctx context.Context, query string, minPrice, maxPrice float64) ([]marketplace.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queries = append(s.queries, query)
+	s.maxPrices = append(s.maxPrices, maxPrice)
+	if s.err != nil {
+		return nil, s.err
+	}
+	if products, ok := s.byQuery[query]; ok {
+		return products, nil
+	}
+	if s.products != nil {
+		return s.products, nil
+	}
+	return []marketplace.Product{{Title: "Wireless Headphones", Price: 45, Link: "https://example.com/headphones"}}, nil
+}
+
+func newClarifyingClient(steps ...llm.FakeStep) (*Client, *stubMarketplace) {
+	client, _ := newFakeClassifierClient(steps...)
+	client.Conversations = NewMemoryConversationStore()
+	store := &stubMarketplace{}
+	client.AmazonClient = store
+	client.EbayClient = &stubMarketplace{}
+	return client, store
+}
+
+func TestProcessConversation_AsksForMissingProduct(t *testing.T) {
+	client, store := newClarifyingClient(llm.FakeJSON(MessageIntent{
+		Type:       IntentSearch,
+		MaxPrice:   50,
+		Confidence: 0.9,
+	}))
+	ctx := context.Background()
+
+	response, err := client.ProcessMessage(ctx, "help me shop, nothing over 50 bucks")
+	require.NoError(t, err)
+	require.NotNil(t, response.Clarification)
+	assert.Equal(t, []string{SlotProduct}, response.Clarification.MissingSlots)
+	assert.Equal(t, "What product are you looking for?", response.Message)
+	assert.NotEmpty(t, response.Clarification.Options)
+	require.NotEmpty(t, response.ConversationID)
+	assert.Empty(t, store.queries)
+
+	response, err = client.ProcessConversation(ctx, response.ConversationID, "wireless headphones")
+	require.NoError(t, err)
+	assert.Nil(t, response.Clarification)
+	require.NotNil(t, response.SearchResults)
+	assert.Equal(t, []string{"wireless headphones"}, store.queries)
+	assert.Equal(t, []float64{50}, store.maxPrices)
+
+	// The clarification was consumed, so the same ID starts a new request
+	_, err = client.Conversations.Load(ctx, response.ConversationID)
+	assert.ErrorIs(t, err, ErrConversationNotFound)
+}
+
+func TestProcessConversation_AsksForIntentWhenUnsure(t *testing.T) {
+	client, store := newClarifyingClient(llm.FakeJSON(MessageIntent{
+		Type:       IntentSearch,
+		Product:    "sneakers",
+		Confidence: 0.2,
+	}))
+	ctx := context.Background()
+
+	response, err := client.ProcessMessage(ctx, "sneakers?")
+	require.NoError(t, err)
+	require.NotNil(t, response.Clarification)
+	assert.Equal(t, []string{SlotIntent}, response.Clarification.MissingSlots)
+	assert.Equal(t, []string{"Search for products", "Compare products", "Create marketing copy", "Both"}, response.Clarification.Options)
+
+	response, err = client.ProcessConversation(ctx, response.ConversationID, "Search for products")
+	require.NoError(t, err)
+	assert.Nil(t, response.Clarification)
+	assert.NotNil(t, response.SearchResults)
+	assert.Equal(t, []string{"sneakers"}, store.queries)
+}
+
+func TestProcessConversation_AsksForIntentWhenUnknown(t *testing.T) {
+	client, store := newClarifyingClient(llm.FakeJSON(MessageIntent{Type: IntentUnknown}))
+	ctx := context.Background()
+
+	response, err := client.ProcessMessage(ctx, "I need some help with my shop")
+	require.NoError(t, err)
+	require.NotNil(t, response.Clarification)
+	assert.Equal(t, []string{SlotIntent}, response.Clarification.MissingSlots)
+	assert.Equal(t, "Would you like me to search for products, compare products, or create marketing copy?", response.Message)
+	assert.Contains(t, response.Clarification.Options, "Compare products")
+	id := response.ConversationID
+
+	// Choosing compare leaves the products to ask for
+	response, err = client.ProcessConversation(ctx, id, "Compare products")
+	require.NoError(t, err)
+	require.NotNil(t, response.Clarification)
+	assert.Equal(t, []string{SlotProducts}, response.Clarification.MissingSlots)
+	assert.Equal(t, id, response.ConversationID)
+
+	response, err = client.ProcessConversation(ctx, id, "AirPods vs Galaxy Buds")
+	require.NoError(t, err)
+	assert.Nil(t, response.Clarification)
+	require.NotNil(t, response.Comparison)
+	assert.ElementsMatch(t, []string{"AirPods", "Galaxy Buds"}, store.queries)
+}
+
+func TestProcessConversation_StopsAskingAfterMaxClarifications(t *testing.T) {
+	client, _ := newClarifyingClient(llm.FakeJSON(MessageIntent{Type: IntentSearch, Confidence: 0.9}))
+	ctx := context.Background()
+
+	response, err := client.ProcessMessage(ctx, "help me shop")
+	require.NoError(t, err)
+	id := response.ConversationID
+
+	// Answering with an intent quick reply leaves the product slot open
+	response, err = client.ProcessConversation(ctx, id, "Search for products")
+	require.NoError(t, err)
+	require.NotNil(t, response.Clarification)
+	assert.Equal(t, id, response.ConversationID)
+
+	response, err = client.ProcessConversation(ctx, id, "Both")
+	require.NoError(t, err)
+	assert.Nil(t, response.Clarification)
+	assert.NotEmpty(t, response.Message)
+}
+
+func TestConversationStores_ScopeByTenant(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+
+	stores := map[string]ConversationStore{
+		"redis":  NewRedisConversationStore(redisClient),
+		"memory": NewMemoryConversationStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			acme := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"})
+			globex := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "globex"})
+			pending := &PendingIntent{Intent: MessageIntent{Type: IntentSearch}, MissingSlots: []string{SlotProduct}, Attempts: 1}
+
+			require.NoError(t, store.Save(acme, "conv_1", pending))
+
+			loaded, err := store.Load(acme, "conv_1")
+			require.NoError(t, err)
+			assert.Equal(t, pending, loaded)
+
+			_, err = store.Load(globex, "conv_1")
+			assert.ErrorIs(t, err, ErrConversationNotFound)
+
+			require.NoError(t, store.Delete(acme, "conv_1"))
+			_, err = store.Load(acme, "conv_1")
+			assert.ErrorIs(t, err, ErrConversationNotFound)
+		})
+	}
+}
+
+func TestMemoryConversationStore_Expires(t *testing.T) {
+	store := NewMemoryConversationStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, store.Save(ctx, "conv_1", &PendingIntent{}))
+	now = now.Add(ConversationTTL + time.Second)
+
+	_, err := store.Load(ctx, "conv_1")
+	assert.ErrorIs(t, err, ErrConversationNotFound)
+}
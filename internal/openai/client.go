@@ -3,213 +3,520 @@ package openai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/jesee-kuya/blue/internal/breaker"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/campaign"
+	"github.com/jesee-kuya/blue/internal/config"
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/logging"
 	"github.com/jesee-kuya/blue/internal/marketplace"
 	"github.com/jesee-kuya/blue/internal/marketplace/amazon"
 	"github.com/jesee-kuya/blue/internal/marketplace/ebay"
+	"github.com/jesee-kuya/blue/internal/metrics"
 	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/jesee-kuya/blue/internal/queryparser"
+	"github.com/jesee-kuya/blue/internal/segments"
+	"github.com/jesee-kuya/blue/internal/tracing"
+	"github.com/jesee-kuya/blue/internal/usage"
 	"github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Client represents an OpenAI GPT-4o client with function calling capabilities
+// Client orchestrates an LLM with function calling capabilities over the marketplace and Qloo tools
 type Client struct {
-	OpenaiClient *openai.Client
-	Model        string
-	AmazonClient marketplace.Client
-	EbayClient   marketplace.Client
-	QlooClient   *qloo.Client
-	Timeout      time.Duration
+	Provider      llm.Provider
+	Model         string
+	AmazonClient  marketplace.Client
+	EbayClient    marketplace.Client
+	QlooClient    *qloo.Client
+	Classifier    IntentClassifier    // consulted when pattern matching is not confident, nil disables
+	Personas      PersonaBuilder      // turns taste segments into personas for marketing, nil disables
+	Segmenter     *segments.Segmenter // segments audiences when Qloo cannot, nil uses fixed segments
+	Conversations ConversationStore   // pending clarifications, nil disables multi-turn slot filling
+	Campaigns     *campaign.Service   // saves generated marketing copy, nil disables campaign history
+	Tools         *ToolRegistry       // tools the model may call, nil disables function calling
+	Breakers      *breaker.Group      // circuit breakers per upstream, nil disables them
+	Usage         *usage.Tracker
+	Timeout       time.Duration
 }
 
-// NewClient creates a new OpenAI client using the OPENAI_API_KEY environment variable
-func NewClient() *Client {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	openaiClient := openai.NewClient(apiKey)
+// NewClient creates a new client configured by CONFIG_FILE and the environment,
+// with its own Redis connection built from the redis settings. It fails when the
+// configuration is invalid; use NewClientFromConfig to share a Redis client.
+func NewClient() (*Client, error) {
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return nil, err
+	}
+
+	redisClient, err := cache.NewRedisClientWithOptions(cfg.RedisOptions())
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewClientFromConfig(cfg, redisClient)
+	if err != nil {
+		redisClient.Close()
+		return nil, err
+	}
 
-	return &Client{
-		OpenaiClient: openaiClient,
-		Model:        "gpt-4o",
-		AmazonClient: amazon.NewClient("", "", "us-east-1"), // Mock credentials for now
-		EbayClient:   ebay.NewClient(""),                    // Mock credentials for now
-		QlooClient:   qloo.NewClient(),
-		Timeout:      30 * time.Second,
+	breakers, err := breaker.NewGroupWithOptions(cfg.BreakerOptions(), redisClient, Upstreams...)
+	if err != nil {
+		redisClient.Close()
+		return nil, err
 	}
+	client.Breakers = breakers
+
+	return client, nil
+}
+
+// NewClientFromConfig creates a client from validated configuration whose
+// caches, conversations and usage share redisClient. Circuit breakers are kept
+// in memory; callers sharing a group assign Breakers.
+func NewClientFromConfig(cfg *config.Config, redisClient *cache.RedisClient) (*Client, error) {
+	provider, err := llm.NewProvider(cfg.ProviderConfig())
+	if err != nil {
+		return nil, err
+	}
+	tracker, err := usage.NewTrackerWithConfig(redisClient, cfg.LLM.PriceTableFile, cfg.LLM.Budget)
+	if err != nil {
+		return nil, err
+	}
+
+	client := newClient(cfg, provider, cfg.LLM.Model, redisClient)
+	client.Usage = tracker
+	client.Timeout = time.Duration(cfg.LLM.Timeout)
+
+	var taxonomy *segments.Taxonomy
+	if cfg.Segments.TaxonomyFile != "" {
+		if taxonomy, err = segments.LoadTaxonomy(cfg.Segments.TaxonomyFile); err != nil {
+			return nil, err
+		}
+	}
+	var inferrer segments.Inferrer
+	if cfg.Segments.LLMInference {
+		inferrer = segments.NewLLMInferrer(meteredProvider{client}, cfg.LLM.Model)
+	}
+	client.Segmenter = segments.NewSegmenter(taxonomy, inferrer)
+	if cfg.Segments.Personas {
+		client.Personas = NewLLMPersonaBuilder(meteredProvider{client}, cfg.LLM.Model, redisClient)
+		client.Tools = client.newToolRegistry()
+	}
+
+	store, err := campaign.NewStore(context.Background(), cfg.CampaignOptions(), redisClient)
+	if err != nil {
+		return nil, err
+	}
+	client.Campaigns = campaign.NewService(store)
+
+	if cfg.ToolPolicyFile != "" {
+		if err := client.Tools.LoadPolicies(cfg.ToolPolicyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return client, nil
 }
 
 // NewClientWithKey creates a new OpenAI client with a specific API key (for testing)
 func NewClientWithKey(apiKey string) *Client {
-	openaiClient := openai.NewClient(apiKey)
+	return NewClientWithProvider(llm.NewOpenAIProvider(apiKey, llm.DefaultModel), llm.DefaultModel)
+}
 
-	return &Client{
-		OpenaiClient: openaiClient,
-		Model:        "gpt-4o",
-		AmazonClient: amazon.NewClient("", "", "us-east-1"), // Mock credentials for now
-		EbayClient:   ebay.NewClient(""),                    // Mock credentials for now
-		QlooClient:   qloo.NewClient(),
-		Timeout:      30 * time.Second,
-	}
+// NewClientWithProvider creates a new client backed by the given LLM provider,
+// with the default marketplace and Qloo settings
+func NewClientWithProvider(provider llm.Provider, model string) *Client {
+	return newClient(config.Default(), provider, model, cache.Shared())
 }
 
-// SendMessage sends a user message to GPT-4o and returns both text response and any function calls
-func (c *Client) SendMessage(message string) (response string, functionCalls []FunctionCall, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
-	defer cancel()
+// newClient creates a client with the marketplace and Qloo clients configured
+// by cfg, whose stores share redisClient
+func newClient(cfg *config.Config, provider llm.Provider, model string, redisClient *cache.RedisClient) *Client {
+	client := &Client{
+		Provider:      provider,
+		Model:         model,
+		AmazonClient:  amazon.NewClientWithOptions(cfg.AmazonOptions(), redisClient),
+		EbayClient:    ebay.NewClientWithOptions(cfg.EbayOptions(), redisClient),
+		QlooClient:    qloo.NewClientWithOptions(cfg.QlooOptions(), redisClient),
+		Conversations: NewRedisConversationStore(redisClient),
+		Usage:         usage.NewTracker(redisClient, usage.DefaultPrices),
+		Breakers:      breaker.NewGroup(breaker.DefaultSettings, nil),
+		Timeout:       30 * time.Second,
+	}
+	client.Tools = client.newToolRegistry()
+	client.Classifier = NewLLMClassifier(meteredProvider{client}, model)
+	client.Segmenter = segments.NewSegmenter(nil, nil)
+
+	return client
+}
 
-	functions := GetFunctionDefinitions()
-	tools := make([]openai.Tool, len(functions))
+// SendMessage sends a user message to the model and returns both text response and any function calls.
+// Token usage is added to the meter in ctx and to the tenant's aggregates.
+func (c *Client) SendMessage(ctx context.Context, message string) (response string, functionCalls []FunctionCall, err error) {
+	var functions []openai.FunctionDefinition
+	if c.Tools != nil {
+		functions = c.Tools.Definitions(ctx)
+	}
+	tools := make([]llm.Tool, len(functions))
 	for i, fn := range functions {
-		tools[i] = openai.Tool{
-			Type:     openai.ToolTypeFunction,
-			Function: &fn,
+		tools[i] = llm.Tool{
+			Name:        fn.Name,
+			Description: fn.Description,
+			Parameters:  fn.Parameters,
 		}
 	}
 
-	req := openai.ChatCompletionRequest{
-		Model: c.Model,
-		Messages: []openai.ChatCompletionMessage{
+	resp, err := c.chat(ctx, llm.ChatRequest{
+		Messages: []llm.Message{
 			{
-				Role:    openai.ChatMessageRoleUser,
+				Role:    llm.RoleUser,
 				Content: message,
 			},
 		},
 		Tools: tools,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	response = resp.Message.Content
+
+	// Parse function calls if any
+	for _, toolCall := range resp.Message.ToolCalls {
+		var args map[string]any
+		if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+			return response, functionCalls, fmt.Errorf("failed to parse function arguments: %w", err)
+		}
+
+		functionCalls = append(functionCalls, FunctionCall{
+			Name:      toolCall.Name,
+			Arguments: args,
+		})
+	}
+
+	return response, functionCalls, nil
+}
+
+// chat sends a request to the provider within the client timeout, enforcing the
+// tenant's budget and recording token usage
+func (c *Client) chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	if err := c.checkBudget(ctx); err != nil {
+		return nil, err
 	}
 
-	resp, err := c.OpenaiClient.CreateChatCompletion(ctx, req)
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	if req.Model == "" {
+		req.Model = c.Model
+	}
+
+	ctx, span := c.startLLMSpan(ctx, "llm chat", req)
+	var resp *llm.ChatResponse
+	err := c.guard(ctx, UpstreamLLM, func() error {
+		var err error
+		resp, err = c.Provider.Chat(ctx, req)
+		return err
+	})
+	endLLMSpan(span, resp, err)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to create chat completion: %w", err)
+		return nil, err
 	}
 
-	if len(resp.Choices) == 0 {
-		return "", nil, fmt.Errorf("no response choices returned")
+	c.recordUsage(ctx, resp.Model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+
+	return resp, nil
+}
+
+// startLLMSpan starts a span for a model call
+func (c *Client) startLLMSpan(ctx context.Context, name string, req llm.ChatRequest) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		attribute.String("llm.provider", c.Provider.Name()),
+		attribute.String("llm.model", req.Model),
+		attribute.Int("llm.tools", len(req.Tools)),
+	)
+}
+
+// endLLMSpan records the model's token usage on the span and ends it
+func endLLMSpan(span trace.Span, resp *llm.ChatResponse, err error) {
+	if resp != nil {
+		span.SetAttributes(
+			attribute.Int("llm.prompt_tokens", resp.Usage.PromptTokens),
+			attribute.Int("llm.completion_tokens", resp.Usage.CompletionTokens),
+		)
 	}
+	tracing.End(span, err)
+}
 
-	choice := resp.Choices[0]
-	response = choice.Message.Content
+// meteredProvider routes calls made by helpers such as the intent classifier through
+// the client so they count against the tenant's budget
+type meteredProvider struct {
+	client *Client
+}
 
-	// Parse function calls if any
-	if choice.Message.ToolCalls != nil {
-		for _, toolCall := range choice.Message.ToolCalls {
-			if toolCall.Type == openai.ToolTypeFunction {
-				var args map[string]any
-				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-					return response, functionCalls, fmt.Errorf("failed to parse function arguments: %w", err)
-				}
-
-				functionCalls = append(functionCalls, FunctionCall{
-					Name:      toolCall.Function.Name,
-					Arguments: args,
-				})
-			}
-		}
+func (p meteredProvider) Name() string {
+	return p.client.Provider.Name()
+}
+
+func (p meteredProvider) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	return p.client.chat(ctx, req)
+}
+
+func (p meteredProvider) ChatStream(ctx context.Context, req llm.ChatRequest, onDelta func(llm.StreamDelta) error) (*llm.ChatResponse, error) {
+	c := p.client
+	if err := c.checkBudget(ctx); err != nil {
+		return nil, err
 	}
 
-	return response, functionCalls, nil
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	if req.Model == "" {
+		req.Model = c.Model
+	}
+
+	ctx, span := c.startLLMSpan(ctx, "llm chat stream", req)
+	var resp *llm.ChatResponse
+	err := c.guard(ctx, UpstreamLLM, func() error {
+		var err error
+		resp, err = c.Provider.ChatStream(ctx, req, onDelta)
+		return err
+	})
+	endLLMSpan(span, resp, err)
+	if err != nil {
+		return nil, err
+	}
+
+	c.recordUsage(ctx, resp.Model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+
+	return resp, nil
 }
 
-// ExecuteFunctionCall executes the requested function and returns results
-func (c *Client) ExecuteFunctionCall(functionCall FunctionCall) (result any, err error) {
-	switch functionCall.Name {
-	case "search_marketplace":
-		return c.executeSearchMarketplace(functionCall.Arguments)
-	case "get_taste_profile":
-		return c.executeGetTasteProfile(functionCall.Arguments)
-	case "generate_ad_copy":
-		return c.executeGenerateAdCopy(functionCall.Arguments)
-	default:
-		return nil, fmt.Errorf("unknown function: %s", functionCall.Name)
+// checkBudget rejects model calls once the tenant in ctx has spent its budget.
+// Failures to read usage are logged and the call is allowed.
+func (c *Client) checkBudget(ctx context.Context) error {
+	if c.Usage == nil {
+		return nil
+	}
+
+	err := c.Usage.CheckBudget(ctx)
+	var budgetErr *usage.BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		return budgetErr
 	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("Budget check failed, allowing request", logging.KeyError, err)
+	}
+	return nil
 }
 
-// executeSearchMarketplace searches products across marketplaces
-func (c *Client) executeSearchMarketplace(args map[string]any) (any, error) {
-	var searchArgs SearchMarketplaceArgs
+// recordUsage prices a model call and records it on the request meter and tenant aggregates
+func (c *Client) recordUsage(ctx context.Context, model string, promptTokens, completionTokens int) {
+	if model == "" {
+		model = c.Model
+	}
 
-	query, ok := args["query"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid query parameter")
+	prices := usage.DefaultPrices
+	if c.Usage != nil {
+		prices = c.Usage.Prices
 	}
-	searchArgs.Query = query
+	callUsage := prices.Usage(model, promptTokens, completionTokens)
+	metrics.ObserveLLMUsage(model, promptTokens, completionTokens, callUsage.CostUSD)
+
+	usage.MeterFromContext(ctx).Add(callUsage)
 
-	if minPrice, ok := args["min_price"].(float64); ok {
-		searchArgs.MinPrice = minPrice
+	if c.Usage != nil {
+		if err := c.Usage.Record(ctx, callUsage); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record usage", "model", model, logging.KeyError, err)
+		}
 	}
-	if maxPrice, ok := args["max_price"].(float64); ok {
-		searchArgs.MaxPrice = maxPrice
+}
+
+// ExecuteFunctionCall executes the requested function and returns results
+func (c *Client) ExecuteFunctionCall(functionCall FunctionCall) (result any, err error) {
+	return c.ExecuteFunctionCallContext(context.Background(), functionCall)
+}
+
+// ExecuteFunctionCallContext executes the requested function on behalf of the tenant in ctx
+func (c *Client) ExecuteFunctionCallContext(ctx context.Context, functionCall FunctionCall) (result any, err error) {
+	if c.Tools == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTool, functionCall.Name)
 	}
+	return c.Tools.Execute(ctx, functionCall)
+}
 
+// executeSearchMarketplace searches products across marketplaces
+func (c *Client) executeSearchMarketplace(ctx context.Context, searchArgs SearchMarketplaceArgs) (SearchResult, error) {
 	// Search across multiple marketplaces
 	var allProducts []marketplace.Product
-
-	// Search Amazon
-	amazonProducts, err := c.AmazonClient.Search(searchArgs.Query, searchArgs.MinPrice, searchArgs.MaxPrice)
-	if err == nil {
-		allProducts = append(allProducts, amazonProducts...)
+	var sources []SourceStatus
+	for _, results := range c.searchMarketplaces(ctx, searchArgs) {
+		sources = append(sources, results.status())
+		if results.Err == nil {
+			allProducts = append(allProducts, results.Products...)
+		}
 	}
 
-	// Search eBay
-	ebayProducts, err := c.EbayClient.Search(searchArgs.Query, searchArgs.MinPrice, searchArgs.MaxPrice)
-	if err == nil {
-		allProducts = append(allProducts, ebayProducts...)
+	// Marketplaces only report prices, so other orderings keep relevance order
+	switch searchArgs.Sort {
+	case queryparser.SortPriceAsc:
+		sort.SliceStable(allProducts, func(i, j int) bool { return allProducts[i].Price < allProducts[j].Price })
+	case queryparser.SortPriceDesc:
+		sort.SliceStable(allProducts, func(i, j int) bool { return allProducts[i].Price > allProducts[j].Price })
 	}
 
-	return map[string]any{
-		"products": allProducts,
-		"count":    len(allProducts),
+	return SearchResult{
+		Products: allProducts,
+		Count:    len(allProducts),
+		Sources:  sources,
 	}, nil
 }
 
-// executeGetTasteProfile analyzes product description using Qloo API
-func (c *Client) executeGetTasteProfile(args map[string]any) (any, error) {
-	description, ok := args["description"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid description parameter")
+// marketplaceQuery adds the brand, color, size and condition to the search text,
+// since marketplaces only accept a keyword query and a price range
+func marketplaceQuery(args SearchMarketplaceArgs) string {
+	terms := []string{args.Query}
+	lower := strings.ToLower(args.Query)
+
+	var extras []string
+	if args.Condition == queryparser.ConditionUsed || args.Condition == queryparser.ConditionRefurbished {
+		extras = append(extras, string(args.Condition))
 	}
+	extras = append(extras, args.Brand, args.Color, args.Size)
 
-	segments, err := c.QlooClient.GetTasteProfile(description)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get taste profile: %w", err)
+	for _, extra := range extras {
+		if extra != "" && !strings.Contains(lower, strings.ToLower(extra)) {
+			terms = append(terms, extra)
+		}
 	}
 
-	return map[string]any{
-		"segments": segments,
-		"count":    len(segments),
-	}, nil
+	return strings.Join(terms, " ")
 }
 
-// executeGenerateAdCopy generates marketing copy for target segments
-func (c *Client) executeGenerateAdCopy(args map[string]any) (any, error) {
-	productTitle, ok := args["product_title"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid product_title parameter")
+// marketplaceResults holds what one marketplace returned for a search
+type marketplaceResults struct {
+	Marketplace string
+	Products    []marketplace.Product
+	Err         error
+	Breaker     breaker.State
+	Note        string // why the search differed from the request, such as an unapplied price filter
+}
+
+// status reports the marketplace's part in a search response
+func (r marketplaceResults) status() SourceStatus {
+	status := SourceStatus{Name: r.Marketplace, Status: SourceOK, Count: len(r.Products), Breaker: r.Breaker, Note: r.Note}
+	switch {
+	case errors.Is(r.Err, breaker.ErrOpen):
+		status.Status = SourceUnavailable
+		status.Error = r.Err.Error()
+	case r.Err != nil:
+		status.Status = SourceError
+		status.Error = r.Err.Error()
 	}
+	return status
+}
+
+// priceFilter returns the price range to send to a marketplace that prices in
+// currency. There are no exchange rates to convert with, so a range in another
+// currency is dropped rather than applied as if it were the marketplace's own.
+func priceFilter(args SearchMarketplaceArgs, currency string) (float64, float64, string) {
+	if args.MinPrice == 0 && args.MaxPrice == 0 {
+		return 0, 0, ""
+	}
+	if args.Currency == "" || strings.EqualFold(args.Currency, currency) {
+		return args.MinPrice, args.MaxPrice, ""
+	}
+	return 0, 0, fmt.Sprintf("price filter in %s not applied because prices are in %s", strings.ToUpper(args.Currency), currency)
+}
 
-	segmentsInterface, ok := args["segments"].([]any)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid segments parameter")
+// searchMarketplaces runs a search against each configured marketplace in turn
+func (c *Client) searchMarketplaces(ctx context.Context, args SearchMarketplaceArgs) []marketplaceResults {
+	searchQuery := marketplaceQuery(args)
+
+	sources := []struct {
+		name     string
+		upstream string
+		currency string
+		client   marketplace.Client
+	}{
+		{"Amazon", UpstreamAmazon, "USD", c.AmazonClient},
+		{"eBay", UpstreamEbay, "USD", c.EbayClient},
 	}
 
-	segments := make([]string, len(segmentsInterface))
-	for i, seg := range segmentsInterface {
-		if segStr, ok := seg.(string); ok {
-			segments[i] = segStr
+	var results []marketplaceResults
+	for _, source := range sources {
+		if source.client == nil {
+			continue
+		}
+		minPrice, maxPrice, note := priceFilter(args, source.currency)
+		if note != "" {
+			logging.FromContext(ctx).Info("Price filter not applied", logging.KeyProvider, source.upstream, "currency", args.Currency)
+		}
+
+		var products []marketplace.Product
+		spanCtx, span := tracing.Start(ctx, "marketplace search "+source.upstream,
+			attribute.String("marketplace.provider", source.upstream), attribute.String("marketplace.query", searchQuery))
+		start := time.Now()
+		err := c.guard(ctx, source.upstream, func() error {
+			var err error
+			products, err = source.client.Search(spanCtx, searchQuery, minPrice, maxPrice)
+			return err
+		})
+		latency := time.Since(start)
+		metrics.ObserveMarketplaceSearch(source.upstream, latency, len(products), err)
+		logger := logging.FromContext(ctx).With(logging.KeyProvider, source.upstream, logging.Latency(latency))
+		if err != nil {
+			logger.Warn("Marketplace search failed", logging.KeyError, err)
 		} else {
-			return nil, fmt.Errorf("invalid segment type at index %d", i)
+			logger.Debug("Marketplace search finished", "results", len(products))
 		}
+		span.SetAttributes(attribute.Int("marketplace.results", len(products)))
+		tracing.End(span, err)
+		results = append(results, marketplaceResults{
+			Marketplace: source.name,
+			Products:    products,
+			Err:         err,
+			Breaker:     c.breakerState(ctx, source.upstream),
+			Note:        note,
+		})
 	}
 
-	// Generate ad copy using template-based approach
-	adCopy := c.generateAdCopyTemplate(productTitle, segments)
+	return results
+}
+
+// executeGetTasteProfile analyzes product description using Qloo API
+func (c *Client) executeGetTasteProfile(ctx context.Context, args GetTasteProfileArgs) (TasteProfileResult, error) {
+	var segments []qloo.Segment
+	err := c.guard(ctx, UpstreamQloo, func() error {
+		var err error
+		segments, err = c.QlooClient.GetTasteProfileWithContext(ctx, args.Description)
+		return err
+	})
+	if err != nil {
+		return TasteProfileResult{}, fmt.Errorf("failed to get taste profile: %w", err)
+	}
 
-	return adCopy, nil
+	return TasteProfileResult{
+		Segments: segments,
+		Count:    len(segments),
+	}, nil
+}
+
+// executeGenerateAdCopy generates marketing copy for target segments
+func (c *Client) executeGenerateAdCopy(ctx context.Context, args GenerateAdCopyArgs) (AdCopyResult, error) {
+	// Generate ad copy using template-based approach
+	return c.generateAdCopyTemplate(args.ProductTitle, args.Segments, args.Personas...), nil
 }
 
 // generateAdCopyTemplate creates ad copy using templates (simple implementation for now)
-func (c *Client) generateAdCopyTemplate(productTitle string, segments []string) AdCopyResult {
+func (c *Client) generateAdCopyTemplate(productTitle string, segments []string, personas ...Persona) AdCopyResult {
 	headlines := []string{
 		fmt.Sprintf("Discover %s - Perfect for %s", productTitle, strings.Join(segments, " & ")),
 		fmt.Sprintf("%s: Designed for %s", productTitle, segments[0]),
@@ -221,6 +528,16 @@ func (c *Client) generateAdCopyTemplate(productTitle string, segments []string)
 		fmt.Sprintf("Join thousands of satisfied customers who chose %s. Perfect for %s looking for quality and value.", productTitle, segments[0]),
 	}
 
+	// Personas add copy written to their messaging angles and motivations
+	for _, persona := range personas {
+		if len(persona.MessagingAngles) > 0 {
+			headlines = append(headlines, fmt.Sprintf("%s: %s", productTitle, persona.MessagingAngles[0]))
+		}
+		if len(persona.Motivations) > 0 {
+			descriptions = append(descriptions, fmt.Sprintf("Made for %s: %s because you want %s.", persona.Name, productTitle, strings.Join(persona.Motivations, ", ")))
+		}
+	}
+
 	callToAction := "Shop Now and Transform Your Experience!"
 
 	return AdCopyResult{
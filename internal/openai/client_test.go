@@ -1,26 +1,82 @@
 package openai
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"path/filepath"
 	"testing"
-
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/config"
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/marketplace"
+	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/jesee-kuya/blue/internal/queryparser"
+	"github.com/jesee-kuya/blue/internal/usage"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewClient_WithEnvironmentVariable(t *testing.T) {
-	os.Setenv("OPENAI_API_KEY", "test-api-key")
-	defer os.Unsetenv("OPENAI_API_KEY")
+	t.Setenv("OPENAI_API_KEY", "test-api-key")
+	t.Setenv("QLOO_API_KEY", "qloo-key")
 
-	client := NewClient()
+	client, err := NewClient()
 
-	assert.NotNil(t, client.OpenaiClient)
+	require.NoError(t, err)
+	assert.NotNil(t, client.Provider)
 	assert.Equal(t, "gpt-4o", client.Model)
 	assert.NotNil(t, client.AmazonClient)
 	assert.NotNil(t, client.EbayClient)
 	assert.NotNil(t, client.QlooClient)
+	assert.NoError(t, client.QlooClient.CheckConfig())
+	assert.NotNil(t, client.Breakers)
+}
+
+func TestNewClient_InvalidConfiguration(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "bogus")
+	_, err := NewClient()
+	assert.ErrorContains(t, err, `llm.provider: unknown provider "bogus"`)
+
+	t.Setenv("LLM_PROVIDER", "")
+	t.Setenv("REDIS_MODE", "sentinel")
+	_, err = NewClient()
+	assert.ErrorContains(t, err, "master name")
+}
+
+func TestNewClientFromConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.LLM.Provider = llm.ProviderFake
+	cfg.LLM.Model = "gpt-4o-mini"
+	cfg.LLM.Timeout = config.Duration(5 * time.Second)
+	cfg.LLM.Budget = usage.Budget{DailyUSD: 2}
+	redisClient := cache.NewRedisClientWithAddr(miniredis.RunT(t).Addr())
+
+	client, err := NewClientFromConfig(cfg, redisClient)
+	require.NoError(t, err)
+	assert.Equal(t, llm.ProviderFake, client.Provider.Name())
+	assert.Equal(t, "gpt-4o-mini", client.Model)
+	assert.Equal(t, 5*time.Second, client.Timeout)
+	assert.Equal(t, 2.0, client.Usage.DefaultBudget.DailyUSD)
+	assert.ErrorIs(t, client.QlooClient.CheckConfig(), qloo.ErrMissingAPIKey)
+	assert.Nil(t, client.Personas)
+	_, ok := client.Tools.Tool("build_personas")
+	assert.False(t, ok)
+
+	cfg.Segments.Personas = true
+	client, err = NewClientFromConfig(cfg, redisClient)
+	require.NoError(t, err)
+	assert.NotNil(t, client.Personas)
+	_, ok = client.Tools.Tool("build_personas")
+	assert.True(t, ok)
+
+	cfg.ToolPolicyFile = filepath.Join(t.TempDir(), "missing.json")
+	_, err = NewClientFromConfig(cfg, redisClient)
+	assert.Error(t, err)
 }
 
 func TestExecuteFunctionCall_SearchMarketplace(t *testing.T) {
@@ -40,10 +96,28 @@ func TestExecuteFunctionCall_SearchMarketplace(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 
-	resultMap, ok := result.(map[string]interface{})
+	searchResult, ok := result.(SearchResult)
 	assert.True(t, ok)
-	assert.Contains(t, resultMap, "products")
-	assert.Contains(t, resultMap, "count")
+	assert.Equal(t, len(searchResult.Products), searchResult.Count)
+}
+
+func TestExecuteSearchMarketplace_ForeignCurrencyPriceFilter(t *testing.T) {
+	client := NewClientWithKey("test-key")
+	amazon, ebay := &stubMarketplace{}, &stubMarketplace{}
+	client.AmazonClient, client.EbayClient = amazon, ebay
+
+	// KSh 5,000 must not be sent to USD marketplaces as $5,000
+	result, err := client.executeSearchMarketplace(context.Background(), SearchMarketplaceArgs{Query: "headphones", MaxPrice: 5000, Currency: "KES"})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0}, amazon.maxPrices)
+	assert.Equal(t, []float64{0}, ebay.maxPrices)
+	require.Len(t, result.Sources, 2)
+	assert.Equal(t, "price filter in KES not applied because prices are in USD", result.Sources[0].Note)
+
+	result, err = client.executeSearchMarketplace(context.Background(), SearchMarketplaceArgs{Query: "headphones", MaxPrice: 50, Currency: "USD"})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0, 50}, amazon.maxPrices)
+	assert.Empty(t, result.Sources[0].Note)
 }
 
 func TestExecuteFunctionCall_SearchMarketplace_MissingQuery(t *testing.T) {
@@ -204,3 +278,109 @@ func TestAdCopyResult_JSONMarshaling(t *testing.T) {
 	assert.Equal(t, adCopy.Headlines, unmarshaled.Headlines)
 	assert.Equal(t, adCopy.CallToAction, unmarshaled.CallToAction)
 }
+
+func TestSendMessage_RecordsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"model": "gpt-4o-2024-08-06",
+			"choices": [{"message": {"role": "assistant", "content": "Hello!"}}],
+			"usage": {"prompt_tokens": 1000, "completion_tokens": 200, "total_tokens": 1200}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithProvider(llm.NewOpenAICompatibleProvider("test-key", server.URL, "gpt-4o"), "gpt-4o")
+	client.Usage = nil
+
+	ctx, meter := usage.WithMeter(context.Background())
+	response, _, err := client.SendMessage(ctx, "hi")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello!", response)
+
+	total := meter.Total()
+	assert.Equal(t, 1200, total.TotalTokens)
+	assert.Equal(t, 1, total.Calls)
+	assert.InDelta(t, 0.0045, total.CostUSD, 1e-9)
+}
+
+func TestProcessMessage_FakeProviderToolCall(t *testing.T) {
+	provider := llm.NewFakeProvider(
+		llm.FakeJSON(MessageIntent{Type: IntentUnknown, Confidence: 0.9}),
+		llm.FakeToolCall("generate_ad_copy", map[string]any{
+			"product_title": "Trail Shoes",
+			"segments":      []string{"Hikers"},
+		}),
+	)
+	client := NewClientWithProvider(provider, "gpt-4o")
+	client.Usage = nil
+
+	response, err := client.ProcessMessage(context.Background(), "hello there")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, response.Marketing)
+	assert.NotNil(t, response.Usage)
+	assert.Equal(t, 2, response.Usage.Calls)
+
+	requests := provider.Requests()
+	if assert.Len(t, requests, 2) {
+		assert.NotNil(t, requests[0].ResponseFormat)
+		assert.Equal(t, "gpt-4o", requests[1].Model)
+		assert.NotEmpty(t, requests[1].Tools)
+	}
+}
+
+func TestClassifyIntent_ParsesSearchConstraints(t *testing.T) {
+	client := NewClientWithKey("test-key")
+
+	intent := client.classifyIntent("Find the cheapest used black Sony headphones under KSh 5,000")
+
+	assert.Equal(t, IntentSearch, intent.Type)
+	assert.Equal(t, 5000.0, intent.MaxPrice)
+	assert.Equal(t, "KES", intent.Currency)
+	assert.Equal(t, "Sony", intent.Brand)
+	assert.Equal(t, "black", intent.Color)
+	assert.Equal(t, queryparser.ConditionUsed, intent.Condition)
+	assert.Equal(t, queryparser.SortPriceAsc, intent.Sort)
+
+	args := client.searchArguments(intent)
+	assert.Equal(t, "Sony", args.Brand)
+	assert.Equal(t, queryparser.SortPriceAsc, args.Sort)
+	assert.Empty(t, args.Size)
+}
+
+func TestExecuteSearchMarketplace_AppliesConstraints(t *testing.T) {
+	client := NewClientWithKey("test-key")
+	amazon := &stubMarketplace{products: []marketplace.Product{
+		{Title: "Sony WH-1000XM4", Price: 250},
+		{Title: "Sony WH-CH520", Price: 40},
+	}}
+	ebay := &stubMarketplace{products: []marketplace.Product{
+		{Title: "Sony WH-1000XM3", Price: 120},
+	}}
+	client.AmazonClient = amazon
+	client.EbayClient = ebay
+
+	result, err := client.ExecuteFunctionCall(FunctionCall{
+		Name: "search_marketplace",
+		Arguments: map[string]any{
+			"query":     "sony headphones",
+			"max_price": 300.0,
+			"brand":     "Sony",
+			"color":     "black",
+			"condition": "refurbished",
+			"sort":      "price_asc",
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sony headphones refurbished black"}, amazon.queries)
+
+	products := result.(SearchResult).Products
+	if assert.Len(t, products, 3) {
+		assert.Equal(t, 40.0, products[0].Price)
+		assert.Equal(t, 120.0, products[1].Price)
+		assert.Equal(t, 250.0, products[2].Price)
+	}
+}
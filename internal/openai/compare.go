@@ -0,0 +1,244 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jesee-kuya/blue/internal/queryparser"
+)
+
+// MaxCompareProducts bounds how many products one comparison searches for
+const MaxCompareProducts = 5
+
+// ErrTooFewProducts is returned when a comparison names fewer than two products
+var ErrTooFewProducts = errors.New("at least two products are required to compare")
+
+// comparePatterns detect requests to compare products
+var comparePatterns = []string{
+	`(?i)\b(compare|comparison|versus|vs)\b`,
+	`(?i)\bwhich\s+(one\s+)?(is|should)\b.*\b(better|cheaper|buy|get)\b`,
+	`(?i)\bdifference\s+between\b`,
+}
+
+var (
+	// compareLeadPattern strips the request wording in front of the products
+	compareLeadPattern = regexp.MustCompile(`(?i)^\s*(please\s+)?((can|could)\s+you\s+)?(compare|(show\s+me\s+)?(a\s+)?comparison\s+(of|between)|(what\s+is\s+the\s+)?difference\s+between|which\s+is\s+better[,:]?)\s+(the\s+)?(prices\s+(of|for)\s+)?`)
+
+	// compareTailPattern strips a trailing question after the products
+	compareTailPattern = regexp.MustCompile(`(?i)[,;:]?\s*(which\s+(one\s+)?(is|should)\b.*|\?+)\s*$`)
+
+	// productSeparatorPattern splits a list of products
+	productSeparatorPattern = regexp.MustCompile(`(?i)\s*(,|;|/|\bvs\b\.?|\bversus\b|\band\b|\bor\b|\bagainst\b)\s*`)
+
+	// articlePattern strips a leading article from a product name
+	articlePattern = regexp.MustCompile(`(?i)^(the|a|an)\s+`)
+)
+
+// extractProducts splits a comparison request into the products it names
+func (c *Client) extractProducts(message string) []string {
+	cleanMessage := compareLeadPattern.ReplaceAllString(message, "")
+	cleanMessage = pricePhrasePattern.ReplaceAllString(cleanMessage, "")
+	cleanMessage = compareTailPattern.ReplaceAllString(cleanMessage, "")
+
+	var products []string
+	for _, part := range productSeparatorPattern.Split(cleanMessage, -1) {
+		part = strings.Trim(strings.TrimSpace(part), ".!?")
+		part = strings.TrimSpace(articlePattern.ReplaceAllString(part, ""))
+		if part != "" {
+			products = append(products, part)
+		}
+	}
+
+	return mergeProducts(nil, products)
+}
+
+// mergeProducts appends products that are not already listed, ignoring case,
+// keeping at most MaxCompareProducts
+func mergeProducts(products, more []string) []string {
+	seen := make(map[string]bool, len(products))
+	for _, product := range products {
+		seen[strings.ToLower(product)] = true
+	}
+
+	for _, product := range more {
+		key := strings.ToLower(product)
+		if seen[key] || len(products) >= MaxCompareProducts {
+			continue
+		}
+		seen[key] = true
+		products = append(products, product)
+	}
+
+	return products
+}
+
+// compareArguments builds the compare_products arguments for an intent. Brand,
+// color and size describe a single product, so only shared constraints apply.
+func (c *Client) compareArguments(intent MessageIntent) CompareProductsArgs {
+	return CompareProductsArgs{
+		Products:  intent.Products,
+		MinPrice:  intent.MinPrice,
+		MaxPrice:  intent.MaxPrice,
+		Currency:  intent.Currency,
+		Condition: intent.Condition,
+	}
+}
+
+// executeCompareProducts searches for each product concurrently and compares
+// the offers side by side
+func (c *Client) executeCompareProducts(ctx context.Context, compareArgs CompareProductsArgs) (ComparisonResult, error) {
+	comparisons := make([]ProductComparison, len(compareArgs.Products))
+	var wg sync.WaitGroup
+	for i, product := range compareArgs.Products {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			comparisons[i] = c.compareProduct(ctx, SearchMarketplaceArgs{
+				Query:     product,
+				MinPrice:  compareArgs.MinPrice,
+				MaxPrice:  compareArgs.MaxPrice,
+				Currency:  compareArgs.Currency,
+				Condition: compareArgs.Condition,
+			})
+		}()
+	}
+	wg.Wait()
+
+	return ComparisonResult{
+		Products:   comparisons,
+		Attributes: buildAttributeTable(comparisons),
+	}, nil
+}
+
+// compareProduct searches every marketplace for one product and summarizes its offers
+func (c *Client) compareProduct(ctx context.Context, args SearchMarketplaceArgs) ProductComparison {
+	comparison := ProductComparison{
+		Product:        args.Query,
+		Brand:          queryparser.Parse(args.Query).Brand,
+		CheapestOffers: make(map[string]ProductSummary),
+	}
+
+	var total float64
+	var failures []string
+	for _, results := range c.searchMarketplaces(ctx, args) {
+		if results.Err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", results.Marketplace, results.Err))
+			continue
+		}
+
+		for _, product := range results.Products {
+			if comparison.OfferCount == 0 || product.Price < comparison.MinPrice {
+				comparison.MinPrice = product.Price
+			}
+			if product.Price > comparison.MaxPrice {
+				comparison.MaxPrice = product.Price
+			}
+			total += product.Price
+			comparison.OfferCount++
+
+			cheapest, ok := comparison.CheapestOffers[results.Marketplace]
+			if !ok || product.Price < cheapest.Price {
+				comparison.CheapestOffers[results.Marketplace] = ProductSummary{
+					Title: product.Title,
+					Price: product.Price,
+					Link:  product.Link,
+				}
+			}
+		}
+	}
+
+	if comparison.OfferCount > 0 {
+		comparison.AveragePrice = roundPrice(total / float64(comparison.OfferCount))
+	} else if len(failures) > 0 {
+		comparison.Error = "search failed: " + strings.Join(failures, "; ")
+	}
+
+	return comparison
+}
+
+// buildAttributeTable lays the comparisons out as rows with one column per product
+func buildAttributeTable(comparisons []ProductComparison) []AttributeRow {
+	row := func(name string, value func(ProductComparison) string) AttributeRow {
+		values := make([]string, len(comparisons))
+		for i, comparison := range comparisons {
+			values[i] = value(comparison)
+			if values[i] == "" {
+				values[i] = "-"
+			}
+		}
+		return AttributeRow{Name: name, Values: values}
+	}
+
+	priced := func(value func(ProductComparison) string) func(ProductComparison) string {
+		return func(comparison ProductComparison) string {
+			if comparison.OfferCount == 0 {
+				return ""
+			}
+			return value(comparison)
+		}
+	}
+
+	rows := []AttributeRow{
+		row("Brand", func(p ProductComparison) string { return p.Brand }),
+		row("Offers", func(p ProductComparison) string { return strconv.Itoa(p.OfferCount) }),
+		row("Price range", priced(func(p ProductComparison) string {
+			return fmt.Sprintf("$%.2f - $%.2f", p.MinPrice, p.MaxPrice)
+		})),
+		row("Average price", priced(func(p ProductComparison) string {
+			return fmt.Sprintf("$%.2f", p.AveragePrice)
+		})),
+	}
+
+	// One row per marketplace that returned an offer for any product
+	for _, name := range offerMarketplaces(comparisons) {
+		rows = append(rows, row("Cheapest on "+name, func(p ProductComparison) string {
+			offer, ok := p.CheapestOffers[name]
+			if !ok {
+				return ""
+			}
+			return fmt.Sprintf("$%.2f", offer.Price)
+		}))
+	}
+
+	return rows
+}
+
+// offerMarketplaces lists the marketplaces with an offer for any compared product, sorted
+func offerMarketplaces(comparisons []ProductComparison) []string {
+	seen := make(map[string]bool)
+	var marketplaces []string
+	for _, comparison := range comparisons {
+		for name := range comparison.CheapestOffers {
+			if !seen[name] {
+				seen[name] = true
+				marketplaces = append(marketplaces, name)
+			}
+		}
+	}
+	sort.Strings(marketplaces)
+	return marketplaces
+}
+
+// cheapestOffer returns the lowest priced offer across all compared products
+func (r *ComparisonResult) cheapestOffer() (product, marketplace string, offer ProductSummary, ok bool) {
+	for _, comparison := range r.Products {
+		for _, name := range offerMarketplaces([]ProductComparison{comparison}) {
+			candidate := comparison.CheapestOffers[name]
+			if !ok || candidate.Price < offer.Price {
+				product, marketplace, offer, ok = comparison.Product, name, candidate, true
+			}
+		}
+	}
+	return product, marketplace, offer, ok
+}
+
+// roundPrice rounds a price to cents
+func roundPrice(price float64) float64 {
+	return float64(int64(price*100+0.5)) / 100
+}
@@ -0,0 +1,170 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/marketplace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyIntent_Comparison(t *testing.T) {
+	client := NewClientWithKey("test-key")
+
+	tests := []struct {
+		message  string
+		products []string
+		maxPrice float64
+	}{
+		{"compare the Sony WH-1000XM5 and Bose QC45 under $300", []string{"sony wh-1000xm5", "bose qc45"}, 300},
+		{"iPhone 15 vs Galaxy S24", []string{"iphone 15", "galaxy s24"}, 0},
+		{"Which is better, the Kindle Paperwhite or the Kobo Clara?", []string{"kindle paperwhite", "kobo clara"}, 0},
+		{"AirPods Pro versus Galaxy Buds 2, which one should I buy?", []string{"airpods pro", "galaxy buds 2"}, 0},
+		{"what is the difference between ps5 and xbox series x", []string{"ps5", "xbox series x"}, 0},
+		{"compare macbook air, dell xps 13 and thinkpad x1 below 1500", []string{"macbook air", "dell xps 13", "thinkpad x1"}, 1500},
+	}
+
+	for _, test := range tests {
+		t.Run(test.message, func(t *testing.T) {
+			intent := client.classifyIntent(test.message)
+			assert.Equal(t, IntentCompare, intent.Type)
+			assert.Equal(t, test.products, intent.Products)
+			assert.Equal(t, test.maxPrice, intent.MaxPrice)
+			assert.GreaterOrEqual(t, intent.Confidence, FastPathConfidence)
+		})
+	}
+}
+
+func TestProcessMessage_ComparesProducts(t *testing.T) {
+	client, amazon := newClarifyingClient()
+	amazon.byQuery = map[string][]marketplace.Product{
+		"sony wh-1000xm5": {
+			{Title: "Sony WH-1000XM5 Black", Price: 279, Link: "https://amazon.example/sony-1"},
+			{Title: "Sony WH-1000XM5 Silver", Price: 299, Link: "https://amazon.example/sony-2"},
+		},
+		"bose qc45": {
+			{Title: "Bose QuietComfort 45", Price: 229, Link: "https://amazon.example/bose"},
+		},
+	}
+	ebay := &stubMarketplace{byQuery: map[string][]marketplace.Product{
+		"sony wh-1000xm5": {{Title: "Sony WH-1000XM5 (open box)", Price: 249, Link: "https://ebay.example/sony"}},
+		"bose qc45":       {},
+	}}
+	client.EbayClient = ebay
+
+	response, err := client.ProcessMessage(context.Background(), "compare the Sony WH-1000XM5 and Bose QC45 under $300")
+	require.NoError(t, err)
+	require.NotNil(t, response.Comparison)
+	assert.Nil(t, response.SearchResults)
+
+	assert.ElementsMatch(t, []string{"sony wh-1000xm5", "bose qc45"}, amazon.queries)
+	assert.ElementsMatch(t, []string{"sony wh-1000xm5", "bose qc45"}, ebay.queries)
+	assert.Equal(t, []float64{300, 300}, amazon.maxPrices)
+
+	require.Len(t, response.Comparison.Products, 2)
+	sony, bose := response.Comparison.Products[0], response.Comparison.Products[1]
+
+	assert.Equal(t, "sony wh-1000xm5", sony.Product)
+	assert.Equal(t, "Sony", sony.Brand)
+	assert.Equal(t, 3, sony.OfferCount)
+	assert.Equal(t, 249.0, sony.MinPrice)
+	assert.Equal(t, 299.0, sony.MaxPrice)
+	assert.Equal(t, 275.67, sony.AveragePrice)
+	assert.Equal(t, 279.0, sony.CheapestOffers["Amazon"].Price)
+	assert.Equal(t, 249.0, sony.CheapestOffers["eBay"].Price)
+
+	assert.Equal(t, "Bose", bose.Brand)
+	assert.Equal(t, 1, bose.OfferCount)
+	assert.NotContains(t, bose.CheapestOffers, "eBay")
+
+	assert.Equal(t, []AttributeRow{
+		{Name: "Brand", Values: []string{"Sony", "Bose"}},
+		{Name: "Offers", Values: []string{"3", "1"}},
+		{Name: "Price range", Values: []string{"$249.00 - $299.00", "$229.00 - $229.00"}},
+		{Name: "Average price", Values: []string{"$275.67", "$229.00"}},
+		{Name: "Cheapest on Amazon", Values: []string{"$279.00", "$229.00"}},
+		{Name: "Cheapest on eBay", Values: []string{"$249.00", "-"}},
+	}, response.Comparison.Attributes)
+
+	assert.Contains(t, response.Message, "sony wh-1000xm5 and bose qc45 compare")
+	assert.Contains(t, response.Message, "**Best Price:** bose qc45 at $229.00 on Amazon")
+}
+
+func TestProcessConversation_AsksForSecondProductToCompare(t *testing.T) {
+	client, amazon := newClarifyingClient(llm.FakeJSON(MessageIntent{
+		Type:       IntentCompare,
+		Products:   []string{"kindle paperwhite"},
+		Confidence: 0.9,
+	}))
+	ctx := context.Background()
+
+	response, err := client.ProcessMessage(ctx, "compare the kindle paperwhite")
+	require.NoError(t, err)
+	require.NotNil(t, response.Clarification)
+	assert.Equal(t, []string{SlotProducts}, response.Clarification.MissingSlots)
+	assert.Equal(t, "What would you like to compare kindle paperwhite with?", response.Message)
+
+	response, err = client.ProcessConversation(ctx, response.ConversationID, "the Kobo Clara")
+	require.NoError(t, err)
+	require.NotNil(t, response.Comparison)
+	assert.ElementsMatch(t, []string{"kindle paperwhite", "Kobo Clara"}, amazon.queries)
+}
+
+func TestExecuteCompareProducts(t *testing.T) {
+	client, _ := newClarifyingClient()
+	client.EbayClient = &stubMarketplace{err: errors.New("ebay unavailable")}
+
+	t.Run("tool call arguments", func(t *testing.T) {
+		result, err := client.ExecuteFunctionCall(FunctionCall{
+			Name: "compare_products",
+			Arguments: map[string]any{
+				"products":  []any{"kindle", "kobo", "Kindle"},
+				"max_price": 150.0,
+			},
+		})
+		require.NoError(t, err)
+
+		comparison := result.(ComparisonResult)
+		require.Len(t, comparison.Products, 2)
+		assert.Equal(t, 1, comparison.Products[0].OfferCount)
+		assert.Empty(t, comparison.Products[0].Error)
+		assert.NotContains(t, comparison.Products[0].CheapestOffers, "eBay")
+	})
+
+	t.Run("too few products", func(t *testing.T) {
+		_, err := client.ExecuteFunctionCall(FunctionCall{
+			Name:      "compare_products",
+			Arguments: map[string]any{"products": []any{"kindle", " "}},
+		})
+		assert.ErrorIs(t, err, ErrTooFewProducts)
+	})
+
+	t.Run("all marketplaces failing", func(t *testing.T) {
+		client.AmazonClient = &stubMarketplace{err: errors.New("amazon unavailable")}
+
+		result, err := client.ExecuteFunctionCall(FunctionCall{
+			Name:      "compare_products",
+			Arguments: map[string]any{"products": []string{"kindle", "kobo"}},
+		})
+		require.NoError(t, err)
+
+		comparison := result.(ComparisonResult)
+		assert.Contains(t, comparison.Products[0].Error, "amazon unavailable")
+		assert.Contains(t, comparison.Products[0].Error, "ebay unavailable")
+		assert.Contains(t, client.formatComparisonMessage(&comparison), "couldn't find any offers for kindle and kobo")
+	})
+}
+
+func TestNormalizeIntent_MultiProductSearchBecomesComparison(t *testing.T) {
+	intent := normalizeIntent(MessageIntent{
+		Type:       IntentSearch,
+		Products:   []string{" running shoes ", "water bottle", ""},
+		Confidence: 0.9,
+	})
+
+	assert.Equal(t, IntentCompare, intent.Type)
+	assert.Equal(t, []string{"running shoes", "water bottle"}, intent.Products)
+}
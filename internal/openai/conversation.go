@@ -0,0 +1,137 @@
+package openai
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/tenant"
+)
+
+// ConversationTTL is how long a pending clarification waits for the user's answer
+const ConversationTTL = 30 * time.Minute
+
+// ErrConversationNotFound is returned when a conversation has no pending clarification
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// PendingIntent is an intent waiting for the user to fill its missing slots
+type PendingIntent struct {
+	Intent       MessageIntent `json:"intent"`
+	MissingSlots []string      `json:"missing_slots"`
+	Attempts     int           `json:"attempts"`
+}
+
+// ConversationStore keeps pending intents between turns of a conversation
+type ConversationStore interface {
+	Load(ctx context.Context, conversationID string) (*PendingIntent, error)
+	Save(ctx context.Context, conversationID string, pending *PendingIntent) error
+	Delete(ctx context.Context, conversationID string) error
+}
+
+// RedisConversationStore stores pending intents in Redis, scoped to the tenant in ctx
+type RedisConversationStore struct {
+	redisClient *cache.RedisClient
+}
+
+// NewRedisConversationStore creates a new Redis-backed conversation store
+func NewRedisConversationStore(redisClient *cache.RedisClient) *RedisConversationStore {
+	return &RedisConversationStore{redisClient: redisClient}
+}
+
+// Load returns the pending intent for the conversation
+func (s *RedisConversationStore) Load(ctx context.Context, conversationID string) (*PendingIntent, error) {
+	var pending PendingIntent
+	if err := s.redisClient.Get(ctx, conversationKey(ctx, conversationID), &pending); err != nil {
+		if cache.IsMiss(err) {
+			return nil, ErrConversationNotFound
+		}
+		return nil, err
+	}
+	return &pending, nil
+}
+
+// Save stores the pending intent until ConversationTTL elapses
+func (s *RedisConversationStore) Save(ctx context.Context, conversationID string, pending *PendingIntent) error {
+	return s.redisClient.SetWithTTL(ctx, conversationKey(ctx, conversationID), pending, ConversationTTL)
+}
+
+// Delete removes the pending intent for the conversation
+func (s *RedisConversationStore) Delete(ctx context.Context, conversationID string) error {
+	return s.redisClient.Del(ctx, conversationKey(ctx, conversationID))
+}
+
+// conversationKey builds the tenant-scoped cache key for a conversation
+func conversationKey(ctx context.Context, conversationID string) string {
+	return tenant.ScopedKey(ctx, "conversation:"+conversationID)
+}
+
+// MemoryConversationStore keeps pending intents in process memory, for tests and
+// single-instance deployments
+type MemoryConversationStore struct {
+	mu      sync.Mutex
+	pending map[string]memoryConversation
+	now     func() time.Time
+}
+
+type memoryConversation struct {
+	pending PendingIntent
+	expires time.Time
+}
+
+// NewMemoryConversationStore creates a new in-memory conversation store
+func NewMemoryConversationStore() *MemoryConversationStore {
+	return &MemoryConversationStore{
+		pending: make(map[string]memoryConversation),
+		now:     time.Now,
+	}
+}
+
+// Load returns the pending intent for the conversation
+func (s *MemoryConversationStore) Load(ctx context.Context, conversationID string) (*PendingIntent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := conversationKey(ctx, conversationID)
+	entry, ok := s.pending[key]
+	if !ok || s.now().After(entry.expires) {
+		delete(s.pending, key)
+		return nil, ErrConversationNotFound
+	}
+	pending := entry.pending
+	return &pending, nil
+}
+
+// Save stores the pending intent until ConversationTTL elapses
+func (s *MemoryConversationStore) Save(ctx context.Context, conversationID string, pending *PendingIntent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending[conversationKey(ctx, conversationID)] = memoryConversation{
+		pending: *pending,
+		expires: s.now().Add(ConversationTTL),
+	}
+	return nil
+}
+
+// Delete removes the pending intent for the conversation
+func (s *MemoryConversationStore) Delete(ctx context.Context, conversationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pending, conversationKey(ctx, conversationID))
+	return nil
+}
+
+// newConversationID generates a random conversation identifier
+func newConversationID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate conversation ID: %w", err)
+	}
+	return "conv_" + hex.EncodeToString(b), nil
+}
@@ -1,69 +1,65 @@
 package openai
 
 import (
+	"context"
+	"maps"
+
+	"github.com/jesee-kuya/blue/internal/retry"
 	"github.com/sashabaranov/go-openai"
-	"github.com/sashabaranov/go-openai/jsonschema"
 )
 
+// builtinTools returns the tools backed by the client's marketplace and Qloo clients.
+// Adding a tool means declaring its argument and result types and registering it here.
+// build_personas is only offered when the client has a persona builder.
+func (c *Client) builtinTools() []Tool {
+	tools := []Tool{
+		NewTool("search_marketplace",
+			"Search for products across multiple marketplaces (Amazon, eBay, Jumia) with optional price filtering",
+			c.executeSearchMarketplace),
+		NewTool("compare_products",
+			"Compare two or more products by searching each across the marketplaces, returning price ranges, the cheapest offer per marketplace and an attribute table",
+			c.executeCompareProducts),
+		NewTool("get_taste_profile",
+			"Analyze a product description using Qloo's Taste AI to identify target audience segments with affinity scores",
+			c.executeGetTasteProfile),
+	}
+	if c.Personas != nil {
+		tools = append(tools, NewTool("build_personas",
+			"Build marketing personas (demographics, interests, motivations, preferred channels and messaging angles) from the top audience segments for a product description; pass them to generate_ad_copy",
+			c.executeBuildPersonas))
+	}
+	return append(tools,
+		NewTool("find_entities",
+			"Find brands, products, artists and other entities in Qloo's catalogue by name, returning their Qloo IDs and popularity",
+			c.executeFindEntities),
+		NewTool("get_entity_insights",
+			"Find what audiences of a brand, product, artist or other entity also like, with affinity scores and the audience's age and gender profile",
+			c.executeGetEntityInsights),
+		NewTool("get_audience_demographics",
+			"Describe who the audience of a brand, product, artist or other entity is by age band and gender, relative to the general population",
+			c.executeGetAudienceDemographics),
+		NewTool("generate_ad_copy",
+			"Generate marketing copy and advertisements for a product targeting specific audience segments",
+			c.executeGenerateAdCopy),
+	)
+}
+
+// newToolRegistry registers the builtin tools with their retry overrides
+func (c *Client) newToolRegistry() *ToolRegistry {
+	registry := NewToolRegistry(c.builtinTools()...)
+	maps.Copy(registry.Retries, builtinRetries)
+	return registry
+}
+
+// builtinRetries overrides the default retry policy for tools that never call an
+// upstream directly: ad copy comes from templates and comparisons already report
+// per-marketplace failures in their result
+var builtinRetries = map[string]retry.Policy{
+	"compare_products": {MaxAttempts: 1},
+	"generate_ad_copy": {MaxAttempts: 1},
+}
+
 // GetFunctionDefinitions returns the OpenAI function definitions for our available functions
 func GetFunctionDefinitions() []openai.FunctionDefinition {
-	return []openai.FunctionDefinition{
-		{
-			Name:        "search_marketplace",
-			Description: "Search for products across multiple marketplaces (Amazon, eBay, Jumia) with optional price filtering",
-			Parameters: jsonschema.Definition{
-				Type: jsonschema.Object,
-				Properties: map[string]jsonschema.Definition{
-					"query": {
-						Type:        jsonschema.String,
-						Description: "Search query for products",
-					},
-					"min_price": {
-						Type:        jsonschema.Number,
-						Description: "Minimum price filter (optional)",
-					},
-					"max_price": {
-						Type:        jsonschema.Number,
-						Description: "Maximum price filter (optional)",
-					},
-				},
-				Required: []string{"query"},
-			},
-		},
-		{
-			Name:        "get_taste_profile",
-			Description: "Analyze a product description using Qloo's Taste AI to identify target audience segments with affinity scores",
-			Parameters: jsonschema.Definition{
-				Type: jsonschema.Object,
-				Properties: map[string]jsonschema.Definition{
-					"description": {
-						Type:        jsonschema.String,
-						Description: "Product description to analyze for audience segments",
-					},
-				},
-				Required: []string{"description"},
-			},
-		},
-		{
-			Name:        "generate_ad_copy",
-			Description: "Generate marketing copy and advertisements for a product targeting specific audience segments",
-			Parameters: jsonschema.Definition{
-				Type: jsonschema.Object,
-				Properties: map[string]jsonschema.Definition{
-					"product_title": {
-						Type:        jsonschema.String,
-						Description: "Title or name of the product",
-					},
-					"segments": {
-						Type: jsonschema.Array,
-						Items: &jsonschema.Definition{
-							Type: jsonschema.String,
-						},
-						Description: "Target audience segments for the ad copy",
-					},
-				},
-				Required: []string{"product_title", "segments"},
-			},
-		},
-	}
+	return NewToolRegistry((&Client{}).builtinTools()...).Definitions(context.Background())
 }
@@ -0,0 +1,109 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/jesee-kuya/blue/internal/qloo"
+)
+
+// executeFindEntities searches Qloo's catalogue for entities by name
+func (c *Client) executeFindEntities(ctx context.Context, args FindEntitiesArgs) (EntitiesResult, error) {
+	var entities []qloo.Entity
+	err := c.guard(ctx, UpstreamQloo, func() error {
+		var err error
+		entities, err = c.QlooClient.SearchEntities(ctx, args.Query, args.Type, args.Limit)
+		return err
+	})
+	if err != nil {
+		return EntitiesResult{}, fmt.Errorf("failed to find entities: %w", err)
+	}
+
+	return EntitiesResult{Entities: entities, Count: len(entities)}, nil
+}
+
+// executeGetEntityInsights finds what audiences of the seed entity also like
+// and who that audience is. Demographics are best effort: the related entities
+// are still returned when they cannot be fetched.
+func (c *Client) executeGetEntityInsights(ctx context.Context, args EntityInsightsArgs) (EntityInsightsResult, error) {
+	entity, err := c.resolveEntity(ctx, args.Entity, args.EntityID, args.EntityType)
+	if err != nil {
+		return EntityInsightsResult{}, err
+	}
+
+	var related []qloo.Entity
+	err = c.guard(ctx, UpstreamQloo, func() error {
+		var err error
+		related, err = c.QlooClient.GetInsights(ctx, qloo.InsightsRequest{
+			EntityIDs: []string{entity.ID},
+			Type:      args.TargetType,
+			Limit:     args.Limit,
+		})
+		return err
+	})
+	if err != nil {
+		return EntityInsightsResult{}, fmt.Errorf("failed to get insights: %w", err)
+	}
+
+	result := EntityInsightsResult{Entity: entity, Related: related, Count: len(related)}
+	demographics, err := c.demographics(ctx, entity.ID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to get audience demographics", "entity", entity.ID, logging.KeyError, err)
+	} else {
+		result.Demographics = &demographics
+	}
+	return result, nil
+}
+
+// executeGetAudienceDemographics describes the audience of an entity
+func (c *Client) executeGetAudienceDemographics(ctx context.Context, args AudienceDemographicsArgs) (AudienceDemographicsResult, error) {
+	entity, err := c.resolveEntity(ctx, args.Entity, args.EntityID, args.EntityType)
+	if err != nil {
+		return AudienceDemographicsResult{}, err
+	}
+
+	demographics, err := c.demographics(ctx, entity.ID)
+	if err != nil {
+		return AudienceDemographicsResult{}, fmt.Errorf("failed to get audience demographics: %w", err)
+	}
+	return AudienceDemographicsResult{Entity: entity, Demographics: demographics}, nil
+}
+
+// resolveEntity returns the entity with the given ID, or else the best match
+// for its name
+func (c *Client) resolveEntity(ctx context.Context, name, id string, entityType qloo.EntityType) (qloo.Entity, error) {
+	if id != "" {
+		return qloo.Entity{ID: id, Name: name, Type: entityType}, nil
+	}
+
+	var entity qloo.Entity
+	err := c.guard(ctx, UpstreamQloo, func() error {
+		var err error
+		entity, err = c.QlooClient.ResolveEntity(ctx, name, entityType)
+		return err
+	})
+	if err != nil {
+		return qloo.Entity{}, fmt.Errorf("failed to find entity %q: %w", name, err)
+	}
+	return entity, nil
+}
+
+// demographics returns the audience demographics of one entity
+func (c *Client) demographics(ctx context.Context, entityID string) (qloo.Demographics, error) {
+	var demographics []qloo.Demographics
+	err := c.guard(ctx, UpstreamQloo, func() error {
+		var err error
+		demographics, err = c.QlooClient.GetDemographics(ctx, entityID)
+		return err
+	})
+	if err != nil {
+		return qloo.Demographics{}, err
+	}
+	for _, d := range demographics {
+		if d.EntityID == entityID {
+			return d, nil
+		}
+	}
+	return qloo.Demographics{}, fmt.Errorf("no demographics returned for entity %s", entityID)
+}
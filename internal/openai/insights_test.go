@@ -0,0 +1,109 @@
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newQlooInsightsServer serves entity search, insights and demographics for a single brand
+func newQlooInsightsServer(t *testing.T, demographicsStatus int) *qloo.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/search":
+			w.Write([]byte(`{"results": [{"entity_id": "B1", "name": "Patagonia", "types": ["urn:entity:brand"], "popularity": 0.97}]}`))
+		case r.URL.Query().Get("filter.type") == "urn:demographics":
+			w.WriteHeader(demographicsStatus)
+			w.Write([]byte(`{"results": {"demographics": [{"entity_id": "B1", "query": {"age": {"35_to_44": 0.25}, "gender": {"female": 0.1}}}]}}`))
+		default:
+			assert.Equal(t, "B1", r.URL.Query().Get("signal.interests.entities"))
+			w.Write([]byte(`{"results": {"entities": [{"entity_id": "B2", "name": "Arc'teryx", "types": ["urn:entity:brand"], "query": {"affinity": 0.88}}]}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	redisClient := cache.NewRedisClientWithAddr(miniredis.RunT(t).Addr())
+	return qloo.NewClientWithOptions(qloo.Options{APIKey: "test-api-key", BaseURL: server.URL}, redisClient)
+}
+
+func TestExecuteFunctionCall_FindEntities(t *testing.T) {
+	client := NewClientWithKey("test-key")
+	client.QlooClient = newQlooInsightsServer(t, http.StatusOK)
+
+	result, err := client.ExecuteFunctionCall(FunctionCall{
+		Name:      "find_entities",
+		Arguments: map[string]any{"query": "Patagonia", "type": "brand"},
+	})
+
+	require.NoError(t, err)
+	entities := result.(EntitiesResult)
+	assert.Equal(t, 1, entities.Count)
+	assert.Equal(t, "B1", entities.Entities[0].ID)
+
+	_, err = client.ExecuteFunctionCall(FunctionCall{
+		Name:      "find_entities",
+		Arguments: map[string]any{"query": "Patagonia", "type": "sneaker"},
+	})
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+}
+
+func TestExecuteFunctionCall_GetEntityInsights(t *testing.T) {
+	client := NewClientWithKey("test-key")
+	client.QlooClient = newQlooInsightsServer(t, http.StatusOK)
+
+	result, err := client.ExecuteFunctionCall(FunctionCall{
+		Name:      "get_entity_insights",
+		Arguments: map[string]any{"entity": "Patagonia", "target_type": "brand"},
+	})
+
+	require.NoError(t, err)
+	insights := result.(EntityInsightsResult)
+	assert.Equal(t, "Patagonia", insights.Entity.Name)
+	require.Len(t, insights.Related, 1)
+	assert.Equal(t, "Arc'teryx", insights.Related[0].Name)
+	assert.Equal(t, 0.88, insights.Related[0].Affinity)
+	require.NotNil(t, insights.Demographics)
+	assert.Equal(t, 0.25, insights.Demographics.Age["35_to_44"])
+
+	_, err = client.ExecuteFunctionCall(FunctionCall{
+		Name:      "get_entity_insights",
+		Arguments: map[string]any{"target_type": "brand"},
+	})
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+}
+
+func TestExecuteFunctionCall_GetEntityInsights_WithoutDemographics(t *testing.T) {
+	client := NewClientWithKey("test-key")
+	client.QlooClient = newQlooInsightsServer(t, http.StatusForbidden)
+
+	result, err := client.ExecuteFunctionCall(FunctionCall{
+		Name:      "get_entity_insights",
+		Arguments: map[string]any{"entity_id": "B1", "target_type": "brand"},
+	})
+
+	require.NoError(t, err)
+	insights := result.(EntityInsightsResult)
+	assert.Equal(t, 1, insights.Count)
+	assert.Nil(t, insights.Demographics)
+}
+
+func TestExecuteFunctionCall_GetAudienceDemographics(t *testing.T) {
+	client := NewClientWithKey("test-key")
+	client.QlooClient = newQlooInsightsServer(t, http.StatusOK)
+
+	result, err := client.ExecuteFunctionCall(FunctionCall{
+		Name:      "get_audience_demographics",
+		Arguments: map[string]any{"entity": "Patagonia"},
+	})
+
+	require.NoError(t, err)
+	audience := result.(AudienceDemographicsResult)
+	assert.Equal(t, "B1", audience.Entity.ID)
+	assert.Equal(t, 0.1, audience.Demographics.Gender["female"])
+}
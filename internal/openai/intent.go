@@ -0,0 +1,216 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/jesee-kuya/blue/internal/metrics"
+	"github.com/jesee-kuya/blue/internal/queryparser"
+	"github.com/jesee-kuya/blue/internal/tracing"
+	"github.com/sashabaranov/go-openai/jsonschema"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FastPathConfidence is the regex classifier confidence at or above which the
+// LLM classifier is skipped
+const FastPathConfidence = 0.75
+
+// IntentClassifier determines what a user message is asking for
+type IntentClassifier interface {
+	Classify(ctx context.Context, message string) (MessageIntent, error)
+}
+
+// intentSystemPrompt instructs the model how to fill the intent schema
+const intentSystemPrompt = `You classify messages sent to a shopping and marketing assistant.
+
+Intents:
+- search: the user wants to find or buy products
+- marketing: the user wants ad copy, campaigns or audience insights for a product
+- combined: the user wants both product search results and marketing copy
+- compare: the user wants to compare two or more products, or to shop for several
+  different products at once
+- unknown: anything else
+
+Extract the product as a short search query without prices or filler words, and
+list descriptive attributes (brand, color, size, condition, features) separately.
+When the message names more than one product, list each as its own query in
+products; otherwise leave products empty.
+Use 0 for prices that are not mentioned and an ISO 4217 code for the currency, or
+an empty string if none is given. Confidence is between 0 and 1.`
+
+// IntentSchema returns the JSON schema for structured intent classification
+func IntentSchema() jsonschema.Definition {
+	return jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"type": {
+				Type:        jsonschema.String,
+				Enum:        []string{string(IntentSearch), string(IntentMarketing), string(IntentCombined), string(IntentCompare), string(IntentUnknown)},
+				Description: "Classified intent of the message",
+			},
+			"product": {
+				Type:        jsonschema.String,
+				Description: "Product search query",
+			},
+			"products": {
+				Type:        jsonschema.Array,
+				Items:       &jsonschema.Definition{Type: jsonschema.String},
+				Description: "Search query for each product when several are named",
+			},
+			"description": {
+				Type:        jsonschema.String,
+				Description: "Product description to base marketing copy on",
+			},
+			"attributes": {
+				Type:        jsonschema.Array,
+				Items:       &jsonschema.Definition{Type: jsonschema.String},
+				Description: "Product attributes such as brand, color, size or condition",
+			},
+			"min_price": {
+				Type:        jsonschema.Number,
+				Description: "Minimum price, 0 if not mentioned",
+			},
+			"max_price": {
+				Type:        jsonschema.Number,
+				Description: "Maximum price, 0 if not mentioned",
+			},
+			"currency": {
+				Type:        jsonschema.String,
+				Description: "ISO 4217 currency code of the prices",
+			},
+			"target_audience": {
+				Type:        jsonschema.String,
+				Description: "Audience the marketing should target",
+			},
+			"channels": {
+				Type:        jsonschema.Array,
+				Items:       &jsonschema.Definition{Type: jsonschema.String},
+				Description: "Marketing channels such as instagram, email or search ads",
+			},
+			"confidence": {
+				Type:        jsonschema.Number,
+				Description: "Confidence in the classification between 0 and 1",
+			},
+		},
+		Required: []string{
+			"type", "product", "products", "description", "attributes", "min_price", "max_price",
+			"currency", "target_audience", "channels", "confidence",
+		},
+		AdditionalProperties: false,
+	}
+}
+
+// LLMClassifier classifies messages with a model using structured output
+type LLMClassifier struct {
+	Provider llm.Provider
+	Model    string
+}
+
+// NewLLMClassifier creates a new LLM-backed intent classifier
+func NewLLMClassifier(provider llm.Provider, model string) *LLMClassifier {
+	return &LLMClassifier{
+		Provider: provider,
+		Model:    model,
+	}
+}
+
+// Classify asks the model for a typed MessageIntent
+func (l *LLMClassifier) Classify(ctx context.Context, message string) (MessageIntent, error) {
+	var intent MessageIntent
+	_, err := llm.ChatJSON(ctx, l.Provider, llm.ChatRequest{
+		Model: l.Model,
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: intentSystemPrompt},
+			{Role: llm.RoleUser, Content: message},
+		},
+		ResponseFormat: &llm.ResponseFormat{
+			Name:   "message_intent",
+			Schema: IntentSchema(),
+			Strict: true,
+		},
+	}, &intent)
+	if err != nil {
+		return MessageIntent{}, fmt.Errorf("failed to classify intent: %w", err)
+	}
+
+	return normalizeIntent(intent), nil
+}
+
+// normalizeIntent clamps model output to values the orchestrator understands
+func normalizeIntent(intent MessageIntent) MessageIntent {
+	switch intent.Type {
+	case IntentSearch, IntentMarketing, IntentCombined, IntentCompare:
+	default:
+		intent.Type = IntentUnknown
+	}
+
+	intent.Product = strings.TrimSpace(intent.Product)
+	var products []string
+	for _, product := range intent.Products {
+		if product = strings.TrimSpace(product); product != "" {
+			products = append(products, product)
+		}
+	}
+	intent.Products = mergeProducts(nil, products)
+
+	// Searching for several products is answered with one search per product
+	if intent.Type == IntentSearch && len(intent.Products) > 1 {
+		intent.Type = IntentCompare
+	}
+	if intent.Type == IntentCompare && len(intent.Products) == 0 && intent.Product != "" {
+		intent.Products = []string{intent.Product}
+	}
+	intent.Currency = strings.ToUpper(strings.TrimSpace(intent.Currency))
+	intent.MinPrice = max(intent.MinPrice, 0)
+	intent.MaxPrice = max(intent.MaxPrice, 0)
+	if intent.MaxPrice > 0 && intent.MinPrice > intent.MaxPrice {
+		intent.MinPrice, intent.MaxPrice = intent.MaxPrice, intent.MinPrice
+	}
+	if intent.Confidence < 0 {
+		intent.Confidence = 0
+	} else if intent.Confidence > 1 {
+		intent.Confidence = 1
+	}
+
+	return intent
+}
+
+// detectIntent classifies a message with the regex fast path, consulting the
+// LLM classifier when the patterns are not confident. The regex result is used
+// if the LLM classifier fails.
+func (c *Client) detectIntent(ctx context.Context, message string) MessageIntent {
+	ctx, span := tracing.Start(ctx, "classify intent")
+	defer span.End()
+
+	intent := c.classifyIntent(message)
+	if intent.Confidence >= FastPathConfidence || c.Classifier == nil {
+		return observeIntent(span, intent, "pattern")
+	}
+
+	classified, err := c.Classifier.Classify(ctx, message)
+	if err != nil {
+		logging.FromContext(ctx).Warn("LLM intent classification failed, using pattern match", logging.KeyError, err)
+		span.RecordError(err)
+		return observeIntent(span, intent, "pattern_fallback")
+	}
+
+	// Constraints the model did not extract are filled in by the query parser
+	c.applyConstraints(&classified, queryparser.Parse(message))
+
+	return observeIntent(span, classified, "llm")
+}
+
+// observeIntent records the classification on the span and in metrics
+func observeIntent(span trace.Span, intent MessageIntent, source string) MessageIntent {
+	span.SetAttributes(
+		attribute.String("intent.type", string(intent.Type)),
+		attribute.String("intent.source", source),
+		attribute.Float64("intent.confidence", intent.Confidence),
+	)
+	metrics.ObserveIntent(string(intent.Type), source)
+	return intent
+}
@@ -0,0 +1,123 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeClassifierClient(steps ...llm.FakeStep) (*Client, *llm.FakeProvider) {
+	provider := llm.NewFakeProvider(steps...)
+	client := NewClientWithProvider(provider, "gpt-4o")
+	client.Usage = nil
+	return client, provider
+}
+
+func TestLLMClassifier_Classify(t *testing.T) {
+	provider := llm.NewFakeProvider(llm.FakeText(`{
+		"type": "search",
+		"product": " blender ",
+		"description": "",
+		"attributes": ["cheap"],
+		"min_price": 0,
+		"max_price": 50,
+		"currency": "usd",
+		"target_audience": "",
+		"channels": [],
+		"confidence": 0.92
+	}`))
+	classifier := NewLLMClassifier(provider, "gpt-4o-mini")
+
+	intent, err := classifier.Classify(context.Background(), "I need a cheap blender")
+
+	require.NoError(t, err)
+	assert.Equal(t, IntentSearch, intent.Type)
+	assert.Equal(t, "blender", intent.Product)
+	assert.Equal(t, []string{"cheap"}, intent.Attributes)
+	assert.Equal(t, 50.0, intent.MaxPrice)
+	assert.Equal(t, "USD", intent.Currency)
+	assert.Equal(t, 0.92, intent.Confidence)
+
+	requests := provider.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "gpt-4o-mini", requests[0].Model)
+	require.NotNil(t, requests[0].ResponseFormat)
+	assert.True(t, requests[0].ResponseFormat.Strict)
+}
+
+func TestLLMClassifier_NormalizesOutput(t *testing.T) {
+	provider := llm.NewFakeProvider(llm.FakeJSON(map[string]any{
+		"type":       "shopping",
+		"min_price":  300,
+		"max_price":  100,
+		"confidence": 3,
+	}))
+
+	intent, err := NewLLMClassifier(provider, "gpt-4o").Classify(context.Background(), "anything")
+
+	require.NoError(t, err)
+	assert.Equal(t, IntentUnknown, intent.Type)
+	assert.Equal(t, 100.0, intent.MinPrice)
+	assert.Equal(t, 300.0, intent.MaxPrice)
+	assert.Equal(t, 1.0, intent.Confidence)
+}
+
+func TestDetectIntent_FastPathSkipsLLM(t *testing.T) {
+	client, provider := newFakeClassifierClient()
+
+	intent := client.detectIntent(context.Background(), "Find laptops under $1000")
+
+	assert.Equal(t, IntentSearch, intent.Type)
+	assert.GreaterOrEqual(t, intent.Confidence, FastPathConfidence)
+	assert.Empty(t, provider.Requests())
+}
+
+func TestDetectIntent_UsesLLMWhenPatternsUnsure(t *testing.T) {
+	client, provider := newFakeClassifierClient(llm.FakeJSON(MessageIntent{
+		Type:       IntentSearch,
+		Product:    "blender",
+		Attributes: []string{"cheap"},
+		Confidence: 0.9,
+	}))
+
+	assert.Equal(t, IntentUnknown, client.classifyIntent("I need a cheap blender").Type)
+
+	intent := client.detectIntent(context.Background(), "I need a cheap blender")
+
+	assert.Equal(t, IntentSearch, intent.Type)
+	assert.Equal(t, "blender", intent.Product)
+	assert.Len(t, provider.Requests(), 1)
+}
+
+func TestDetectIntent_FallsBackToPatterns(t *testing.T) {
+	client, _ := newFakeClassifierClient(
+		llm.FakeError(errors.New("provider unavailable")),
+		llm.FakeText("not json"),
+	)
+
+	for i := 0; i < 2; i++ {
+		intent := client.detectIntent(context.Background(), "Create marketing copy")
+		assert.Equal(t, IntentMarketing, intent.Type)
+		assert.Less(t, intent.Confidence, FastPathConfidence)
+	}
+}
+
+func TestClassifyIntent_Confidence(t *testing.T) {
+	client := NewClientWithKey("test-key")
+
+	assert.Equal(t, 0.0, client.classifyIntent("hello there").Confidence)
+	assert.Equal(t, 0.8, client.classifyIntent("Search for wireless mice").Confidence)
+}
+
+func TestClassifyIntent_DescriptionNeedsMarketingWords(t *testing.T) {
+	client := NewClientWithKey("test-key")
+
+	for _, message := range []string{"Find headphones under $50", "Search for an iPad", "Show me add-on batteries"} {
+		assert.Empty(t, client.classifyIntent(message).Description, message)
+	}
+	assert.Equal(t, "wireless headphones", client.classifyIntent("Create ads for wireless headphones").Description)
+}
@@ -3,17 +3,32 @@ package openai
 import (
 	"context"
 	"regexp"
-	"strconv"
 	"strings"
+
+	"github.com/jesee-kuya/blue/internal/queryparser"
+	"github.com/jesee-kuya/blue/internal/usage"
 )
 
 // MessageIntent represents the classified intent of a user message
 type MessageIntent struct {
-	Type        IntentType `json:"type"`
-	Product     string     `json:"product,omitempty"`
-	MinPrice    float64    `json:"min_price,omitempty"`
-	MaxPrice    float64    `json:"max_price,omitempty"`
-	Description string     `json:"description,omitempty"`
+	Type           IntentType `json:"type"`
+	Product        string     `json:"product,omitempty"`
+	Products       []string   `json:"products,omitempty"` // products named by a comparison
+	MinPrice       float64    `json:"min_price,omitempty"`
+	MaxPrice       float64    `json:"max_price,omitempty"`
+	Description    string     `json:"description,omitempty"`
+	Attributes     []string   `json:"attributes,omitempty"`
+	Currency       string     `json:"currency,omitempty"`
+	TargetAudience string     `json:"target_audience,omitempty"`
+	Channels       []string   `json:"channels,omitempty"`
+	Confidence     float64    `json:"confidence"` // 0 to 1, low values call for clarification
+
+	// Search constraints parsed from the message
+	Brand     string                `json:"brand,omitempty"`
+	Color     string                `json:"color,omitempty"`
+	Size      string                `json:"size,omitempty"`
+	Condition queryparser.Condition `json:"condition,omitempty"`
+	Sort      queryparser.Sort      `json:"sort,omitempty"`
 }
 
 // IntentType represents different types of user intents
@@ -23,15 +38,20 @@ const (
 	IntentSearch    IntentType = "search"
 	IntentMarketing IntentType = "marketing"
 	IntentCombined  IntentType = "combined"
+	IntentCompare   IntentType = "compare"
 	IntentUnknown   IntentType = "unknown"
 )
 
 // OrchestratorResponse represents the final orchestrated response
 type OrchestratorResponse struct {
-	Message       string                `json:"message"`
-	SearchResults *SearchResultsSummary `json:"search_results,omitempty"`
-	Marketing     *MarketingCopy        `json:"marketing,omitempty"`
-	Errors        []string              `json:"errors,omitempty"`
+	Message        string                `json:"message"`
+	ConversationID string                `json:"conversation_id,omitempty"`
+	Clarification  *Clarification        `json:"clarification,omitempty"`
+	SearchResults  *SearchResultsSummary `json:"search_results,omitempty"`
+	Marketing      *MarketingCopy        `json:"marketing,omitempty"`
+	Comparison     *ComparisonResult     `json:"comparison,omitempty"`
+	Usage          *usage.Usage          `json:"usage,omitempty"`
+	Errors         []string              `json:"errors,omitempty"`
 }
 
 // SearchResultsSummary represents summarized search results
@@ -39,6 +59,7 @@ type SearchResultsSummary struct {
 	Products []ProductSummary `json:"products"`
 	Count    int              `json:"count"`
 	Query    string           `json:"query"`
+	Sources  []SourceStatus   `json:"sources,omitempty"`
 }
 
 // ProductSummary represents a simplified product for responses
@@ -50,26 +71,66 @@ type ProductSummary struct {
 
 // MarketingCopy represents marketing content
 type MarketingCopy struct {
-	Headlines    []string `json:"headlines"`
-	Descriptions []string `json:"descriptions"`
-	CallToAction string   `json:"call_to_action"`
-	Segments     []string `json:"target_segments"`
+	Headlines    []string  `json:"headlines"`
+	Descriptions []string  `json:"descriptions"`
+	CallToAction string    `json:"call_to_action"`
+	Segments     []string  `json:"target_segments"`
+	Personas     []Persona `json:"personas,omitempty"`
+
+	// SegmentSource tells where the segments came from: qloo, or taxonomy, llm
+	// or default when Qloo was unavailable
+	SegmentSource string `json:"segment_source,omitempty"`
+
+	// CampaignID identifies the saved campaign when campaign history is enabled
+	CampaignID string `json:"campaign_id,omitempty"`
 }
 
-// ProcessMessage orchestrates the handling of user messages
+// ProcessMessage orchestrates the handling of user messages. It returns a
+// *usage.BudgetExceededError when the tenant has spent its model budget.
 func (c *Client) ProcessMessage(ctx context.Context, message string) (*OrchestratorResponse, error) {
-	intent := c.classifyIntent(message)
-
-	switch intent.Type {
-	case IntentSearch:
-		return c.handleSearchIntent(ctx, intent)
-	case IntentMarketing:
-		return c.handleMarketingIntent(ctx, intent)
-	case IntentCombined:
-		return c.handleCombinedIntent(ctx, intent)
+	return c.ProcessConversation(ctx, "", message)
+}
+
+// ProcessConversation handles one turn of a conversation. When the request is
+// ambiguous the response carries a clarification and a conversation ID; sending
+// the user's answer with that ID resumes the original request.
+func (c *Client) ProcessConversation(ctx context.Context, conversationID, message string) (*OrchestratorResponse, error) {
+	if err := c.checkBudget(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, meter := usage.WithMeter(ctx)
+	intent, attempts := c.resumeIntent(ctx, conversationID, message)
+
+	var response *OrchestratorResponse
+	var err error
+
+	missing := c.missingSlots(intent)
+	switch {
+	case len(missing) > 0 && attempts < MaxClarifications:
+		response = c.clarify(ctx, conversationID, intent, missing, attempts)
+	case intent.Type == IntentSearch:
+		response, err = c.handleSearchIntent(ctx, intent)
+	case intent.Type == IntentMarketing:
+		response, err = c.handleMarketingIntent(ctx, intent)
+	case intent.Type == IntentCombined:
+		response, err = c.handleCombinedIntent(ctx, intent)
+	case intent.Type == IntentCompare:
+		response, err = c.handleCompareIntent(ctx, intent)
 	default:
-		return c.handleUnknownIntent(ctx, message)
+		response, err = c.handleUnknownIntent(ctx, message)
 	}
+
+	if response != nil {
+		if response.ConversationID == "" {
+			response.ConversationID = conversationID
+		}
+		if total := meter.Total(); total.Calls > 0 {
+			response.Usage = &total
+		}
+	}
+
+	return response, err
 }
 
 // classifyIntent analyzes the user message to determine intent
@@ -78,6 +139,7 @@ func (c *Client) classifyIntent(message string) MessageIntent {
 
 	// Search patterns
 	searchPatterns := []string{
+		`(?i)^\s*(find|search|show|list|browse|look\s+for)\b`,
 		`(?i)\b(find|search|show|list|get)\b.*\b(product|item|listing)`,
 		`(?i)\b(find|search|show)\s+me\b`,
 		`(?i)\bunder\s+\$?\d+`,
@@ -87,24 +149,28 @@ func (c *Client) classifyIntent(message string) MessageIntent {
 
 	// Marketing patterns
 	marketingPatterns := []string{
-		`(?i)\b(marketing|advertis|ad|campaign|copy|promo)\b`,
-		`(?i)\b(create|generate|suggest|make).*\b(ad|marketing|copy)\b`,
+		`(?i)\b(marketing|advertis\w*|ads?|campaigns?|copy|promos?|promotion)\b`,
+		`(?i)\b(create|generate|suggest|make).*\b(ads?|marketing|copy)\b`,
 		`(?i)\btarget\s+(audience|segment)`,
 	}
 
 	// Combined patterns
 	combinedPatterns := []string{
-		`(?i)\b(find|search).*\b(and|then).*\b(marketing|ad|copy)\b`,
-		`(?i)\b(marketing|ad).*\b(for|about).*\b(find|search)\b`,
+		`(?i)\b(find|search).*\b(and|then).*\b(marketing|ads?|copy)\b`,
+		`(?i)\b(marketing|ads?).*\b(for|about).*\b(find|search)\b`,
 	}
 
 	hasSearch := c.matchesAnyPattern(message, searchPatterns)
 	hasMarketing := c.matchesAnyPattern(message, marketingPatterns)
 	hasCombined := c.matchesAnyPattern(message, combinedPatterns)
+	hasCompare := c.matchesAnyPattern(message, comparePatterns)
 
 	intent := MessageIntent{}
 
-	if hasCombined || (hasSearch && hasMarketing) {
+	if hasCompare && !hasMarketing {
+		intent.Type = IntentCompare
+		intent.Products = c.extractProducts(message)
+	} else if hasCombined || (hasSearch && hasMarketing) {
 		intent.Type = IntentCombined
 	} else if hasMarketing {
 		intent.Type = IntentMarketing
@@ -118,8 +184,20 @@ func (c *Client) classifyIntent(message string) MessageIntent {
 	intent.Product = c.extractProduct(message)
 	intent.Description = c.extractDescription(message)
 
-	// Extract price range
-	intent.MinPrice, intent.MaxPrice = c.extractPriceRange(message)
+	// Extract price range and other search constraints
+	c.applyConstraints(&intent, queryparser.Parse(message))
+
+	// Patterns are trustworthy when they found both an intent and a product
+	switch {
+	case intent.Type == IntentUnknown:
+		intent.Confidence = 0
+	case intent.Type == IntentCompare && len(intent.Products) < 2:
+		intent.Confidence = 0.5
+	case intent.Product == "":
+		intent.Confidence = 0.5
+	default:
+		intent.Confidence = 0.8
+	}
 
 	return intent
 }
@@ -134,11 +212,28 @@ func (c *Client) matchesAnyPattern(message string, patterns []string) bool {
 	return false
 }
 
+// pricePhrasePattern matches price constraints, which are parsed separately and
+// should not become part of the product query. Bare numbers only count as prices
+// next to a price keyword or currency so model numbers like "iPhone 15" survive.
+var pricePhrasePattern = func() *regexp.Regexp {
+	symbol := `(?:us\$|\$|€|£|₦|kshs?\.?|kes)`
+	number := `\d[\d,.]*k?\b`
+	unit := `(?:usd|eur|gbp|kes|dollars|bucks|euros|pounds|shillings)\b`
+	amount := symbol + `?\s*` + number + `(?:\s*` + unit + `)?`
+	rangeTail := `(?:\s*(?:-|–|to|and)\s*` + amount + `)?`
+	keyword := `\b(?:under|below|less\s+than|cheaper\s+than|over|above|more\s+than|at\s+least|at\s+most|up\s+to|max|within|between|around|about|roughly|approximately|from)\s+`
+
+	return regexp.MustCompile(`(?i)` +
+		keyword + amount + rangeTail + `|` +
+		symbol + `\s*` + number + rangeTail + `|` +
+		`\b` + number + `\s*` + unit)
+}()
+
 // extractProduct extracts product name from message
 func (c *Client) extractProduct(message string) string {
 	// Remove common prefixes and suffixes
-	cleanMessage := regexp.MustCompile(`(?i)\b(find|search|show|get|for|about|create|generate|marketing|ad|copy)\b`).ReplaceAllString(message, "")
-	cleanMessage = regexp.MustCompile(`(?i)\bunder\s+\$?\d+.*`).ReplaceAllString(cleanMessage, "")
+	cleanMessage := regexp.MustCompile(`(?i)\b(find|search|show|list|get|for|about|create|generate|marketing|ads?|copy)\b`).ReplaceAllString(message, "")
+	cleanMessage = pricePhrasePattern.ReplaceAllString(cleanMessage, "")
 	cleanMessage = strings.TrimSpace(cleanMessage)
 
 	// Extract meaningful product terms
@@ -158,11 +253,14 @@ func (c *Client) extractProduct(message string) string {
 	return ""
 }
 
+// marketingKeywordPattern matches whole marketing words, so "add", "headphones"
+// and "iPad" do not turn a message into a marketing request
+var marketingKeywordPattern = regexp.MustCompile(`(?i)\b(marketing|ads?)\b`)
+
 // extractDescription extracts product description for marketing
 func (c *Client) extractDescription(message string) string {
 	// For marketing intents, use the entire cleaned message as description
-	if strings.Contains(strings.ToLower(message), "marketing") ||
-		strings.Contains(strings.ToLower(message), "ad") {
+	if marketingKeywordPattern.MatchString(message) {
 		return c.extractProduct(message)
 	}
 	return ""
@@ -170,28 +268,48 @@ func (c *Client) extractDescription(message string) string {
 
 // extractPriceRange extracts min and max prices from message
 func (c *Client) extractPriceRange(message string) (float64, float64) {
-	var minPrice, maxPrice float64
+	constraints := queryparser.Parse(message)
+	return constraints.MinPrice, constraints.MaxPrice
+}
 
-	// Pattern: "under $X" or "less than $X"
-	underPattern := regexp.MustCompile(`(?i)\b(under|less\s+than)\s+\$?(\d+(?:\.\d{2})?)`)
-	if matches := underPattern.FindStringSubmatch(message); len(matches) > 2 {
-		if price, err := strconv.ParseFloat(matches[2], 64); err == nil {
-			maxPrice = price
-		}
+// applyConstraints fills search constraints the classifier left empty
+func (c *Client) applyConstraints(intent *MessageIntent, constraints queryparser.Query) {
+	if intent.MinPrice == 0 && intent.MaxPrice == 0 {
+		intent.MinPrice, intent.MaxPrice = constraints.MinPrice, constraints.MaxPrice
 	}
-
-	// Pattern: "between $X and $Y"
-	betweenPattern := regexp.MustCompile(`(?i)\bbetween\s+\$?(\d+(?:\.\d{2})?)\s+(?:and|to)\s+\$?(\d+(?:\.\d{2})?)`)
-	if matches := betweenPattern.FindStringSubmatch(message); len(matches) > 2 {
-		if min, err := strconv.ParseFloat(matches[1], 64); err == nil {
-			minPrice = min
-		}
-		if max, err := strconv.ParseFloat(matches[2], 64); err == nil {
-			maxPrice = max
-		}
+	if intent.Currency == "" {
+		intent.Currency = constraints.Currency
+	}
+	if intent.Brand == "" {
+		intent.Brand = constraints.Brand
 	}
+	if intent.Color == "" {
+		intent.Color = constraints.Color
+	}
+	if intent.Size == "" {
+		intent.Size = constraints.Size
+	}
+	if intent.Condition == queryparser.ConditionAny {
+		intent.Condition = constraints.Condition
+	}
+	if intent.Sort == queryparser.SortRelevance {
+		intent.Sort = constraints.Sort
+	}
+}
 
-	return minPrice, maxPrice
+// searchArguments builds the search_marketplace arguments for an intent
+func (c *Client) searchArguments(intent MessageIntent) SearchMarketplaceArgs {
+	return SearchMarketplaceArgs{
+		Query:     intent.Product,
+		MinPrice:  intent.MinPrice,
+		MaxPrice:  intent.MaxPrice,
+		Currency:  intent.Currency,
+		Brand:     intent.Brand,
+		Color:     intent.Color,
+		Size:      intent.Size,
+		Condition: intent.Condition,
+		Sort:      intent.Sort,
+	}
 }
 
 // isStopWord checks if a word should be filtered out
@@ -4,9 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/marketplace"
+	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/jesee-kuya/blue/internal/segments"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,48 +34,47 @@ func (m *MockClient) executeWithRetry(ctx context.Context, fc FunctionCall) (any
 func TestConvertSearchResults_EmptyResults(t *testing.T) {
 	client := NewClientWithKey("test-key")
 
-	result := map[string]any{
-		"products": []any{},
-		"count":    0,
-	}
-
-	summary := client.convertSearchResults(result, "test query")
+	summary := client.convertSearchResults(SearchResult{}, "test query")
 
 	assert.Equal(t, "test query", summary.Query)
 	assert.Equal(t, 0, summary.Count)
 	assert.Empty(t, summary.Products)
 }
 
-func TestConvertSearchResults_InvalidFormat(t *testing.T) {
+func TestConvertSearchResults_Products(t *testing.T) {
 	client := NewClientWithKey("test-key")
 
-	result := "invalid format"
+	result := SearchResult{
+		Products: []marketplace.Product{{Title: "Laptop", Price: 999.99, Link: "https://example.com/laptop"}},
+		Count:    1,
+	}
 
 	summary := client.convertSearchResults(result, "test query")
 
-	assert.Equal(t, "test query", summary.Query)
-	assert.Equal(t, 0, summary.Count)
-	assert.Empty(t, summary.Products)
+	assert.Equal(t, 1, summary.Count)
+	assert.Equal(t, []ProductSummary{{Title: "Laptop", Price: 999.99, Link: "https://example.com/laptop"}}, summary.Products)
 }
 
 func TestExtractSegments_EmptyResult(t *testing.T) {
 	client := NewClientWithKey("test-key")
 
-	result := map[string]any{}
-
-	segments := client.extractSegments(result)
+	segments := client.extractSegments(TasteProfileResult{})
 
 	assert.Equal(t, []string{"General Consumers"}, segments)
 }
 
-func TestExtractSegments_InvalidFormat(t *testing.T) {
+func TestExtractSegments_Names(t *testing.T) {
 	client := NewClientWithKey("test-key")
 
-	result := "invalid"
-
-	segments := client.extractSegments(result)
+	segments := client.extractSegments(TasteProfileResult{
+		Segments: []qloo.Segment{
+			{Name: "Tech Enthusiasts", AffinityScore: 0.85},
+			{Name: "", AffinityScore: 0.5},
+			{Name: "Gamers", AffinityScore: 0.78},
+		},
+	})
 
-	assert.Equal(t, []string{"General Consumers"}, segments)
+	assert.Equal(t, []string{"Tech Enthusiasts", "Gamers"}, segments)
 }
 
 func TestHandleSearchIntent_ExecutionError(t *testing.T) {
@@ -95,25 +101,15 @@ func TestHandleSearchIntent_ExecutionError(t *testing.T) {
 }
 
 func TestHandleMarketingIntent_TasteProfileFailure(t *testing.T) {
-	mockClient := &MockClient{
-		Client: NewClientWithKey("test-key"),
-	}
+	client := NewClientWithKey("test-key")
 
-	callCount := 0
-	mockClient.mockExecuteFunc = func(fc FunctionCall) (any, error) {
-		callCount++
-		switch fc.Name {
-		case "get_taste_profile":
-			return nil, errors.New("taste profile API error")
-		case "generate_ad_copy":
-			return AdCopyResult{
-				Headlines:    []string{"Default Headline"},
-				Descriptions: []string{"Default Description"},
-				CallToAction: "Buy Now!",
-			}, nil
-		}
-		return nil, nil
-	}
+	qlooCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		qlooCalls++
+		http.Error(w, `{"error": "taste profile API error"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+	client.QlooClient = qloo.NewClientWithOptions(qloo.Options{APIKey: "test-api-key", BaseURL: server.URL}, cache.NewRedisClientWithAddr(miniredis.RunT(t).Addr()))
 
 	intent := MessageIntent{
 		Type:        IntentMarketing,
@@ -122,13 +118,15 @@ func TestHandleMarketingIntent_TasteProfileFailure(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	response, err := mockClient.handleMarketingIntent(ctx, intent)
+	response, err := client.handleMarketingIntent(ctx, intent)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, response)
 	assert.NotNil(t, response.Marketing)
-	assert.Equal(t, 2, callCount)                                        // Should still call ad copy generation
-	assert.Contains(t, response.Marketing.Segments, "General Consumers") // Should use default segments
+	assert.Equal(t, 1, qlooCalls)                                              // Should not retry a rejected request
+	assert.Equal(t, segments.SourceTaxonomy, response.Marketing.SegmentSource) // Should fall back to the taxonomy
+	assert.Contains(t, response.Marketing.Segments, "Gamers")                  // Should use the category's segments
+	assert.NotEmpty(t, response.Marketing.Headlines)
 }
 
 func TestHandleCombinedIntent_PartialFailure(t *testing.T) {
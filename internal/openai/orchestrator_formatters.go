@@ -4,48 +4,18 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/jesee-kuya/blue/internal/marketplace"
+	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/jesee-kuya/blue/internal/segments"
 )
 
-// convertSearchResults converts function call results to SearchResultsSummary
-func (c *Client) convertSearchResults(result any, query string) *SearchResultsSummary {
-	resultMap, ok := result.(map[string]any)
-	if !ok {
-		return &SearchResultsSummary{Query: query, Count: 0}
-	}
-
-	productsInterface, ok := resultMap["products"]
-	if !ok {
-		return &SearchResultsSummary{Query: query, Count: 0}
-	}
-
-	var products []ProductSummary
-
-	// Handle different product formats
-	switch p := productsInterface.(type) {
-	case []marketplace.Product:
-		for _, product := range p {
-			products = append(products, ProductSummary{
-				Title: product.Title,
-				Price: product.Price,
-				Link:  product.Link,
-			})
-		}
-	case []any:
-		for _, item := range p {
-			if productMap, ok := item.(map[string]any); ok {
-				product := ProductSummary{}
-				if title, ok := productMap["title"].(string); ok {
-					product.Title = title
-				}
-				if price, ok := productMap["price"].(float64); ok {
-					product.Price = price
-				}
-				if link, ok := productMap["link"].(string); ok {
-					product.Link = link
-				}
-				products = append(products, product)
-			}
+// convertSearchResults converts search results to SearchResultsSummary
+func (c *Client) convertSearchResults(result SearchResult, query string) *SearchResultsSummary {
+	products := make([]ProductSummary, len(result.Products))
+	for i, product := range result.Products {
+		products[i] = ProductSummary{
+			Title: product.Title,
+			Price: product.Price,
+			Link:  product.Link,
 		}
 	}
 
@@ -53,79 +23,47 @@ func (c *Client) convertSearchResults(result any, query string) *SearchResultsSu
 		Products: products,
 		Count:    len(products),
 		Query:    query,
+		Sources:  result.Sources,
 	}
 }
 
-// convertMarketingResults converts function call results to MarketingCopy
-func (c *Client) convertMarketingResults(result any, segments []string) *MarketingCopy {
-	switch r := result.(type) {
-	case AdCopyResult:
-		return &MarketingCopy{
-			Headlines:    r.Headlines,
-			Descriptions: r.Descriptions,
-			CallToAction: r.CallToAction,
-			Segments:     segments,
-		}
-	case map[string]any:
-		marketing := &MarketingCopy{Segments: segments}
-
-		if headlines, ok := r["headlines"].([]string); ok {
-			marketing.Headlines = headlines
-		} else if headlinesInterface, ok := r["headlines"].([]any); ok {
-			for _, h := range headlinesInterface {
-				if headline, ok := h.(string); ok {
-					marketing.Headlines = append(marketing.Headlines, headline)
-				}
-			}
-		}
-
-		if descriptions, ok := r["descriptions"].([]string); ok {
-			marketing.Descriptions = descriptions
-		} else if descriptionsInterface, ok := r["descriptions"].([]any); ok {
-			for _, d := range descriptionsInterface {
-				if description, ok := d.(string); ok {
-					marketing.Descriptions = append(marketing.Descriptions, description)
-				}
-			}
-		}
-
-		if cta, ok := r["call_to_action"].(string); ok {
-			marketing.CallToAction = cta
-		}
-
-		return marketing
+// convertMarketingResults converts ad copy results to MarketingCopy
+func (c *Client) convertMarketingResults(result AdCopyResult, segments []string, personas []Persona) *MarketingCopy {
+	return &MarketingCopy{
+		Headlines:    result.Headlines,
+		Descriptions: result.Descriptions,
+		CallToAction: result.CallToAction,
+		Segments:     segments,
+		Personas:     personas,
 	}
-
-	return &MarketingCopy{Segments: segments}
 }
 
-// extractSegments extracts segment names from taste profile results
-func (c *Client) extractSegments(result any) []string {
-	resultMap, ok := result.(map[string]any)
-	if !ok {
-		return []string{"General Consumers"}
-	}
+// fallbackSegments are used when Qloo fails and no segmenter is configured
+var fallbackSegments = []qloo.Segment{
+	{Name: "General Consumers", AffinityScore: 0.5},
+	{Name: "Value Seekers", AffinityScore: 0.4},
+}
 
-	segmentsInterface, ok := resultMap["segments"]
-	if !ok {
-		return []string{"General Consumers"}
-	}
+// segmentSourceNote explains segments that did not come from Qloo
+func segmentSourceNote(source string) string {
+	switch source {
+	case segments.SourceTaxonomy:
+		return " _(estimated from the product category)_"
+	case segments.SourceLLM:
+		return " _(inferred by AI)_"
+	case segments.SourceDefault:
+		return " _(general audience)_"
+	}
+	return ""
+}
 
+// extractSegments extracts segment names from taste profile results
+func (c *Client) extractSegments(result TasteProfileResult) []string {
 	var segments []string
-
-	switch s := segmentsInterface.(type) {
-	case []any:
-		for _, item := range s {
-			if segmentMap, ok := item.(map[string]any); ok {
-				if name, ok := segmentMap["name"].(string); ok {
-					segments = append(segments, name)
-				}
-			} else if segmentStr, ok := item.(string); ok {
-				segments = append(segments, segmentStr)
-			}
+	for _, segment := range result.Segments {
+		if segment.Name != "" {
+			segments = append(segments, segment.Name)
 		}
-	case []string:
-		segments = s
 	}
 
 	if len(segments) == 0 {
@@ -166,7 +104,7 @@ func (c *Client) formatMarketingMessage(marketing *MarketingCopy, productName st
 	}
 
 	if len(marketing.Segments) > 0 {
-		message.WriteString(fmt.Sprintf("**Target Audience:** %s\n\n", strings.Join(marketing.Segments, ", ")))
+		message.WriteString(fmt.Sprintf("**Target Audience:** %s%s\n\n", strings.Join(marketing.Segments, ", "), segmentSourceNote(marketing.SegmentSource)))
 	}
 
 	if len(marketing.Headlines) > 0 {
@@ -220,7 +158,7 @@ func (c *Client) formatCombinedMessage(searchResults *SearchResultsSummary, mark
 		message.WriteString("## Marketing Copy\n")
 
 		if len(marketing.Segments) > 0 {
-			message.WriteString(fmt.Sprintf("**Target Audience:** %s\n\n", strings.Join(marketing.Segments, ", ")))
+			message.WriteString(fmt.Sprintf("**Target Audience:** %s%s\n\n", strings.Join(marketing.Segments, ", "), segmentSourceNote(marketing.SegmentSource)))
 		}
 
 		if len(marketing.Headlines) > 0 {
@@ -245,3 +183,45 @@ func (c *Client) formatCombinedMessage(searchResults *SearchResultsSummary, mark
 
 	return message.String()
 }
+
+// formatComparisonMessage creates a side-by-side summary of compared products
+func (c *Client) formatComparisonMessage(comparison *ComparisonResult) string {
+	var names []string
+	for _, product := range comparison.Products {
+		names = append(names, product.Product)
+	}
+
+	bestProduct, bestMarketplace, bestOffer, found := comparison.cheapestOffer()
+	if !found {
+		return fmt.Sprintf("I couldn't find any offers for %s. Try adjusting your search terms or price range.", joinProducts(names))
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("Here's how %s compare:\n\n", joinProducts(names)))
+
+	for _, product := range comparison.Products {
+		if product.OfferCount == 0 {
+			message.WriteString(fmt.Sprintf("• %s: no offers found\n", product.Product))
+			continue
+		}
+
+		var cheapest []string
+		for _, name := range offerMarketplaces([]ProductComparison{product}) {
+			cheapest = append(cheapest, fmt.Sprintf("%s $%.2f", name, product.CheapestOffers[name].Price))
+		}
+		message.WriteString(fmt.Sprintf("• %s: %d offers, $%.2f - $%.2f (cheapest: %s)\n",
+			product.Product, product.OfferCount, product.MinPrice, product.MaxPrice, strings.Join(cheapest, ", ")))
+	}
+
+	message.WriteString(fmt.Sprintf("\n**Best Price:** %s at $%.2f on %s\n", bestProduct, bestOffer.Price, bestMarketplace))
+
+	return message.String()
+}
+
+// joinProducts lists product names as "a, b and c"
+func joinProducts(products []string) string {
+	if len(products) < 2 {
+		return strings.Join(products, "")
+	}
+	return strings.Join(products[:len(products)-1], ", ") + " and " + products[len(products)-1]
+}
@@ -2,10 +2,18 @@ package openai
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"math"
 	"time"
+
+	"github.com/jesee-kuya/blue/internal/campaign"
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/jesee-kuya/blue/internal/metrics"
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/jesee-kuya/blue/internal/segments"
+	"github.com/jesee-kuya/blue/internal/tracing"
+	"github.com/jesee-kuya/blue/internal/usage"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // handleSearchIntent processes search-only requests
@@ -18,14 +26,7 @@ func (c *Client) handleSearchIntent(ctx context.Context, intent MessageIntent) (
 	}
 
 	// Execute search with retry logic
-	searchResult, err := c.executeWithRetry(ctx, FunctionCall{
-		Name: "search_marketplace",
-		Arguments: map[string]any{
-			"query":     intent.Product,
-			"min_price": intent.MinPrice,
-			"max_price": intent.MaxPrice,
-		},
-	})
+	searchResult, err := callTool[SearchMarketplaceArgs, SearchResult](ctx, c, "search_marketplace", c.searchArguments(intent))
 	if err != nil {
 		return &OrchestratorResponse{
 			Message: fmt.Sprintf("I encountered an error while searching for %s: %v", intent.Product, err),
@@ -58,29 +59,18 @@ func (c *Client) handleMarketingIntent(ctx context.Context, intent MessageIntent
 		description = intent.Product
 	}
 
-	// Get taste profile
-	tasteResult, err := c.executeWithRetry(ctx, FunctionCall{
-		Name: "get_taste_profile",
-		Arguments: map[string]any{
-			"description": description,
-		},
-	})
-
-	var segments []string
-	if err != nil {
-		log.Printf("Taste profile failed, using default segments: %v", err)
-		segments = []string{"General Consumers", "Value Seekers"}
-	} else {
-		segments = c.extractSegments(tasteResult)
+	audience := c.audienceSegments(ctx, description)
+	segmentNames := c.extractSegments(TasteProfileResult{Segments: audience.Segments})
+	var personas []Persona
+	if audience.Source != segments.SourceDefault {
+		personas = c.buildPersonas(ctx, description, audience.Segments)
 	}
 
 	// Generate ad copy
-	adResult, err := c.executeWithRetry(ctx, FunctionCall{
-		Name: "generate_ad_copy",
-		Arguments: map[string]any{
-			"product_title": intent.Product,
-			"segments":      segments,
-		},
+	adResult, err := callTool[GenerateAdCopyArgs, AdCopyResult](ctx, c, "generate_ad_copy", GenerateAdCopyArgs{
+		ProductTitle: intent.Product,
+		Segments:     segmentNames,
+		Personas:     personas,
 	})
 	if err != nil {
 		return &OrchestratorResponse{
@@ -89,7 +79,9 @@ func (c *Client) handleMarketingIntent(ctx context.Context, intent MessageIntent
 		}, nil
 	}
 
-	marketing := c.convertMarketingResults(adResult, segments)
+	marketing := c.convertMarketingResults(adResult, segmentNames, personas)
+	marketing.SegmentSource = audience.Source
+	c.saveCampaign(ctx, intent, marketing)
 	message := c.formatMarketingMessage(marketing, intent.Product)
 
 	return &OrchestratorResponse{
@@ -105,14 +97,7 @@ func (c *Client) handleCombinedIntent(ctx context.Context, intent MessageIntent)
 
 	// Step 1: Search for products
 	if intent.Product != "" {
-		searchResult, err := c.executeWithRetry(ctx, FunctionCall{
-			Name: "search_marketplace",
-			Arguments: map[string]any{
-				"query":     intent.Product,
-				"min_price": intent.MinPrice,
-				"max_price": intent.MaxPrice,
-			},
-		})
+		searchResult, err := callTool[SearchMarketplaceArgs, SearchResult](ctx, c, "search_marketplace", c.searchArguments(intent))
 
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("Search failed: %v", err))
@@ -128,35 +113,26 @@ func (c *Client) handleCombinedIntent(ctx context.Context, intent MessageIntent)
 	}
 
 	if description != "" {
-		// Get taste profile
-		tasteResult, err := c.executeWithRetry(ctx, FunctionCall{
-			Name: "get_taste_profile",
-			Arguments: map[string]any{
-				"description": description,
-			},
-		})
-
-		var segments []string
-		if err != nil {
-			log.Printf("Taste profile failed, using default segments: %v", err)
-			segments = []string{"General Consumers", "Value Seekers"}
-		} else {
-			segments = c.extractSegments(tasteResult)
+		audience := c.audienceSegments(ctx, description)
+		segmentNames := c.extractSegments(TasteProfileResult{Segments: audience.Segments})
+		var personas []Persona
+		if audience.Source != segments.SourceDefault {
+			personas = c.buildPersonas(ctx, description, audience.Segments)
 		}
 
 		// Generate ad copy
-		adResult, err := c.executeWithRetry(ctx, FunctionCall{
-			Name: "generate_ad_copy",
-			Arguments: map[string]any{
-				"product_title": intent.Product,
-				"segments":      segments,
-			},
+		adResult, err := callTool[GenerateAdCopyArgs, AdCopyResult](ctx, c, "generate_ad_copy", GenerateAdCopyArgs{
+			ProductTitle: intent.Product,
+			Segments:     segmentNames,
+			Personas:     personas,
 		})
 
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("Marketing generation failed: %v", err))
 		} else {
-			response.Marketing = c.convertMarketingResults(adResult, segments)
+			response.Marketing = c.convertMarketingResults(adResult, segmentNames, personas)
+			response.Marketing.SegmentSource = audience.Source
+			c.saveCampaign(ctx, intent, response.Marketing)
 		}
 	}
 
@@ -167,10 +143,91 @@ func (c *Client) handleCombinedIntent(ctx context.Context, intent MessageIntent)
 	return response, nil
 }
 
+// audienceSegments returns Qloo's taste profile for the description, falling
+// back to local segmentation when Qloo is not configured, fails or finds no
+// segments
+func (c *Client) audienceSegments(ctx context.Context, description string) segments.Result {
+	if c.QlooClient != nil && c.QlooClient.CheckConfig() == nil {
+		tasteResult, err := callTool[GetTasteProfileArgs, TasteProfileResult](ctx, c, "get_taste_profile", GetTasteProfileArgs{
+			Description: description,
+		})
+		switch {
+		case err != nil:
+			logging.FromContext(ctx).Warn("Taste profile failed, using local segmentation", logging.KeyError, err)
+		case len(tasteResult.Segments) == 0:
+			logging.FromContext(ctx).Info("Taste profile found no segments, using local segmentation")
+		default:
+			return segments.Result{Segments: tasteResult.Segments, Source: segments.SourceQloo}
+		}
+	}
+
+	if c.Segmenter == nil {
+		return segments.Result{Segments: fallbackSegments, Source: segments.SourceDefault}
+	}
+	return c.Segmenter.Segment(ctx, description)
+}
+
+// saveCampaign records generated marketing copy as a draft campaign. Failures
+// are logged so that the copy is still returned.
+func (c *Client) saveCampaign(ctx context.Context, intent MessageIntent, marketing *MarketingCopy) {
+	if c.Campaigns == nil {
+		return
+	}
+
+	product := intent.Product
+	if product == "" {
+		product = intent.Description
+	}
+	var channel string
+	if len(intent.Channels) > 0 {
+		channel = intent.Channels[0]
+	}
+
+	saved, err := c.Campaigns.Create(ctx, &campaign.Campaign{
+		Product:       product,
+		Segments:      marketing.Segments,
+		SegmentSource: marketing.SegmentSource,
+		Variants:      campaign.Variants(marketing.Headlines, marketing.Descriptions, marketing.CallToAction),
+		Channel:       channel,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to save campaign", logging.KeyError, err)
+		return
+	}
+	marketing.CampaignID = saved.ID
+}
+
+// handleCompareIntent searches for each product and compares the offers side by side
+func (c *Client) handleCompareIntent(ctx context.Context, intent MessageIntent) (*OrchestratorResponse, error) {
+	if len(intent.Products) < 2 {
+		return &OrchestratorResponse{
+			Message: "I need at least two products to compare. Please name the products you'd like me to compare.",
+			Errors:  []string{"Fewer than two products specified in comparison request"},
+		}, nil
+	}
+
+	comparison, err := callTool[CompareProductsArgs, ComparisonResult](ctx, c, "compare_products", c.compareArguments(intent))
+	if err != nil {
+		return &OrchestratorResponse{
+			Message: fmt.Sprintf("I encountered an error while comparing %s: %v", joinProducts(intent.Products), err),
+			Errors:  []string{err.Error()},
+		}, nil
+	}
+
+	return &OrchestratorResponse{
+		Message:    c.formatComparisonMessage(&comparison),
+		Comparison: &comparison,
+	}, nil
+}
+
 // handleUnknownIntent processes unclear requests using OpenAI
 func (c *Client) handleUnknownIntent(ctx context.Context, message string) (*OrchestratorResponse, error) {
 	// Use OpenAI to understand and respond to the message
-	aiResponse, functionCalls, err := c.SendMessage(message)
+	aiResponse, functionCalls, err := c.SendMessage(ctx, message)
+	var budgetErr *usage.BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		return nil, budgetErr
+	}
 	if err != nil {
 		return &OrchestratorResponse{
 			Message: "I'm sorry, I couldn't understand your request. Please try asking about product searches or marketing copy generation.",
@@ -183,6 +240,7 @@ func (c *Client) handleUnknownIntent(ctx context.Context, message string) (*Orch
 	}
 
 	// Execute any function calls returned by OpenAI
+	var personas []Persona
 	for _, fc := range functionCalls {
 		result, err := c.executeWithRetry(ctx, fc)
 		if err != nil {
@@ -190,50 +248,107 @@ func (c *Client) handleUnknownIntent(ctx context.Context, message string) (*Orch
 			continue
 		}
 
-		// Process results based on function type
-		switch fc.Name {
-		case "search_marketplace":
-			response.SearchResults = c.convertSearchResults(result, "")
-		case "generate_ad_copy":
-			response.Marketing = c.convertMarketingResults(result, []string{})
+		// Process results based on their type
+		switch r := result.(type) {
+		case SearchResult:
+			query, _ := fc.Arguments["query"].(string)
+			response.SearchResults = c.convertSearchResults(r, query)
+		case AdCopyResult:
+			response.Marketing = c.convertMarketingResults(r, []string{}, personas)
+		case PersonasResult:
+			personas = r.Personas
+			if response.Marketing != nil {
+				response.Marketing.Personas = personas
+			}
+		case ComparisonResult:
+			response.Comparison = &r
 		}
 	}
 
 	return response, nil
 }
 
-// executeWithRetry executes a function call with exponential backoff retry logic
+// executeWithRetry executes a model-requested function call, retrying transient
+// failures under the tool's retry policy
 func (c *Client) executeWithRetry(ctx context.Context, fc FunctionCall) (any, error) {
-	const maxRetries = 3
-	const baseDelay = 100 * time.Millisecond
-
-	var lastErr error
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		// Check context cancellation
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
+	var result any
+	err := c.retry(ctx, fc.Name, func(ctx context.Context) error {
+		var err error
+		result, err = c.ExecuteFunctionCallContext(ctx, fc)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
-		result, err := c.ExecuteFunctionCall(fc)
-		if err == nil {
-			return result, nil
-		}
+// callTool runs a registered tool with typed arguments on behalf of the tenant in
+// ctx, retrying failures like executeWithRetry
+func callTool[A, R any](ctx context.Context, c *Client, name string, args A) (R, error) {
+	var result R
+	if c.Tools == nil {
+		return result, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+	}
 
-		lastErr = err
-		if attempt < maxRetries-1 {
-			delay := time.Duration(float64(baseDelay) * math.Pow(2, float64(attempt)))
-			log.Printf("Function call %s failed (attempt %d/%d), retrying in %v: %v",
-				fc.Name, attempt+1, maxRetries, delay, err)
+	tool, err := c.Tools.Authorize(ctx, name)
+	if err != nil {
+		return result, err
+	}
+	typed, ok := tool.(*TypedTool[A, R])
+	if !ok {
+		return result, fmt.Errorf("tool %s does not take %T and return %T", name, args, result)
+	}
 
-			select {
-			case <-time.After(delay):
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			}
+	err = c.retry(ctx, name, func(ctx context.Context) error {
+		var err error
+		result, err = typed.Call(ctx, args)
+		return err
+	})
+	return result, err
+}
+
+// retry calls fn under the named tool's retry policy. Calls the tenant may not
+// make and arguments the tool rejects are never retried.
+func (c *Client) retry(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	policy := retry.DefaultPolicy
+	if c.Tools != nil {
+		policy = c.Tools.RetryPolicy(name)
+	}
+
+	ctx = logging.With(ctx, logging.KeyTool, name)
+	ctx, span := tracing.Start(ctx, "tool "+name, attribute.String("tool.name", name))
+	start := time.Now()
+	attempt := 0
+	err := policy.Do(ctx, func(ctx context.Context) error {
+		attempt++
+		ctx, attemptSpan := tracing.Start(ctx, "tool "+name+" attempt",
+			attribute.String("tool.name", name), attribute.Int("tool.attempt", attempt))
+		err := fn(ctx)
+		tracing.End(attemptSpan, err)
+		if errors.Is(err, ErrUnknownTool) || errors.Is(err, ErrToolDisabled) || errors.Is(err, ErrInvalidArguments) {
+			return retry.MarkPermanent(err)
 		}
+		return err
+	}, func(attempt int, err error, delay time.Duration) {
+		metrics.ObserveToolRetry(name)
+		logging.FromContext(ctx).Warn("Tool call failed, retrying",
+			logging.KeyAttempt, attempt, "max_attempts", max(policy.MaxAttempts, 1), "delay", delay.String(), logging.KeyError, err)
+	})
+	latency := time.Since(start)
+	metrics.ObserveToolCall(name, latency, err)
+	logger := logging.FromContext(ctx).With("attempts", attempt, logging.Latency(latency))
+	if err != nil {
+		logger.Warn("Tool call failed", logging.KeyError, err)
+	} else {
+		logger.Debug("Tool call finished")
 	}
+	span.SetAttributes(attribute.Int("tool.attempts", attempt))
+	tracing.End(span, err)
 
-	return nil, fmt.Errorf("function call %s failed after %d attempts: %w", fc.Name, maxRetries, lastErr)
+	var exhausted *retry.ExhaustedError
+	if errors.As(err, &exhausted) {
+		return fmt.Errorf("function call %s failed after %d attempts: %w", name, exhausted.Attempts, exhausted.Err)
+	}
+	return err
 }
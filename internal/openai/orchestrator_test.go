@@ -5,7 +5,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/campaign"
+	"github.com/jesee-kuya/blue/internal/tenant"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // MockClient extends Client for testing
@@ -374,3 +379,25 @@ func TestProcessMessage_Integration(t *testing.T) {
 	assert.Equal(t, 1, response.SearchResults.Count)
 	assert.Contains(t, response.Message, "gaming laptops")
 }
+
+func TestHandleMarketingIntent_SavesCampaign(t *testing.T) {
+	client, _ := newFakeClassifierClient()
+	client.QlooClient = newTasteProfileServer(t)
+	client.Campaigns = campaign.NewService(campaign.NewRedisStore(cache.NewRedisClientWithAddr(miniredis.RunT(t).Addr())))
+	ctx := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"})
+
+	response, err := client.handleMarketingIntent(ctx, MessageIntent{Type: IntentMarketing, Product: "Gaming Laptop", Channels: []string{"instagram"}})
+
+	require.NoError(t, err)
+	require.NotNil(t, response.Marketing)
+	require.NotEmpty(t, response.Marketing.CampaignID)
+
+	saved, err := client.Campaigns.Get(ctx, response.Marketing.CampaignID)
+	require.NoError(t, err)
+	assert.Equal(t, "Gaming Laptop", saved.Product)
+	assert.Equal(t, "acme", saved.TenantID)
+	assert.Equal(t, "instagram", saved.Channel)
+	assert.Equal(t, campaign.StatusDraft, saved.Status)
+	assert.Equal(t, []string{"Tech Enthusiasts"}, saved.Segments)
+	assert.Equal(t, response.Marketing.Headlines[0], saved.Variants[0].Headline)
+}
@@ -0,0 +1,277 @@
+package openai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/jesee-kuya/blue/internal/metrics"
+	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// Persona builder defaults
+const (
+	DefaultMaxPersonas     = 3
+	DefaultPersonaCacheTTL = 24 * time.Hour
+)
+
+// ErrPersonasDisabled is returned by the persona tool when the client has no persona builder
+var ErrPersonasDisabled = errors.New("persona generation is disabled")
+
+// Persona is a marketing persona built from a Qloo audience segment
+type Persona struct {
+	Name              string              `json:"name"`
+	Segment           string              `json:"segment"`
+	Demographics      PersonaDemographics `json:"demographics"`
+	Interests         []string            `json:"interests"`
+	Motivations       []string            `json:"motivations"`
+	PreferredChannels []string            `json:"preferred_channels"`
+	MessagingAngles   []string            `json:"messaging_angles"`
+}
+
+// PersonaDemographics describes who a persona is
+type PersonaDemographics struct {
+	AgeRange string `json:"age_range"`
+	Gender   string `json:"gender"`
+	Income   string `json:"income"`
+	Location string `json:"location"`
+}
+
+// PersonaBuilder turns audience segments into personas
+type PersonaBuilder interface {
+	Build(ctx context.Context, description string, segments []qloo.Segment) ([]Persona, error)
+}
+
+// personaSystemPrompt instructs the model how to fill the persona schema
+const personaSystemPrompt = `You are a marketing strategist. Turn each audience segment into one
+realistic buyer persona for the product described by the user.
+
+Give each persona a memorable first name and role (for example "Maya, the weekend
+trail runner") and set segment to the segment it was built from, unchanged. Keep
+demographics plausible for the segment and use broad ranges. List three to five
+interests, motivations, preferred marketing channels (such as instagram, tiktok,
+email or search ads) and messaging angles, each a short phrase.`
+
+// PersonaSchema returns the JSON schema for structured persona generation
+func PersonaSchema() jsonschema.Definition {
+	list := func(description string) jsonschema.Definition {
+		return jsonschema.Definition{
+			Type:        jsonschema.Array,
+			Items:       &jsonschema.Definition{Type: jsonschema.String},
+			Description: description,
+		}
+	}
+	text := func(description string) jsonschema.Definition {
+		return jsonschema.Definition{Type: jsonschema.String, Description: description}
+	}
+
+	persona := jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"name":    text("Persona name and role"),
+			"segment": text("Audience segment the persona was built from"),
+			"demographics": {
+				Type: jsonschema.Object,
+				Properties: map[string]jsonschema.Definition{
+					"age_range": text("Age range such as 25-34"),
+					"gender":    text("Predominant gender, or mixed"),
+					"income":    text("Income bracket"),
+					"location":  text("Typical location, such as urban or suburban"),
+				},
+				Required:             []string{"age_range", "gender", "income", "location"},
+				AdditionalProperties: false,
+			},
+			"interests":          list("Interests and hobbies"),
+			"motivations":        list("Reasons the persona would buy the product"),
+			"preferred_channels": list("Marketing channels that reach the persona"),
+			"messaging_angles":   list("Angles the ad copy should take"),
+		},
+		Required:             []string{"name", "segment", "demographics", "interests", "motivations", "preferred_channels", "messaging_angles"},
+		AdditionalProperties: false,
+	}
+
+	return jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"personas": {Type: jsonschema.Array, Items: &persona, Description: "One persona per segment"},
+		},
+		Required:             []string{"personas"},
+		AdditionalProperties: false,
+	}
+}
+
+// LLMPersonaBuilder builds personas for the top segments with a model using
+// structured output, caching them per product description and tenant
+type LLMPersonaBuilder struct {
+	Provider    llm.Provider
+	Model       string
+	MaxPersonas int
+	CacheTTL    time.Duration
+
+	redisClient *cache.RedisClient
+}
+
+// NewLLMPersonaBuilder creates a persona builder caching in redisClient. A nil
+// redisClient disables caching.
+func NewLLMPersonaBuilder(provider llm.Provider, model string, redisClient *cache.RedisClient) *LLMPersonaBuilder {
+	return &LLMPersonaBuilder{
+		Provider:    provider,
+		Model:       model,
+		MaxPersonas: DefaultMaxPersonas,
+		CacheTTL:    DefaultPersonaCacheTTL,
+		redisClient: redisClient,
+	}
+}
+
+// Build returns a persona for each of the highest scoring segments
+func (b *LLMPersonaBuilder) Build(ctx context.Context, description string, segments []qloo.Segment) ([]Persona, error) {
+	segments = topSegments(segments, b.MaxPersonas)
+	if description == "" || len(segments) == 0 {
+		return nil, nil
+	}
+
+	cacheKey := personaCacheKey(ctx, description, segments)
+	if b.redisClient != nil {
+		var cached []Persona
+		if err := b.redisClient.Get(ctx, cacheKey, &cached); err == nil {
+			metrics.ObserveCacheLookup("persona", true)
+			return cached, nil
+		}
+		metrics.ObserveCacheLookup("persona", false)
+	}
+
+	prompt, err := personaPrompt(description, segments)
+	if err != nil {
+		return nil, err
+	}
+
+	var output struct {
+		Personas []Persona `json:"personas"`
+	}
+	_, err = llm.ChatJSON(ctx, b.Provider, llm.ChatRequest{
+		Model: b.Model,
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: personaSystemPrompt},
+			{Role: llm.RoleUser, Content: prompt},
+		},
+		ResponseFormat: &llm.ResponseFormat{
+			Name:   "audience_personas",
+			Schema: PersonaSchema(),
+			Strict: true,
+		},
+	}, &output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build personas: %w", err)
+	}
+
+	personas := normalizePersonas(output.Personas, len(segments))
+	if len(personas) > 0 && b.redisClient != nil {
+		if err := b.redisClient.SetWithTTL(ctx, cacheKey, personas, b.CacheTTL); err != nil {
+			logging.FromContext(ctx).Warn("Failed to cache personas", logging.KeyError, err)
+		}
+	}
+	return personas, nil
+}
+
+// personaCacheKey identifies personas by tenant, description and segment
+// names, so segments from a different source are never served stale personas
+func personaCacheKey(ctx context.Context, description string, segments []qloo.Segment) string {
+	names := make([]string, len(segments))
+	for i, segment := range segments {
+		names[i] = segment.Name
+	}
+	sort.Strings(names)
+
+	sum := sha256.Sum256([]byte(description + "\n" + strings.Join(names, "\n")))
+	return tenant.ScopedKey(ctx, "persona:"+hex.EncodeToString(sum[:]))
+}
+
+// personaPrompt describes the product and segments to the model
+func personaPrompt(description string, segments []qloo.Segment) (string, error) {
+	data, err := json.Marshal(segments)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode segments: %w", err)
+	}
+	return fmt.Sprintf("Product: %s\nAudience segments with Qloo affinity scores: %s", description, data), nil
+}
+
+// topSegments returns up to n named segments with the highest affinity first
+func topSegments(segments []qloo.Segment, n int) []qloo.Segment {
+	if n <= 0 {
+		n = DefaultMaxPersonas
+	}
+
+	var named []qloo.Segment
+	for _, segment := range segments {
+		if strings.TrimSpace(segment.Name) != "" {
+			named = append(named, segment)
+		}
+	}
+	sort.SliceStable(named, func(i, j int) bool { return named[i].AffinityScore > named[j].AffinityScore })
+	if len(named) > n {
+		named = named[:n]
+	}
+	return named
+}
+
+// normalizePersonas drops unnamed personas and trims the list to one per segment
+func normalizePersonas(personas []Persona, n int) []Persona {
+	var normalized []Persona
+	for _, persona := range personas {
+		persona.Name = strings.TrimSpace(persona.Name)
+		if persona.Name == "" {
+			continue
+		}
+		persona.Segment = strings.TrimSpace(persona.Segment)
+		normalized = append(normalized, persona)
+	}
+	if len(normalized) > n {
+		normalized = normalized[:n]
+	}
+	return normalized
+}
+
+// executeBuildPersonas builds personas from the taste profile of a description
+func (c *Client) executeBuildPersonas(ctx context.Context, args BuildPersonasArgs) (PersonasResult, error) {
+	if c.Personas == nil {
+		return PersonasResult{}, retry.MarkPermanent(ErrPersonasDisabled)
+	}
+
+	taste, err := c.executeGetTasteProfile(ctx, GetTasteProfileArgs{Description: args.Description})
+	if err != nil {
+		return PersonasResult{}, err
+	}
+
+	personas, err := c.Personas.Build(ctx, args.Description, taste.Segments)
+	if err != nil {
+		return PersonasResult{}, err
+	}
+	return PersonasResult{Segments: taste.Segments, Personas: personas}, nil
+}
+
+// buildPersonas returns personas for the segments, or nil when personas are
+// disabled or cannot be built. Marketing copy is still generated without them.
+func (c *Client) buildPersonas(ctx context.Context, description string, segments []qloo.Segment) []Persona {
+	if c.Personas == nil || len(segments) == 0 {
+		return nil
+	}
+
+	personas, err := c.Personas.Build(ctx, description, segments)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Persona generation failed, continuing without personas", logging.KeyError, err)
+		return nil
+	}
+	return personas
+}
@@ -0,0 +1,199 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/jesee-kuya/blue/internal/segments"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSegments = []qloo.Segment{
+	{Name: "Casual Gamers", AffinityScore: 0.4},
+	{Name: "Tech Enthusiasts", AffinityScore: 0.85},
+	{Name: "Streamers", AffinityScore: 0.7},
+	{Name: "Early Adopters", AffinityScore: 0.72},
+}
+
+func testPersona(name, segment string) Persona {
+	return Persona{
+		Name:              name,
+		Segment:           segment,
+		Demographics:      PersonaDemographics{AgeRange: "25-34", Gender: "mixed", Income: "middle", Location: "urban"},
+		Interests:         []string{"esports"},
+		Motivations:       []string{"smooth frame rates"},
+		PreferredChannels: []string{"twitch"},
+		MessagingAngles:   []string{"Built for the late-night raid"},
+	}
+}
+
+func TestLLMPersonaBuilder_Build(t *testing.T) {
+	provider := llm.NewFakeProvider(llm.FakeJSON(map[string]any{
+		"personas": []Persona{
+			testPersona(" Sam, the spec checker ", "Tech Enthusiasts"),
+			testPersona("", "Early Adopters"),
+			testPersona("Riley, the first in line", "Early Adopters"),
+		},
+	}))
+	redisClient := cache.NewRedisClientWithAddr(miniredis.RunT(t).Addr())
+	builder := NewLLMPersonaBuilder(provider, "gpt-4o-mini", redisClient)
+	builder.MaxPersonas = 2
+	ctx := context.Background()
+
+	personas, err := builder.Build(ctx, "Gaming laptop with RGB keyboard", testSegments)
+
+	require.NoError(t, err)
+	require.Len(t, personas, 2)
+	assert.Equal(t, "Sam, the spec checker", personas[0].Name)
+	assert.Equal(t, "Riley, the first in line", personas[1].Name)
+
+	requests := provider.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "gpt-4o-mini", requests[0].Model)
+	require.NotNil(t, requests[0].ResponseFormat)
+	assert.True(t, requests[0].ResponseFormat.Strict)
+	prompt := requests[0].Messages[1].Content
+	assert.Contains(t, prompt, "Tech Enthusiasts")
+	assert.Contains(t, prompt, "Early Adopters")
+	assert.NotContains(t, prompt, "Casual Gamers")
+
+	// The same description is served from the cache, separately for each tenant
+	cached, err := builder.Build(ctx, "Gaming laptop with RGB keyboard", testSegments)
+	require.NoError(t, err)
+	assert.Equal(t, personas, cached)
+	assert.Len(t, provider.Requests(), 1)
+
+	acme := tenant.WithTenant(ctx, tenant.Tenant{ID: "acme"})
+	_, err = builder.Build(acme, "Gaming laptop with RGB keyboard", testSegments)
+	assert.ErrorIs(t, err, llm.ErrScriptExhausted)
+
+	// Different segments for the same description are not served from the cache
+	_, err = builder.Build(ctx, "Gaming laptop with RGB keyboard", []qloo.Segment{{Name: "Gamers", AffinityScore: 0.6}})
+	assert.ErrorIs(t, err, llm.ErrScriptExhausted)
+}
+
+func TestLLMPersonaBuilder_NothingToBuild(t *testing.T) {
+	provider := llm.NewFakeProvider()
+	builder := NewLLMPersonaBuilder(provider, "gpt-4o", nil)
+
+	personas, err := builder.Build(context.Background(), "Gaming laptop", []qloo.Segment{{Name: " "}})
+
+	assert.NoError(t, err)
+	assert.Nil(t, personas)
+	assert.Empty(t, provider.Requests())
+}
+
+func TestGenerateAdCopyTemplate_UsesPersonas(t *testing.T) {
+	client := NewClientWithKey("test-key")
+
+	result := client.generateAdCopyTemplate("Gaming Laptop", []string{"Tech Enthusiasts"}, testPersona("Sam", "Tech Enthusiasts"))
+
+	assert.Contains(t, result.Headlines, "Gaming Laptop: Built for the late-night raid")
+	assert.Contains(t, result.Descriptions[len(result.Descriptions)-1], "smooth frame rates")
+}
+
+type stubPersonaBuilder struct {
+	personas []Persona
+	err      error
+}
+
+func (s stubPersonaBuilder) Build(ctx context.Context, description string, segments []qloo.Segment) ([]Persona, error) {
+	return s.personas, s.err
+}
+
+// newTasteProfileServer serves a taste profile with testSegments
+func newTasteProfileServer(t *testing.T) *qloo.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "success", "segments": [{"name": "Tech Enthusiasts", "affinity_score": 0.85}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	redisClient := cache.NewRedisClientWithAddr(miniredis.RunT(t).Addr())
+	return qloo.NewClientWithOptions(qloo.Options{APIKey: "test-api-key", BaseURL: server.URL}, redisClient)
+}
+
+func TestHandleMarketingIntent_IncludesPersonas(t *testing.T) {
+	client, _ := newFakeClassifierClient()
+	client.QlooClient = newTasteProfileServer(t)
+	client.Personas = stubPersonaBuilder{personas: []Persona{testPersona("Sam", "Tech Enthusiasts")}}
+
+	response, err := client.handleMarketingIntent(context.Background(), MessageIntent{Type: IntentMarketing, Product: "Gaming Laptop"})
+
+	require.NoError(t, err)
+	require.NotNil(t, response.Marketing)
+	assert.Equal(t, []string{"Tech Enthusiasts"}, response.Marketing.Segments)
+	require.Len(t, response.Marketing.Personas, 1)
+	assert.Equal(t, "Sam", response.Marketing.Personas[0].Name)
+	assert.Contains(t, response.Marketing.Headlines, "Gaming Laptop: Built for the late-night raid")
+
+	// Marketing copy is still generated when personas fail
+	client.Personas = stubPersonaBuilder{err: errors.New("model unavailable")}
+	response, err = client.handleMarketingIntent(context.Background(), MessageIntent{Type: IntentMarketing, Product: "Gaming Laptop"})
+	require.NoError(t, err)
+	require.NotNil(t, response.Marketing)
+	assert.NotEmpty(t, response.Marketing.Headlines)
+	assert.Nil(t, response.Marketing.Personas)
+}
+
+func TestExecuteFunctionCall_BuildPersonas(t *testing.T) {
+	client := NewClientWithKey("test-key")
+	client.QlooClient = newTasteProfileServer(t)
+	client.Personas = stubPersonaBuilder{personas: []Persona{testPersona("Sam", "Tech Enthusiasts")}}
+	client.Tools = client.newToolRegistry()
+
+	result, err := client.ExecuteFunctionCall(FunctionCall{
+		Name:      "build_personas",
+		Arguments: map[string]any{"description": "Gaming laptop"},
+	})
+
+	require.NoError(t, err)
+	personas := result.(PersonasResult)
+	assert.Len(t, personas.Segments, 1)
+	assert.Equal(t, "Sam", personas.Personas[0].Name)
+
+	// Without a persona builder the model is not offered the tool
+	client.Personas = nil
+	client.Tools = client.newToolRegistry()
+	for _, definition := range client.Tools.Definitions(context.Background()) {
+		assert.NotEqual(t, "build_personas", definition.Name)
+	}
+	_, err = client.ExecuteFunctionCall(FunctionCall{
+		Name:      "build_personas",
+		Arguments: map[string]any{"description": "Gaming laptop"},
+	})
+	assert.ErrorIs(t, err, ErrUnknownTool)
+}
+
+func TestHandleMarketingIntent_FallsBackToLocalSegments(t *testing.T) {
+	client, _ := newFakeClassifierClient()
+	client.QlooClient = qloo.NewClientWithConfig("", "http://127.0.0.1:0")
+
+	response, err := client.handleMarketingIntent(context.Background(), MessageIntent{Type: IntentMarketing, Product: "Gaming Laptop"})
+
+	require.NoError(t, err)
+	require.NotNil(t, response.Marketing)
+	assert.Equal(t, segments.SourceTaxonomy, response.Marketing.SegmentSource)
+	assert.Equal(t, "Gamers", response.Marketing.Segments[0])
+	assert.Contains(t, response.Message, "estimated from the product category")
+
+	client.Segmenter = nil
+	response, err = client.handleMarketingIntent(context.Background(), MessageIntent{Type: IntentMarketing, Product: "Gaming Laptop"})
+	require.NoError(t, err)
+	assert.Equal(t, segments.SourceDefault, response.Marketing.SegmentSource)
+	assert.Equal(t, []string{"General Consumers", "Value Seekers"}, response.Marketing.Segments)
+
+	client.QlooClient = newTasteProfileServer(t)
+	response, err = client.handleMarketingIntent(context.Background(), MessageIntent{Type: IntentMarketing, Product: "Gaming Laptop"})
+	require.NoError(t, err)
+	assert.Equal(t, segments.SourceQloo, response.Marketing.SegmentSource)
+	assert.NotContains(t, response.Message, "_(")
+}
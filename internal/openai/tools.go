@@ -0,0 +1,330 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+var (
+	// ErrUnknownTool is returned when the model calls a tool that is not registered
+	ErrUnknownTool = errors.New("unknown function")
+	// ErrToolDisabled is returned when a tool is not enabled for the tenant in ctx
+	ErrToolDisabled = errors.New("tool not enabled for tenant")
+	// ErrInvalidArguments is returned when tool arguments do not match the tool's schema
+	ErrInvalidArguments = errors.New("invalid arguments")
+)
+
+// Tool is a capability the model can call by name
+type Tool interface {
+	Name() string
+	Definition() openai.FunctionDefinition
+	Execute(ctx context.Context, args map[string]any) (any, error)
+}
+
+// argsValidator is implemented by argument structs with rules the schema cannot
+// express. Validate may also normalize the arguments.
+type argsValidator interface {
+	Validate() error
+}
+
+// TypedTool is a Tool whose arguments decode into A and whose result is R. The
+// JSON schema is generated from A's json, description, enum and required tags.
+type TypedTool[A, R any] struct {
+	name        string
+	description string
+	schema      jsonschema.Definition
+	run         func(ctx context.Context, args A) (R, error)
+}
+
+// NewTool creates a typed tool. It panics if no schema can be generated for A,
+// which is a programming error like an invalid regexp in MustCompile.
+func NewTool[A, R any](name, description string, run func(ctx context.Context, args A) (R, error)) *TypedTool[A, R] {
+	var zero A
+	schema, err := jsonschema.GenerateSchemaForType(zero)
+	if err != nil {
+		panic(fmt.Sprintf("tool %s: failed to generate schema: %v", name, err))
+	}
+
+	return &TypedTool[A, R]{
+		name:        name,
+		description: description,
+		schema:      *schema,
+		run:         run,
+	}
+}
+
+// Name returns the name the model calls the tool by
+func (t *TypedTool[A, R]) Name() string {
+	return t.name
+}
+
+// Definition returns the function definition sent to the model
+func (t *TypedTool[A, R]) Definition() openai.FunctionDefinition {
+	return openai.FunctionDefinition{
+		Name:        t.name,
+		Description: t.description,
+		Parameters:  t.schema,
+	}
+}
+
+// Execute decodes and validates model-provided arguments, then runs the tool
+func (t *TypedTool[A, R]) Execute(ctx context.Context, args map[string]any) (any, error) {
+	decoded, err := decodeArguments[A](t.schema, args)
+	if err != nil {
+		return nil, err
+	}
+	return t.Call(ctx, decoded)
+}
+
+// Call validates typed arguments and runs the tool
+func (t *TypedTool[A, R]) Call(ctx context.Context, args A) (R, error) {
+	if v, ok := any(&args).(argsValidator); ok {
+		if err := v.Validate(); err != nil {
+			var zero R
+			return zero, fmt.Errorf("%w: %w", ErrInvalidArguments, err)
+		}
+	}
+	return t.run(ctx, args)
+}
+
+// decodeArguments checks arguments against the schema and decodes them into A
+func decodeArguments[A any](schema jsonschema.Definition, args map[string]any) (A, error) {
+	var decoded A
+
+	// Round-trip through JSON so arguments built in Go and arguments parsed from
+	// the model are checked the same way
+	data, err := json.Marshal(args)
+	if err != nil {
+		return decoded, fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return decoded, fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+
+	for _, name := range schema.Required {
+		if _, ok := values[name]; !ok {
+			return decoded, invalidParameter(name)
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := schema.Properties[name]
+		if !ok {
+			continue // unknown arguments are ignored
+		}
+		value := values[name]
+		if value == "" && len(property.Enum) > 0 && !slices.Contains(schema.Required, name) {
+			delete(values, name) // an empty optional enum means no preference
+			continue
+		}
+		if !jsonschema.Validate(property, value) {
+			return decoded, invalidParameter(name)
+		}
+	}
+
+	data, err = json.Marshal(values)
+	if err != nil {
+		return decoded, fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return decoded, fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+
+	return decoded, nil
+}
+
+// invalidParameter reports an argument that is missing or has the wrong type
+func invalidParameter(name string) error {
+	return fmt.Errorf("%w: missing or invalid %s parameter", ErrInvalidArguments, name)
+}
+
+// ToolPolicy selects the tools a tenant may use. An empty Enabled list allows
+// every tool that is not Disabled.
+type ToolPolicy struct {
+	Enabled  []string `json:"enabled,omitempty"`
+	Disabled []string `json:"disabled,omitempty"`
+}
+
+// Allows reports whether the policy permits the named tool
+func (p ToolPolicy) Allows(name string) bool {
+	if slices.Contains(p.Disabled, name) {
+		return false
+	}
+	return len(p.Enabled) == 0 || slices.Contains(p.Enabled, name)
+}
+
+// ToolRegistry holds the tools available to the model, which tenants may use
+// them and how failed calls are retried
+type ToolRegistry struct {
+	DefaultPolicy ToolPolicy
+	Policies      map[string]ToolPolicy // keyed by tenant ID
+
+	DefaultRetry retry.Policy
+	Retries      map[string]retry.Policy // keyed by tool name
+
+	mu    sync.RWMutex
+	tools map[string]Tool
+	order []string
+}
+
+// NewToolRegistry creates a registry with the given tools enabled for every tenant
+func NewToolRegistry(tools ...Tool) *ToolRegistry {
+	registry := &ToolRegistry{
+		Policies:     make(map[string]ToolPolicy),
+		DefaultRetry: retry.DefaultPolicy,
+		Retries:      make(map[string]retry.Policy),
+		tools:        make(map[string]Tool),
+	}
+	for _, tool := range tools {
+		registry.Register(tool)
+	}
+	return registry
+}
+
+// Register adds a tool, replacing any tool with the same name
+func (r *ToolRegistry) Register(tool Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[tool.Name()]; !exists {
+		r.order = append(r.order, tool.Name())
+	}
+	r.tools[tool.Name()] = tool
+}
+
+// Tool returns the named tool regardless of tenant policy
+func (r *ToolRegistry) Tool(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// Enabled returns the tools the tenant in ctx may use, in registration order
+func (r *ToolRegistry) Enabled(ctx context.Context) []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policy := r.policy(ctx)
+	var tools []Tool
+	for _, name := range r.order {
+		if policy.Allows(name) {
+			tools = append(tools, r.tools[name])
+		}
+	}
+	return tools
+}
+
+// Definitions returns the function definitions of the tools enabled for the tenant in ctx
+func (r *ToolRegistry) Definitions(ctx context.Context) []openai.FunctionDefinition {
+	tools := r.Enabled(ctx)
+	definitions := make([]openai.FunctionDefinition, len(tools))
+	for i, tool := range tools {
+		definitions[i] = tool.Definition()
+	}
+	return definitions
+}
+
+// Authorize returns the named tool if the tenant in ctx may use it
+func (r *ToolRegistry) Authorize(ctx context.Context, name string) (Tool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tool, ok := r.tools[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+	}
+	if !r.policy(ctx).Allows(name) {
+		return nil, fmt.Errorf("%w: %s", ErrToolDisabled, name)
+	}
+	return tool, nil
+}
+
+// Execute decodes a model-requested function call and runs it on behalf of the
+// tenant in ctx
+func (r *ToolRegistry) Execute(ctx context.Context, fc FunctionCall) (any, error) {
+	tool, err := r.Authorize(ctx, fc.Name)
+	if err != nil {
+		return nil, err
+	}
+	return tool.Execute(ctx, fc.Arguments)
+}
+
+// RetryPolicy returns the retry policy for the named tool
+func (r *ToolRegistry) RetryPolicy(name string) retry.Policy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if policy, ok := r.Retries[name]; ok {
+		return policy
+	}
+	return r.DefaultRetry
+}
+
+// policy returns the tool policy for the tenant in ctx
+func (r *ToolRegistry) policy(ctx context.Context) ToolPolicy {
+	if policy, ok := r.Policies[tenant.ID(ctx)]; ok {
+		return policy
+	}
+	return r.DefaultPolicy
+}
+
+// toolPolicyFile is the format of TOOL_POLICY_FILE
+type toolPolicyFile struct {
+	Default      ToolPolicy              `json:"default"`
+	Tenants      map[string]ToolPolicy   `json:"tenants"`
+	DefaultRetry *retry.Policy           `json:"default_retry,omitempty"`
+	Retry        map[string]retry.Policy `json:"retry,omitempty"`
+}
+
+// LoadPolicies reads the default and per-tenant tool policies from a JSON file
+// of the form {"default": {...}, "tenants": {"acme": {"enabled": [...]}}}. The
+// optional "default_retry" and per-tool "retry" entries, such as
+// {"search_marketplace": {"max_attempts": 5, "base_delay": "200ms"}}, override
+// the registry's retry policies.
+func (r *ToolRegistry) LoadPolicies(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read tool policies: %w", err)
+	}
+
+	var file toolPolicyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse tool policies: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.DefaultPolicy = file.Default
+	r.Policies = make(map[string]ToolPolicy, len(file.Tenants))
+	for id, policy := range file.Tenants {
+		r.Policies[id] = policy
+	}
+	if file.DefaultRetry != nil {
+		r.DefaultRetry = *file.DefaultRetry
+	}
+	for name, policy := range file.Retry {
+		r.Retries[name] = policy
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/metrics"
+	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/sashabaranov/go-openai/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type greetArgs struct {
+	Name   string   `json:"name" description:"Who to greet"`
+	Times  int      `json:"times" required:"false"`
+	Tone   string   `json:"tone,omitempty" enum:"formal,casual"`
+	Extras []string `json:"extras,omitempty"`
+}
+
+func (a *greetArgs) Validate() error {
+	a.Name = strings.TrimSpace(a.Name)
+	if a.Name == "" {
+		return errors.New("name must not be blank")
+	}
+	if a.Times == 0 {
+		a.Times = 1
+	}
+	return nil
+}
+
+func newGreetTool() *TypedTool[greetArgs, string] {
+	return NewTool("greet", "Greet someone", func(ctx context.Context, args greetArgs) (string, error) {
+		return strings.Repeat("hello "+args.Name+" ", args.Times) + args.Tone, nil
+	})
+}
+
+func TestGetFunctionDefinitions_GeneratedFromArgs(t *testing.T) {
+	definitions := GetFunctionDefinitions()
+
+	var names []string
+	schemas := make(map[string]jsonschema.Definition)
+	for _, definition := range definitions {
+		names = append(names, definition.Name)
+		schemas[definition.Name] = definition.Parameters.(jsonschema.Definition)
+	}
+	assert.Equal(t, []string{"search_marketplace", "compare_products", "get_taste_profile", "find_entities", "get_entity_insights", "get_audience_demographics", "generate_ad_copy"}, names)
+	assert.Equal(t, []string{"target_type"}, schemas["get_entity_insights"].Required)
+	var entityTypes []string
+	for _, entityType := range qloo.EntityTypes {
+		entityTypes = append(entityTypes, string(entityType))
+	}
+	assert.Equal(t, entityTypes, schemas["find_entities"].Properties["type"].Enum)
+	assert.Equal(t, entityTypes, schemas["get_entity_insights"].Properties["target_type"].Enum)
+
+	search := schemas["search_marketplace"]
+	assert.Equal(t, jsonschema.Object, search.Type)
+	assert.Equal(t, []string{"query"}, search.Required)
+	assert.Equal(t, jsonschema.Number, search.Properties["max_price"].Type)
+	assert.Equal(t, []string{"new", "used", "refurbished"}, search.Properties["condition"].Enum)
+	assert.Equal(t, []string{"price_asc", "price_desc", "rating", "newest"}, search.Properties["sort"].Enum)
+	assert.Equal(t, "Search query for products", search.Properties["query"].Description)
+
+	assert.Equal(t, []string{"products"}, schemas["compare_products"].Required)
+	assert.Equal(t, jsonschema.Array, schemas["compare_products"].Properties["products"].Type)
+	assert.Equal(t, []string{"product_title", "segments"}, schemas["generate_ad_copy"].Required)
+}
+
+func TestTypedTool_DecodesAndValidatesArguments(t *testing.T) {
+	registry := NewToolRegistry(newGreetTool())
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		want    string
+		wantErr string
+	}{
+		{"model arguments", map[string]any{"name": "Ada", "times": 2.0, "tone": "formal"}, "hello Ada hello Ada formal", ""},
+		{"go arguments", map[string]any{"name": " Ada ", "extras": []string{"x"}}, "hello Ada ", ""},
+		{"unknown arguments are ignored", map[string]any{"name": "Ada", "mood": "happy"}, "hello Ada ", ""},
+		{"empty optional enum", map[string]any{"name": "Ada", "tone": ""}, "hello Ada ", ""},
+		{"missing required", map[string]any{"times": 1}, "", "missing or invalid name parameter"},
+		{"wrong type", map[string]any{"name": "Ada", "times": "twice"}, "", "missing or invalid times parameter"},
+		{"fractional integer", map[string]any{"name": "Ada", "times": 1.5}, "", "missing or invalid times parameter"},
+		{"value outside enum", map[string]any{"name": "Ada", "tone": "rude"}, "", "missing or invalid tone parameter"},
+		{"failed validation", map[string]any{"name": "  "}, "", "name must not be blank"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := registry.Execute(ctx, FunctionCall{Name: "greet", Arguments: test.args})
+			if test.wantErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidArguments)
+				assert.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, result)
+		})
+	}
+}
+
+func TestToolRegistry_TenantPolicies(t *testing.T) {
+	registry := NewToolRegistry((&Client{}).builtinTools()...)
+	registry.Register(newGreetTool())
+	registry.DefaultPolicy = ToolPolicy{Disabled: []string{"greet"}}
+	registry.Policies["acme"] = ToolPolicy{Enabled: []string{"search_marketplace", "greet"}}
+
+	definitionNames := func(ctx context.Context) []string {
+		var names []string
+		for _, definition := range registry.Definitions(ctx) {
+			names = append(names, definition.Name)
+		}
+		return names
+	}
+
+	acme := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"})
+	globex := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "globex"})
+
+	assert.Equal(t, []string{"search_marketplace", "greet"}, definitionNames(acme))
+	assert.Equal(t, []string{"search_marketplace", "compare_products", "get_taste_profile", "find_entities", "get_entity_insights", "get_audience_demographics", "generate_ad_copy"}, definitionNames(globex))
+
+	_, err := registry.Execute(acme, FunctionCall{Name: "greet", Arguments: map[string]any{"name": "Ada"}})
+	assert.NoError(t, err)
+
+	_, err = registry.Execute(globex, FunctionCall{Name: "greet", Arguments: map[string]any{"name": "Ada"}})
+	assert.ErrorIs(t, err, ErrToolDisabled)
+
+	_, err = registry.Execute(acme, FunctionCall{Name: "generate_ad_copy"})
+	assert.ErrorIs(t, err, ErrToolDisabled)
+
+	_, err = registry.Execute(acme, FunctionCall{Name: "missing"})
+	assert.ErrorIs(t, err, ErrUnknownTool)
+}
+
+func TestToolRegistry_LoadPolicies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"default": {"disabled": ["generate_ad_copy"]},
+		"tenants": {"acme": {"enabled": ["search_marketplace"]}}
+	}`), 0o600))
+
+	registry := NewToolRegistry()
+	require.NoError(t, registry.LoadPolicies(path))
+
+	assert.False(t, registry.DefaultPolicy.Allows("generate_ad_copy"))
+	assert.True(t, registry.DefaultPolicy.Allows("search_marketplace"))
+	assert.True(t, registry.Policies["acme"].Allows("search_marketplace"))
+	assert.False(t, registry.Policies["acme"].Allows("get_taste_profile"))
+
+	assert.Error(t, registry.LoadPolicies(filepath.Join(t.TempDir(), "missing.json")))
+}
+
+func TestSendMessage_OffersEnabledToolsOnly(t *testing.T) {
+	client, provider := newFakeClassifierClient(llm.FakeText("Hello!"))
+	client.Tools.Policies["acme"] = ToolPolicy{Enabled: []string{"search_marketplace", "compare_products"}}
+	ctx := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"})
+
+	_, _, err := client.SendMessage(ctx, "hi")
+	require.NoError(t, err)
+
+	requests := provider.Requests()
+	require.Len(t, requests, 1)
+	var names []string
+	for _, tool := range requests[0].Tools {
+		names = append(names, tool.Name)
+	}
+	assert.Equal(t, []string{"search_marketplace", "compare_products"}, names)
+}
+
+func TestCallTool_TypedResults(t *testing.T) {
+	client, amazon := newClarifyingClient()
+	client.Tools.Register(newGreetTool())
+	client.Tools.Policies["acme"] = ToolPolicy{Disabled: []string{"search_marketplace"}}
+	ctx := context.Background()
+
+	result, err := callTool[SearchMarketplaceArgs, SearchResult](ctx, client, "search_marketplace", SearchMarketplaceArgs{Query: "headphones"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Count)
+	assert.Equal(t, []string{"headphones"}, amazon.queries)
+
+	greeting, err := callTool[greetArgs, string](ctx, client, "greet", greetArgs{Name: "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello Ada ", greeting)
+
+	_, err = callTool[greetArgs, int](ctx, client, "greet", greetArgs{Name: "Ada"})
+	assert.ErrorContains(t, err, "does not take")
+
+	_, err = callTool[SearchMarketplaceArgs, SearchResult](ctx, client, "search_marketplace", SearchMarketplaceArgs{Query: " "})
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+
+	acme := tenant.WithTenant(ctx, tenant.Tenant{ID: "acme"})
+	_, err = callTool[SearchMarketplaceArgs, SearchResult](acme, client, "search_marketplace", SearchMarketplaceArgs{Query: "headphones"})
+	assert.ErrorIs(t, err, ErrToolDisabled)
+	assert.Len(t, amazon.queries, 1)
+}
+
+func TestProcessMessage_ToolCallQueryInSummary(t *testing.T) {
+	client, _ := newClarifyingClient(
+		llm.FakeJSON(MessageIntent{Type: IntentUnknown, Confidence: 0.9}),
+		llm.FakeToolCall("search_marketplace", map[string]any{"query": "trail shoes", "max_price": 80}),
+	)
+
+	response, err := client.ProcessMessage(context.Background(), "hello there")
+
+	require.NoError(t, err)
+	require.NotNil(t, response.SearchResults)
+	assert.Equal(t, "trail shoes", response.SearchResults.Query)
+	assert.Equal(t, 2, response.SearchResults.Count)
+}
+
+func newFlakyTool(calls *int, errs ...error) *TypedTool[greetArgs, string] {
+	return NewTool("flaky", "Fail before greeting", func(ctx context.Context, args greetArgs) (string, error) {
+		*calls++
+		if *calls <= len(errs) {
+			return "", errs[*calls-1]
+		}
+		return "hello " + args.Name, nil
+	})
+}
+
+func TestCallTool_RetriesTransientErrorsOnly(t *testing.T) {
+	ctx := context.Background()
+	fast := retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   string
+	}{
+		{"transient then success", []error{&retry.StatusError{Service: "eBay", StatusCode: 503}}, 2, ""},
+		{"rate limited then success", []error{&retry.StatusError{Service: "qloo", StatusCode: 429, RetryAfter: time.Millisecond}}, 2, ""},
+		{"permanent upstream error", []error{fmt.Errorf("failed to get taste profile: %w", retry.MarkPermanent(qloo.ErrMissingAPIKey))}, 1, "failed to get taste profile: QLOO_API_KEY not set"},
+		{"client error status", []error{&retry.StatusError{Service: "qloo", StatusCode: 401}}, 1, "qloo API returned status 401"},
+		{"invalid arguments", []error{invalidParameter("query")}, 1, "invalid arguments: missing or invalid query parameter"},
+		{"exhausted", []error{errors.New("boom"), errors.New("boom"), errors.New("boom")}, 3, "function call flaky failed after 3 attempts: boom"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, _ := newClarifyingClient()
+			calls := 0
+			client.Tools.Register(newFlakyTool(&calls, test.errs...))
+			client.Tools.Retries["flaky"] = fast
+
+			result, err := callTool[greetArgs, string](ctx, client, "flaky", greetArgs{Name: "Ada"})
+
+			assert.Equal(t, test.wantCalls, calls)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "hello Ada", result)
+		})
+	}
+}
+
+func TestExecuteWithRetry_MissingQlooKeyIsNotRetried(t *testing.T) {
+	client, _ := newClarifyingClient()
+	client.QlooClient = qloo.NewClientWithConfig("", "http://127.0.0.1:0")
+
+	start := time.Now()
+	_, err := client.executeWithRetry(context.Background(), FunctionCall{
+		Name:      "get_taste_profile",
+		Arguments: map[string]any{"description": "trail running shoes"},
+	})
+
+	assert.ErrorIs(t, err, qloo.ErrMissingAPIKey)
+	assert.NotContains(t, err.Error(), "attempts")
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestToolRegistry_RetryPolicies(t *testing.T) {
+	client, _ := newClarifyingClient()
+	assert.Equal(t, retry.DefaultPolicy, client.Tools.RetryPolicy("search_marketplace"))
+	assert.Equal(t, 1, client.Tools.RetryPolicy("generate_ad_copy").MaxAttempts)
+
+	path := filepath.Join(t.TempDir(), "tools.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"default_retry": {"max_attempts": 2, "base_delay": "50ms"},
+		"retry": {"search_marketplace": {"max_attempts": 5, "base_delay": "200ms", "max_delay": "1s", "max_elapsed": "4s"}}
+	}`), 0o600))
+	require.NoError(t, client.Tools.LoadPolicies(path))
+
+	assert.Equal(t, retry.Policy{MaxAttempts: 5, BaseDelay: 200 * time.Millisecond, MaxDelay: time.Second, MaxElapsed: 4 * time.Second},
+		client.Tools.RetryPolicy("search_marketplace"))
+	assert.Equal(t, retry.Policy{MaxAttempts: 2, BaseDelay: 50 * time.Millisecond}, client.Tools.RetryPolicy("get_taste_profile"))
+	assert.Equal(t, 1, client.Tools.RetryPolicy("generate_ad_copy").MaxAttempts)
+}
+
+func TestCallTool_RecordsMetrics(t *testing.T) {
+	client, _ := newClarifyingClient()
+	calls := 0
+	tool := NewTool("metered", "Fail once before greeting", func(ctx context.Context, args greetArgs) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", &retry.StatusError{Service: "qloo", StatusCode: 503}
+		}
+		return "hello", nil
+	})
+	client.Tools.Register(tool)
+	client.Tools.Retries["metered"] = retry.Policy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+
+	_, err := callTool[greetArgs, string](context.Background(), client, "metered", greetArgs{Name: "Ada"})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	assert.Contains(t, body, `blue_tool_calls_total{outcome="success",tool="metered"} 1`)
+	assert.Contains(t, body, `blue_tool_call_retries_total{tool="metered"} 1`)
+	assert.Contains(t, body, `blue_tool_call_duration_seconds_count{tool="metered"} 1`)
+}
@@ -0,0 +1,74 @@
+package openai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that records finished spans for the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spansByName(recorder *tracetest.SpanRecorder) map[string][]sdktrace.ReadOnlySpan {
+	spans := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+	return spans
+}
+
+func TestCallTool_TracesEachAttempt(t *testing.T) {
+	recorder := recordSpans(t)
+	client, _ := newClarifyingClient()
+	calls := 0
+	client.Tools.Register(newFlakyTool(&calls, &retry.StatusError{Service: "qloo", StatusCode: 503}))
+	client.Tools.Retries["flaky"] = retry.Policy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+
+	_, err := callTool[greetArgs, string](context.Background(), client, "flaky", greetArgs{Name: "Ada"})
+	require.NoError(t, err)
+
+	spans := spansByName(recorder)
+	require.Len(t, spans["tool flaky"], 1)
+	parent := spans["tool flaky"][0]
+	assert.Contains(t, parent.Attributes(), attribute.Int("tool.attempts", 2))
+
+	attempts := spans["tool flaky attempt"]
+	require.Len(t, attempts, 2)
+	assert.Equal(t, codes.Error, attempts[0].Status().Code)
+	assert.Equal(t, codes.Unset, attempts[1].Status().Code)
+	for _, attempt := range attempts {
+		assert.Equal(t, parent.SpanContext().SpanID(), attempt.Parent().SpanID())
+	}
+}
+
+func TestProcessMessage_TracesIntentAndMarketplaces(t *testing.T) {
+	recorder := recordSpans(t)
+	client, _ := newClarifyingClient(llm.FakeJSON(MessageIntent{Type: IntentSearch, Product: "headphones", Confidence: 0.9}))
+
+	_, err := client.ProcessMessage(context.Background(), "find headphones")
+	require.NoError(t, err)
+
+	spans := spansByName(recorder)
+	require.Len(t, spans["classify intent"], 1)
+	assert.Contains(t, spans["classify intent"][0].Attributes(), attribute.String("intent.type", string(IntentSearch)))
+
+	require.Len(t, spans["marketplace search amazon"], 1)
+	assert.Contains(t, spans["marketplace search amazon"][0].Attributes(), attribute.Int("marketplace.results", 1))
+	assert.Len(t, spans["marketplace search ebay"], 1)
+}
@@ -1,5 +1,16 @@
 package openai
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jesee-kuya/blue/internal/breaker"
+	"github.com/jesee-kuya/blue/internal/marketplace"
+	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/jesee-kuya/blue/internal/queryparser"
+)
+
 // FunctionCall represents a function call request from OpenAI
 type FunctionCall struct {
 	Name      string                 `json:"name"`
@@ -15,20 +26,166 @@ type FunctionResult struct {
 
 // SearchMarketplaceArgs represents arguments for marketplace search function
 type SearchMarketplaceArgs struct {
-	Query    string  `json:"query"`
-	MinPrice float64 `json:"min_price"`
-	MaxPrice float64 `json:"max_price"`
+	Query     string                `json:"query" description:"Search query for products"`
+	MinPrice  float64               `json:"min_price" required:"false" description:"Minimum price filter (optional)"`
+	MaxPrice  float64               `json:"max_price" required:"false" description:"Maximum price filter (optional)"`
+	Currency  string                `json:"currency,omitempty" description:"ISO 4217 currency code of the price filters (optional)"`
+	Brand     string                `json:"brand,omitempty" description:"Brand to restrict results to (optional)"`
+	Color     string                `json:"color,omitempty" description:"Product color (optional)"`
+	Size      string                `json:"size,omitempty" description:"Clothing, shoe or screen size (optional)"`
+	Condition queryparser.Condition `json:"condition,omitempty" enum:"new,used,refurbished" description:"Required item condition (optional)"`
+	Sort      queryparser.Sort      `json:"sort,omitempty" enum:"price_asc,price_desc,rating,newest" description:"Result ordering (optional)"`
+}
+
+// Validate rejects searches without a query or with an impossible price range
+func (a *SearchMarketplaceArgs) Validate() error {
+	a.Query = strings.TrimSpace(a.Query)
+	if a.Query == "" {
+		return errors.New("missing or invalid query parameter")
+	}
+	return validatePriceRange(a.MinPrice, a.MaxPrice)
+}
+
+// SearchResult represents the products found by a marketplace search
+type SearchResult struct {
+	Products []marketplace.Product `json:"products"`
+	Count    int                   `json:"count"`
+	Sources  []SourceStatus        `json:"sources,omitempty"`
+}
+
+// Source statuses reported for each marketplace in a search
+const (
+	SourceOK          = "ok"
+	SourceError       = "error"
+	SourceUnavailable = "unavailable" // skipped because its circuit breaker is open
+)
+
+// SourceStatus reports how one marketplace answered a search
+type SourceStatus struct {
+	Name    string        `json:"name"`
+	Status  string        `json:"status"`
+	Count   int           `json:"count"`
+	Error   string        `json:"error,omitempty"`
+	Breaker breaker.State `json:"breaker,omitempty"`
+	Note    string        `json:"note,omitempty"`
 }
 
 // GetTasteProfileArgs represents arguments for taste profile function
 type GetTasteProfileArgs struct {
-	Description string `json:"description"`
+	Description string `json:"description" description:"Product description to analyze for audience segments"`
+}
+
+// TasteProfileResult represents the audience segments found for a description
+type TasteProfileResult struct {
+	Segments []qloo.Segment `json:"segments"`
+	Count    int            `json:"count"`
+}
+
+// BuildPersonasArgs represents arguments for the persona builder function
+type BuildPersonasArgs struct {
+	Description string `json:"description" description:"Product description to build audience personas for"`
+}
+
+// Validate rejects requests without a description
+func (a *BuildPersonasArgs) Validate() error {
+	a.Description = strings.TrimSpace(a.Description)
+	if a.Description == "" {
+		return errors.New("missing or invalid description parameter")
+	}
+	return nil
+}
+
+// PersonasResult represents the personas built from a product's taste segments
+type PersonasResult struct {
+	Segments []qloo.Segment `json:"segments"`
+	Personas []Persona      `json:"personas"`
+}
+
+// FindEntitiesArgs represents arguments for the Qloo entity search function
+type FindEntitiesArgs struct {
+	Query string          `json:"query" description:"Name of the brand, product, artist or other entity to find"`
+	Type  qloo.EntityType `json:"type,omitempty" enum:"brand,product,artist,movie,tv_show,book,podcast,videogame,place,person" description:"Kind of entity to find (optional)"`
+	Limit int             `json:"limit" required:"false" description:"Maximum number of entities to return (optional)"`
+}
+
+// Validate rejects searches without a query
+func (a *FindEntitiesArgs) Validate() error {
+	a.Query = strings.TrimSpace(a.Query)
+	if a.Query == "" {
+		return errors.New("missing or invalid query parameter")
+	}
+	return validateLimit(a.Limit)
+}
+
+// EntitiesResult represents the entities found by a Qloo entity search
+type EntitiesResult struct {
+	Entities []qloo.Entity `json:"entities"`
+	Count    int           `json:"count"`
+}
+
+// EntityInsightsArgs represents arguments for the Qloo insights function
+type EntityInsightsArgs struct {
+	Entity     string          `json:"entity" required:"false" description:"Name of the seed brand, product, artist or other entity"`
+	EntityID   string          `json:"entity_id,omitempty" description:"Qloo ID of the seed entity, when already known (optional)"`
+	EntityType qloo.EntityType `json:"entity_type,omitempty" enum:"brand,product,artist,movie,tv_show,book,podcast,videogame,place,person" description:"Kind of the seed entity, used to resolve its name (optional)"`
+	TargetType qloo.EntityType `json:"target_type" enum:"brand,product,artist,movie,tv_show,book,podcast,videogame,place,person" description:"Kind of related entities to recommend"`
+	Limit      int             `json:"limit" required:"false" description:"Maximum number of related entities to return (optional)"`
+}
+
+// Validate requires the seed entity's name or ID
+func (a *EntityInsightsArgs) Validate() error {
+	a.Entity = strings.TrimSpace(a.Entity)
+	a.EntityID = strings.TrimSpace(a.EntityID)
+	if a.Entity == "" && a.EntityID == "" {
+		return errors.New("missing entity or entity_id parameter")
+	}
+	return validateLimit(a.Limit)
+}
+
+// EntityInsightsResult represents what audiences of a seed entity also like
+type EntityInsightsResult struct {
+	Entity       qloo.Entity        `json:"entity"`
+	Related      []qloo.Entity      `json:"related"`
+	Count        int                `json:"count"`
+	Demographics *qloo.Demographics `json:"demographics,omitempty"`
+}
+
+// AudienceDemographicsArgs represents arguments for the Qloo demographics function
+type AudienceDemographicsArgs struct {
+	Entity     string          `json:"entity" required:"false" description:"Name of the brand, product, artist or other entity"`
+	EntityID   string          `json:"entity_id,omitempty" description:"Qloo ID of the entity, when already known (optional)"`
+	EntityType qloo.EntityType `json:"entity_type,omitempty" enum:"brand,product,artist,movie,tv_show,book,podcast,videogame,place,person" description:"Kind of entity, used to resolve its name (optional)"`
+}
+
+// Validate requires the entity's name or ID
+func (a *AudienceDemographicsArgs) Validate() error {
+	a.Entity = strings.TrimSpace(a.Entity)
+	a.EntityID = strings.TrimSpace(a.EntityID)
+	if a.Entity == "" && a.EntityID == "" {
+		return errors.New("missing entity or entity_id parameter")
+	}
+	return nil
+}
+
+// AudienceDemographicsResult represents the audience of an entity
+type AudienceDemographicsResult struct {
+	Entity       qloo.Entity       `json:"entity"`
+	Demographics qloo.Demographics `json:"demographics"`
 }
 
 // GenerateAdCopyArgs represents arguments for ad copy generation function
 type GenerateAdCopyArgs struct {
-	ProductTitle string   `json:"product_title"`
-	Segments     []string `json:"segments"`
+	ProductTitle string    `json:"product_title" description:"Title or name of the product"`
+	Segments     []string  `json:"segments" description:"Target audience segments for the ad copy"`
+	Personas     []Persona `json:"personas,omitempty" description:"Personas built for the segments, whose messaging angles and motivations shape the copy (optional)"`
+}
+
+// Validate rejects requests without any segment to target
+func (a *GenerateAdCopyArgs) Validate() error {
+	if len(a.Segments) == 0 {
+		return errors.New("missing or invalid segments parameter")
+	}
+	return nil
 }
 
 // AdCopyResult represents the result of ad copy generation
@@ -37,3 +194,70 @@ type AdCopyResult struct {
 	Descriptions []string `json:"descriptions"`
 	CallToAction string   `json:"call_to_action"`
 }
+
+// CompareProductsArgs represents arguments for the product comparison function
+type CompareProductsArgs struct {
+	Products  []string              `json:"products" description:"Search query for each product to compare"`
+	MinPrice  float64               `json:"min_price" required:"false" description:"Minimum price filter applied to every product (optional)"`
+	MaxPrice  float64               `json:"max_price" required:"false" description:"Maximum price filter applied to every product (optional)"`
+	Currency  string                `json:"currency,omitempty" description:"ISO 4217 currency code of the price filters (optional)"`
+	Condition queryparser.Condition `json:"condition,omitempty" enum:"new,used,refurbished" description:"Required item condition (optional)"`
+}
+
+// Validate drops blank and repeated products and requires at least two to remain
+func (a *CompareProductsArgs) Validate() error {
+	var products []string
+	for _, product := range a.Products {
+		if product = strings.TrimSpace(product); product != "" {
+			products = append(products, product)
+		}
+	}
+	a.Products = mergeProducts(nil, products)
+	if len(a.Products) < 2 {
+		return ErrTooFewProducts
+	}
+	return validatePriceRange(a.MinPrice, a.MaxPrice)
+}
+
+// ComparisonResult represents the result of comparing products across marketplaces
+type ComparisonResult struct {
+	Products   []ProductComparison `json:"products"`
+	Attributes []AttributeRow      `json:"attributes"`
+}
+
+// ProductComparison summarizes the offers found for one compared product
+type ProductComparison struct {
+	Product        string                    `json:"product"`
+	Brand          string                    `json:"brand,omitempty"`
+	OfferCount     int                       `json:"offer_count"`
+	MinPrice       float64                   `json:"min_price"`
+	MaxPrice       float64                   `json:"max_price"`
+	AveragePrice   float64                   `json:"average_price"`
+	CheapestOffers map[string]ProductSummary `json:"cheapest_offers"` // keyed by marketplace
+	Error          string                    `json:"error,omitempty"`
+}
+
+// AttributeRow is one row of a comparison table, with a value per compared product
+type AttributeRow struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// validatePriceRange rejects negative prices and a maximum below the minimum
+func validatePriceRange(minPrice, maxPrice float64) error {
+	if minPrice < 0 || maxPrice < 0 {
+		return errors.New("prices must not be negative")
+	}
+	if maxPrice > 0 && minPrice > maxPrice {
+		return fmt.Errorf("min_price %.2f is above max_price %.2f", minPrice, maxPrice)
+	}
+	return nil
+}
+
+// validateLimit rejects negative result limits
+func validateLimit(limit int) error {
+	if limit < 0 {
+		return errors.New("limit must not be negative")
+	}
+	return nil
+}
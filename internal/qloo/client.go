@@ -2,81 +2,153 @@ package qloo
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math"
 	"net/http"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/jesee-kuya/blue/internal/metrics"
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/jesee-kuya/blue/internal/tracing"
 )
 
+// ErrMissingAPIKey is returned when QLOO_API_KEY is not configured
+var ErrMissingAPIKey = errors.New("QLOO_API_KEY not set")
+
+// Defaults used for unset options
+const (
+	DefaultBaseURL     = "https://api.qloo.com/v1"
+	DefaultTimeout     = 30 * time.Second
+	DefaultCacheTTL    = 10 * time.Minute
+	DefaultMaxSegments = 10
+	DefaultMaxEntities = 20
+)
+
+// Options configures a Qloo client
+type Options struct {
+	APIKey   string
+	BaseURL  string
+	Timeout  time.Duration // per request
+	CacheTTL time.Duration // how long taste profiles are cached
+
+	MaxSegments int     // most segments returned per profile
+	MinAffinity float64 // segments scoring below this are dropped
+	MaxEntities int     // most entities returned per search or insights request
+}
+
 // Client represents a Qloo Taste AI™ API client
 type Client struct {
 	apiKey      string
 	baseURL     string
+	cacheTTL    time.Duration
+	maxSegments int
+	minAffinity float64
+	maxEntities int
 	httpClient  *http.Client
 	redisClient *cache.RedisClient
 }
 
 // NewClient creates a new Qloo client using the QLOO_API_KEY environment variable
 func NewClient() *Client {
-	apiKey := os.Getenv("QLOO_API_KEY")
-	return &Client{
-		apiKey:      apiKey,
-		baseURL:     "https://api.qloo.com/v1",
-		httpClient:  &http.Client{Timeout: 30 * time.Second},
-		redisClient: cache.NewRedisClient(),
-	}
+	return NewClientWithOptions(Options{APIKey: os.Getenv("QLOO_API_KEY")}, cache.Shared())
 }
 
 // NewClientWithConfig creates a new Qloo client with custom configuration (for testing)
 func NewClientWithConfig(apiKey, baseURL string) *Client {
+	return NewClientWithOptions(Options{APIKey: apiKey, BaseURL: baseURL}, cache.Shared())
+}
+
+// NewClientWithOptions creates a new Qloo client caching in redisClient
+func NewClientWithOptions(opts Options, redisClient *cache.RedisClient) *Client {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
+	if opts.MaxSegments <= 0 {
+		opts.MaxSegments = DefaultMaxSegments
+	}
+	if opts.MaxEntities <= 0 {
+		opts.MaxEntities = DefaultMaxEntities
+	}
 	return &Client{
-		apiKey:      apiKey,
-		baseURL:     baseURL,
-		httpClient:  &http.Client{Timeout: 30 * time.Second},
-		redisClient: cache.NewRedisClient(),
+		apiKey:      opts.APIKey,
+		baseURL:     opts.BaseURL,
+		cacheTTL:    opts.CacheTTL,
+		maxSegments: opts.MaxSegments,
+		minAffinity: opts.MinAffinity,
+		maxEntities: opts.MaxEntities,
+		httpClient:  tracing.HTTPClient(opts.Timeout),
+		redisClient: redisClient,
 	}
 }
 
-// GetTasteProfile analyzes a product description with Redis caching
-func (c *Client) GetTasteProfile(description string) ([]Segment, error) {
+// CheckConfig reports missing configuration
+func (c *Client) CheckConfig() error {
 	if c.apiKey == "" {
-		return nil, fmt.Errorf("QLOO_API_KEY not set")
+		return ErrMissingAPIKey
 	}
+	return nil
+}
 
+// GetTasteProfile analyzes a product description with Redis caching
+func (c *Client) GetTasteProfile(description string) ([]Segment, error) {
+	return c.GetTasteProfileWithContext(context.Background(), description)
+}
+
+// GetTasteProfileWithContext analyzes a product description, scoping the cache to the tenant in ctx
+func (c *Client) GetTasteProfileWithContext(ctx context.Context, description string) ([]Segment, error) {
 	if description == "" {
 		return []Segment{}, nil
 	}
 
+	if c.apiKey == "" {
+		return nil, retry.MarkPermanent(ErrMissingAPIKey)
+	}
+
 	// Generate cache key
-	cacheKey := c.generateCacheKey(description)
+	cacheKey := tenant.ScopedKey(ctx, c.generateCacheKey(description))
 
 	// Try to get from cache first
 	var cachedSegments []Segment
-	if err := c.redisClient.Get(cacheKey, &cachedSegments); err == nil {
+	if err := c.redisClient.Get(ctx, cacheKey, &cachedSegments); err == nil {
+		metrics.ObserveCacheLookup("qloo", true)
 		return cachedSegments, nil
 	}
+	metrics.ObserveCacheLookup("qloo", false)
 
 	// Cache miss - fetch fresh data
-	segments, err := c.fetchTasteProfile(description)
+	segments, err := c.fetchTasteProfile(ctx, description)
 	if err != nil {
 		return nil, err
 	}
 
-	// Cache the results for 10 minutes
-	c.redisClient.SetWithTTL(cacheKey, segments, 10*time.Minute)
+	c.redisClient.SetWithTTL(ctx, cacheKey, segments, c.cacheTTL)
 
 	return segments, nil
 }
 
-// fetchTasteProfile performs the actual API call
-func (c *Client) fetchTasteProfile(description string) ([]Segment, error) {
+// fetchTasteProfile performs the actual API call. Error responses become
+// APIErrors and the segments are validated before they are returned.
+func (c *Client) fetchTasteProfile(ctx context.Context, description string) ([]Segment, error) {
 	request := TasteProfileRequest{Description: description}
-	request.Options.MaxSegments = 10
+	request.Options.MaxSegments = c.maxSegments
 
 	payload, err := json.Marshal(request)
 	if err != nil {
@@ -84,9 +156,9 @@ func (c *Client) fetchTasteProfile(description string) ([]Segment, error) {
 	}
 
 	reqURL := fmt.Sprintf("%s/taste/profile", c.baseURL)
-	req, err := http.NewRequest("POST", reqURL, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewBuffer(payload))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, retry.MarkPermanent(fmt.Errorf("failed to create request: %w", err))
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
@@ -99,7 +171,7 @@ func (c *Client) fetchTasteProfile(description string) ([]Segment, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("qloo API returned status %d", resp.StatusCode)
+		return nil, newStatusAPIError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -109,14 +181,61 @@ func (c *Client) fetchTasteProfile(description string) ([]Segment, error) {
 
 	var response TasteProfileResponse
 	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, retry.MarkPermanent(fmt.Errorf("failed to parse response: %w", err))
 	}
 
-	return response.Segments, nil
+	if err := statusError(response.Status, response.Message); err != nil {
+		return nil, err
+	}
+
+	return c.filterSegments(ctx, response.Segments)
+}
+
+// filterSegments drops malformed segments and those below the minimum
+// affinity, then keeps the highest scoring up to the maximum. A response whose
+// segments are all malformed is an error rather than an empty profile.
+func (c *Client) filterSegments(ctx context.Context, segments []Segment) ([]Segment, error) {
+	logger := logging.FromContext(ctx)
+
+	valid := make([]Segment, 0, len(segments))
+	invalid := 0
+	for _, segment := range segments {
+		if err := validateSegment(segment); err != nil {
+			logger.Warn("dropping invalid Qloo segment", slog.String("segment", segment.Name), slog.Any(logging.KeyError, err))
+			invalid++
+			continue
+		}
+		if segment.AffinityScore < c.minAffinity {
+			continue
+		}
+		valid = append(valid, segment)
+	}
+
+	if invalid > 0 && invalid == len(segments) {
+		return nil, validationError("all %d segments are invalid", invalid)
+	}
+
+	sort.SliceStable(valid, func(i, j int) bool { return valid[i].AffinityScore > valid[j].AffinityScore })
+	if len(valid) > c.maxSegments {
+		valid = valid[:c.maxSegments]
+	}
+	return valid, nil
+}
+
+// validateSegment checks a segment has a name and an affinity score in [0, 1]
+func validateSegment(segment Segment) error {
+	if strings.TrimSpace(segment.Name) == "" {
+		return errors.New("empty segment name")
+	}
+	if math.IsNaN(segment.AffinityScore) || segment.AffinityScore < 0 || segment.AffinityScore > 1 {
+		return fmt.Errorf("affinity score %v out of range", segment.AffinityScore)
+	}
+	return nil
 }
 
-// generateCacheKey creates a cache key for the description
+// generateCacheKey creates a cache key for the description. The segment
+// options are part of the key since they change the cached result.
 func (c *Client) generateCacheKey(description string) string {
 	hash := fmt.Sprintf("%x", md5.Sum([]byte(description)))
-	return fmt.Sprintf("qloo:profile:%s", hash)
+	return fmt.Sprintf("qloo:profile:%d:%g:%s", c.maxSegments, c.minAffinity, hash)
 }
@@ -1,12 +1,17 @@
 package qloo
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/retry"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestQlooClient_GetTasteProfile_Success(t *testing.T) {
@@ -73,6 +78,101 @@ func TestQlooClient_GetTasteProfile_NoAPIKey(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, segments)
 	assert.Contains(t, err.Error(), "QLOO_API_KEY not set")
+	assert.ErrorIs(t, err, ErrMissingAPIKey)
+	assert.True(t, retry.IsPermanent(err))
+}
+
+func TestQlooClient_GetTasteProfile_RateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClientWithConfig("test-api-key", server.URL)
+
+	_, err := client.GetTasteProfile("rate limited description")
+
+	var status *retry.StatusError
+	assert.ErrorAs(t, err, &status)
+	assert.Equal(t, http.StatusTooManyRequests, status.StatusCode)
+
+	class, retryAfter := retry.Classify(err)
+	assert.Equal(t, retry.RateLimited, class)
+	assert.Equal(t, 7*time.Second, retryAfter)
+	assert.ErrorIs(t, err, ErrQuota)
+}
+
+func TestQlooClient_GetTasteProfile_ErrorKinds(t *testing.T) {
+	tests := []struct {
+		status int
+		kind   error
+	}{
+		{http.StatusForbidden, ErrAuth},
+		{http.StatusUnprocessableEntity, ErrValidation},
+		{http.StatusBadGateway, ErrServer},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(`upstream said no`))
+		}))
+
+		client := NewClientWithConfig("test-api-key", server.URL)
+		_, err := client.GetTasteProfile("error kinds " + http.StatusText(tt.status))
+		server.Close()
+
+		assert.ErrorIs(t, err, tt.kind, "status %d", tt.status)
+		assert.Contains(t, err.Error(), "upstream said no")
+	}
+}
+
+func TestQlooClient_GetTasteProfile_ValidatesAndLimitsSegments(t *testing.T) {
+	mockResponse := `{
+        "status": "success",
+        "segments": [
+            {"name": "Casual Gamers", "affinity_score": 0.45},
+            {"name": "", "affinity_score": 0.9},
+            {"name": "Tech Enthusiasts", "affinity_score": 0.85},
+            {"name": "Overflow", "affinity_score": 1.7},
+            {"name": "Early Adopters", "affinity_score": 0.72},
+            {"name": "Bargain Hunters", "affinity_score": 0.2}
+        ]
+    }`
+
+	var requested TasteProfileRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&requested)
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(Options{APIKey: "test-api-key", BaseURL: server.URL, MaxSegments: 2, MinAffinity: 0.3}, cache.Shared())
+
+	segments, err := client.GetTasteProfile("segment limits")
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, requested.Options.MaxSegments)
+	assert.Equal(t, []Segment{
+		{Name: "Tech Enthusiasts", AffinityScore: 0.85},
+		{Name: "Early Adopters", AffinityScore: 0.72},
+	}, segments)
+}
+
+func TestQlooClient_GetTasteProfile_AllSegmentsInvalid(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "success", "segments": [{"name": " ", "affinity_score": 0.5}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithConfig("test-api-key", server.URL)
+
+	segments, err := client.GetTasteProfile("all invalid")
+
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+	assert.True(t, retry.IsPermanent(err))
+	assert.Nil(t, segments)
 }
 
 func TestQlooClient_GetTasteProfile_APIError(t *testing.T) {
@@ -83,14 +183,16 @@ func TestQlooClient_GetTasteProfile_APIError(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient()
-	client.baseURL = server.URL
+	client := NewClientWithConfig("test-api-key", server.URL)
 
 	segments, err := client.GetTasteProfile("test description")
 
 	assert.Error(t, err)
 	assert.Nil(t, segments)
 	assert.Contains(t, err.Error(), "Qloo API returned status 401")
+	assert.Contains(t, err.Error(), "Invalid API key")
+	assert.ErrorIs(t, err, ErrAuth)
+	assert.True(t, retry.IsPermanent(err))
 }
 
 func TestQlooClient_GetTasteProfile_InvalidJSON(t *testing.T) {
@@ -102,8 +204,7 @@ func TestQlooClient_GetTasteProfile_InvalidJSON(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient()
-	client.baseURL = server.URL
+	client := NewClientWithConfig("test-api-key", server.URL)
 
 	segments, err := client.GetTasteProfile("test description")
 
@@ -127,14 +228,15 @@ func TestQlooClient_GetTasteProfile_APILevelError(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient()
-	client.baseURL = server.URL
+	client := NewClientWithConfig("test-api-key", server.URL)
 
 	segments, err := client.GetTasteProfile("short")
 
 	assert.Error(t, err)
 	assert.Nil(t, segments)
 	assert.Contains(t, err.Error(), "Description too short")
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.True(t, retry.IsPermanent(err))
 }
 
 func TestQlooClient_GetTasteProfile_NoSegments(t *testing.T) {
@@ -151,8 +253,7 @@ func TestQlooClient_GetTasteProfile_NoSegments(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient()
-	client.baseURL = server.URL
+	client := NewClientWithConfig("test-api-key", server.URL)
 
 	segments, err := client.GetTasteProfile("generic product")
 
@@ -0,0 +1,120 @@
+package qloo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jesee-kuya/blue/internal/retry"
+)
+
+// serviceName prefixes errors reported for Qloo responses
+const serviceName = "Qloo"
+
+// Sentinel errors matched by APIError according to its kind
+var (
+	ErrAuth            = errors.New("Qloo rejected the API key")
+	ErrQuota           = errors.New("Qloo quota or rate limit exceeded")
+	ErrValidation      = errors.New("Qloo rejected the request")
+	ErrServer          = errors.New("Qloo server error")
+	ErrInvalidResponse = errors.New("invalid Qloo response")
+)
+
+// APIError is an error reported by the Qloo API, either through the HTTP status
+// or through the status and message fields of a successful response. Status
+// errors wrap a retry.StatusError so quota responses honour Retry-After and
+// other statuses retry by class.
+type APIError struct {
+	Kind    error // one of ErrAuth, ErrQuota, ErrValidation or ErrServer
+	Message string
+	Status  *retry.StatusError // nil when the API reported the error in a 200 response
+}
+
+func (e *APIError) Error() string {
+	prefix := serviceName + " API error"
+	if e.Status != nil {
+		prefix = e.Status.Error()
+	}
+	if e.Message == "" {
+		return prefix
+	}
+	return prefix + ": " + e.Message
+}
+
+// Is matches the sentinel for the error's kind
+func (e *APIError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *APIError) Unwrap() error {
+	if e.Status == nil {
+		return nil
+	}
+	return e.Status
+}
+
+// errorBody is the shape of Qloo error responses
+type errorBody struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+// newStatusAPIError builds the error for a non-200 response, reading the
+// message from its body when there is one
+func newStatusAPIError(resp *http.Response) error {
+	apiErr := &APIError{
+		Kind:    kindForStatus(resp.StatusCode),
+		Message: readErrorMessage(resp.Body),
+		Status:  retry.NewStatusError(serviceName, resp),
+	}
+	return apiErr
+}
+
+// kindForStatus maps an HTTP status to the kind of API error it signals
+func kindForStatus(code int) error {
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return ErrAuth
+	case code == http.StatusTooManyRequests || code == http.StatusPaymentRequired:
+		return ErrQuota
+	case code >= http.StatusInternalServerError:
+		return ErrServer
+	default:
+		return ErrValidation
+	}
+}
+
+// readErrorMessage extracts the message from an error body, falling back to a
+// short excerpt of the raw body
+func readErrorMessage(body io.Reader) string {
+	data, err := io.ReadAll(io.LimitReader(body, 4096))
+	if err != nil || len(data) == 0 {
+		return ""
+	}
+
+	var parsed errorBody
+	if json.Unmarshal(data, &parsed) == nil {
+		if parsed.Message != "" {
+			return parsed.Message
+		}
+		if parsed.Error != "" {
+			return parsed.Error
+		}
+	}
+
+	text := strings.TrimSpace(string(data))
+	if len(text) > 200 {
+		text = text[:200] + "..."
+	}
+	return text
+}
+
+// validationError reports a response that failed validation. Retrying will not
+// change what the API returns, so it is permanent.
+func validationError(format string, args ...any) error {
+	return retry.MarkPermanent(fmt.Errorf("%w: %s", ErrInvalidResponse, fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,241 @@
+package qloo
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/jesee-kuya/blue/internal/metrics"
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/jesee-kuya/blue/internal/tenant"
+)
+
+// demographicsType is the insights filter that returns audience demographics
+const demographicsType = "urn:demographics"
+
+// ErrEntityNotFound is returned when an entity search finds nothing to resolve a name to
+var ErrEntityNotFound = errors.New("no matching Qloo entity")
+
+// SearchEntities finds entities whose name matches query. An empty entityType
+// searches every type. At most limit entities are returned, or the client's
+// maximum when limit is zero.
+func (c *Client) SearchEntities(ctx context.Context, query string, entityType EntityType, limit int) ([]Entity, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, retry.MarkPermanent(fmt.Errorf("%w: empty search query", ErrValidation))
+	}
+	if entityType != "" && !entityType.Valid() {
+		return nil, retry.MarkPermanent(fmt.Errorf("%w: unknown entity type %q", ErrValidation, entityType))
+	}
+
+	params := url.Values{"query": {query}, "take": {strconv.Itoa(c.limit(limit))}}
+	if entityType != "" {
+		params.Set("types", entityType.URN())
+	}
+
+	var response searchResponse
+	if err := c.cachedGet(ctx, "search", params, &response); err != nil {
+		return nil, err
+	}
+	return c.convertEntities(ctx, response.Results, limit), nil
+}
+
+// ResolveEntity returns the best match for name, preferring entities of the
+// given type when one is set
+func (c *Client) ResolveEntity(ctx context.Context, name string, entityType EntityType) (Entity, error) {
+	entities, err := c.SearchEntities(ctx, name, entityType, 1)
+	if err != nil {
+		return Entity{}, err
+	}
+	if len(entities) == 0 {
+		return Entity{}, retry.MarkPermanent(fmt.Errorf("%w: %q", ErrEntityNotFound, name))
+	}
+	return entities[0], nil
+}
+
+// GetInsights returns entities of the requested type that audiences of the
+// seed entities also like, with the highest affinity first
+func (c *Client) GetInsights(ctx context.Context, request InsightsRequest) ([]Entity, error) {
+	if len(request.EntityIDs) == 0 {
+		return nil, retry.MarkPermanent(fmt.Errorf("%w: no seed entities", ErrValidation))
+	}
+	if !request.Type.Valid() {
+		return nil, retry.MarkPermanent(fmt.Errorf("%w: unknown entity type %q", ErrValidation, request.Type))
+	}
+
+	params := url.Values{
+		"filter.type":               {request.Type.URN()},
+		"signal.interests.entities": {strings.Join(request.EntityIDs, ",")},
+		"take":                      {strconv.Itoa(c.limit(request.Limit))},
+	}
+
+	var response insightsResponse
+	if err := c.cachedGet(ctx, "insights", params, &response); err != nil {
+		return nil, err
+	}
+
+	entities := c.convertEntities(ctx, response.Results.Entities, request.Limit)
+	for i := range entities {
+		if entities[i].Type == "" {
+			entities[i].Type = request.Type
+		}
+	}
+	return entities, nil
+}
+
+// GetDemographics returns the age and gender affinities of each entity's audience
+func (c *Client) GetDemographics(ctx context.Context, entityIDs ...string) ([]Demographics, error) {
+	if len(entityIDs) == 0 {
+		return nil, retry.MarkPermanent(fmt.Errorf("%w: no entities", ErrValidation))
+	}
+
+	params := url.Values{
+		"filter.type":               {demographicsType},
+		"signal.interests.entities": {strings.Join(entityIDs, ",")},
+	}
+
+	var response insightsResponse
+	if err := c.cachedGet(ctx, "insights", params, &response); err != nil {
+		return nil, err
+	}
+
+	demographics := make([]Demographics, 0, len(response.Results.Demographics))
+	for _, result := range response.Results.Demographics {
+		if result.EntityID == "" {
+			continue
+		}
+		demographics = append(demographics, Demographics{
+			EntityID: result.EntityID,
+			Age:      result.Query.Age,
+			Gender:   result.Query.Gender,
+		})
+	}
+	return demographics, nil
+}
+
+// limit returns the number of entities to request, capped at the client's maximum
+func (c *Client) limit(requested int) int {
+	if requested <= 0 || requested > c.maxEntities {
+		return c.maxEntities
+	}
+	return requested
+}
+
+// convertEntities drops entities without an ID or name and those with an
+// affinity outside [0, 1], keeping at most limit
+func (c *Client) convertEntities(ctx context.Context, results []apiEntity, limit int) []Entity {
+	entities := make([]Entity, 0, len(results))
+	for _, result := range results {
+		if result.EntityID == "" || strings.TrimSpace(result.Name) == "" || result.Query.Affinity < 0 || result.Query.Affinity > 1 {
+			logging.FromContext(ctx).Warn("dropping invalid Qloo entity", slog.String("entity", result.EntityID), slog.String("name", result.Name))
+			continue
+		}
+
+		entity := Entity{
+			ID:         result.EntityID,
+			Name:       result.Name,
+			Popularity: result.Popularity,
+			Affinity:   result.Query.Affinity,
+		}
+		if len(result.Types) > 0 {
+			entity.Type = entityTypeFromURN(result.Types[0])
+		}
+		for _, tag := range result.Tags {
+			if tag.Name != "" {
+				entity.Tags = append(entity.Tags, tag.Name)
+			}
+		}
+		entities = append(entities, entity)
+	}
+
+	if n := c.limit(limit); len(entities) > n {
+		entities = entities[:n]
+	}
+	return entities
+}
+
+// apiResponse is implemented by response bodies that can report an error in a
+// successful response
+type apiResponse interface {
+	apiError() error
+}
+
+func (r *searchResponse) apiError() error {
+	return statusError(r.Status, r.Message)
+}
+
+func (r *insightsResponse) apiError() error {
+	return statusError(r.Status, r.Message)
+}
+
+// statusError returns the error reported by a response's status field, if any
+func statusError(status, message string) error {
+	if status != "error" {
+		return nil
+	}
+	return retry.MarkPermanent(&APIError{Kind: ErrValidation, Message: message})
+}
+
+// cachedGet fetches path with params into out, caching the decoded body per
+// tenant. Responses reporting an error are returned as errors and not cached.
+func (c *Client) cachedGet(ctx context.Context, path string, params url.Values, out apiResponse) error {
+	if c.apiKey == "" {
+		return retry.MarkPermanent(ErrMissingAPIKey)
+	}
+
+	query := params.Encode()
+	cacheKey := tenant.ScopedKey(ctx, fmt.Sprintf("qloo:%s:%x", path, md5.Sum([]byte(query))))
+	if err := c.redisClient.Get(ctx, cacheKey, out); err == nil {
+		metrics.ObserveCacheLookup("qloo", true)
+		return nil
+	}
+	metrics.ObserveCacheLookup("qloo", false)
+
+	if err := c.get(ctx, path, query, out); err != nil {
+		return err
+	}
+	if err := out.apiError(); err != nil {
+		return err
+	}
+	c.redisClient.SetWithTTL(ctx, cacheKey, out, c.cacheTTL)
+	return nil
+}
+
+// get performs a GET request and decodes the JSON response into out
+func (c *Client) get(ctx context.Context, path, query string, out any) error {
+	reqURL := fmt.Sprintf("%s/%s?%s", c.baseURL, path, query)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return retry.MarkPermanent(fmt.Errorf("failed to create request: %w", err))
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newStatusAPIError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return retry.MarkPermanent(fmt.Errorf("failed to parse response: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package qloo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newInsightsTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	redisClient := cache.NewRedisClientWithAddr(miniredis.RunT(t).Addr())
+	return NewClientWithOptions(Options{APIKey: "test-api-key", BaseURL: server.URL, MaxEntities: 5}, redisClient)
+}
+
+func TestSearchEntities(t *testing.T) {
+	calls := 0
+	client := newInsightsTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/search", r.URL.Path)
+		assert.Equal(t, "Bearer test-api-key", r.Header.Get("Authorization"))
+		assert.Equal(t, "Nike", r.URL.Query().Get("query"))
+		assert.Equal(t, "urn:entity:brand", r.URL.Query().Get("types"))
+		assert.Contains(t, []string{"5", "1"}, r.URL.Query().Get("take"))
+
+		w.Write([]byte(`{"results": [
+			{"entity_id": "B1", "name": "Nike", "types": ["urn:entity:brand"], "popularity": 0.99, "tags": [{"name": "sportswear"}]},
+			{"entity_id": "", "name": "No ID"},
+			{"entity_id": "B2", "name": "Nike SB", "types": ["urn:entity:brand"], "popularity": 0.8}
+		]}`))
+	})
+
+	entities, err := client.SearchEntities(context.Background(), " Nike ", EntityBrand, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []Entity{
+		{ID: "B1", Name: "Nike", Type: EntityBrand, Popularity: 0.99, Tags: []string{"sportswear"}},
+		{ID: "B2", Name: "Nike SB", Type: EntityBrand, Popularity: 0.8},
+	}, entities)
+
+	// Repeated searches are served from the cache
+	_, err = client.SearchEntities(context.Background(), "Nike", EntityBrand, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	entity, err := client.ResolveEntity(context.Background(), "Nike", EntityBrand)
+	require.NoError(t, err)
+	assert.Equal(t, "B1", entity.ID)
+	assert.Equal(t, 2, calls)
+}
+
+func TestSearchEntities_LimitIndependentOfSegments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "20", r.URL.Query().Get("take"))
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer server.Close()
+
+	redisClient := cache.NewRedisClientWithAddr(miniredis.RunT(t).Addr())
+	client := NewClientWithOptions(Options{APIKey: "test-api-key", BaseURL: server.URL, MaxSegments: 2}, redisClient)
+
+	_, err := client.SearchEntities(context.Background(), "Nike", "", 0)
+	require.NoError(t, err)
+	_, err = client.SearchEntities(context.Background(), "Adidas", "", 50)
+	require.NoError(t, err)
+}
+
+func TestSearchEntities_InvalidArguments(t *testing.T) {
+	client := newInsightsTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request expected")
+	})
+
+	_, err := client.SearchEntities(context.Background(), " ", "", 0)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = client.SearchEntities(context.Background(), "Nike", "sneaker", 0)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.True(t, retry.IsPermanent(err))
+}
+
+func TestResolveEntity_NotFound(t *testing.T) {
+	client := newInsightsTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": []}`))
+	})
+
+	_, err := client.ResolveEntity(context.Background(), "Nothing Like It", "")
+	assert.ErrorIs(t, err, ErrEntityNotFound)
+	assert.True(t, retry.IsPermanent(err))
+}
+
+func TestGetInsights(t *testing.T) {
+	client := newInsightsTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/insights", r.URL.Path)
+		assert.Equal(t, "urn:entity:artist", r.URL.Query().Get("filter.type"))
+		assert.Equal(t, "B1,B2", r.URL.Query().Get("signal.interests.entities"))
+		assert.Equal(t, "2", r.URL.Query().Get("take"))
+
+		w.Write([]byte(`{"results": {"entities": [
+			{"entity_id": "A1", "name": "Kendrick Lamar", "query": {"affinity": 0.91}},
+			{"entity_id": "A2", "name": "Out Of Range", "query": {"affinity": 3}},
+			{"entity_id": "A3", "name": "Travis Scott", "types": ["urn:entity:artist"], "query": {"affinity": 0.87}},
+			{"entity_id": "A4", "name": "Over The Limit", "query": {"affinity": 0.5}}
+		]}}`))
+	})
+
+	entities, err := client.GetInsights(context.Background(), InsightsRequest{EntityIDs: []string{"B1", "B2"}, Type: EntityArtist, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []Entity{
+		{ID: "A1", Name: "Kendrick Lamar", Type: EntityArtist, Affinity: 0.91},
+		{ID: "A3", Name: "Travis Scott", Type: EntityArtist, Affinity: 0.87},
+	}, entities)
+
+	_, err = client.GetInsights(context.Background(), InsightsRequest{Type: EntityArtist})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestGetDemographics(t *testing.T) {
+	client := newInsightsTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "urn:demographics", r.URL.Query().Get("filter.type"))
+		w.Write([]byte(`{"results": {"demographics": [
+			{"entity_id": "B1", "query": {"age": {"24_and_younger": 0.3, "55_and_older": -0.4}, "gender": {"male": 0.1, "female": -0.1}}}
+		]}}`))
+	})
+
+	demographics, err := client.GetDemographics(context.Background(), "B1")
+	require.NoError(t, err)
+	require.Len(t, demographics, 1)
+	assert.Equal(t, "B1", demographics[0].EntityID)
+	assert.Equal(t, 0.3, demographics[0].Age["24_and_younger"])
+	assert.Equal(t, -0.1, demographics[0].Gender["female"])
+}
+
+func TestInsights_ErrorsAreNotCached(t *testing.T) {
+	calls := 0
+	client := newInsightsTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Write([]byte(`{"status": "error", "message": "unknown entity"}`))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := client.GetDemographics(context.Background(), "B1")
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Contains(t, err.Error(), "unknown entity")
+
+	_, err = client.GetDemographics(context.Background(), "B1")
+	assert.ErrorIs(t, err, ErrServer)
+	assert.Equal(t, 2, calls)
+}
@@ -1,5 +1,10 @@
 package qloo
 
+import (
+	"slices"
+	"strings"
+)
+
 // Segment represents an audience segment with affinity score from Qloo's Taste AI™
 type Segment struct {
 	Name          string  `json:"name"`
@@ -20,3 +25,104 @@ type TasteProfileResponse struct {
 	Status   string    `json:"status"`
 	Message  string    `json:"message,omitempty"`
 }
+
+// EntityType is a kind of entity in Qloo's catalogue, such as a brand or an artist
+type EntityType string
+
+// Entity types accepted by entity search and insights
+const (
+	EntityBrand     EntityType = "brand"
+	EntityProduct   EntityType = "product"
+	EntityArtist    EntityType = "artist"
+	EntityMovie     EntityType = "movie"
+	EntityTVShow    EntityType = "tv_show"
+	EntityBook      EntityType = "book"
+	EntityPodcast   EntityType = "podcast"
+	EntityVideoGame EntityType = "videogame"
+	EntityPlace     EntityType = "place"
+	EntityPerson    EntityType = "person"
+)
+
+// EntityTypes lists every entity type, in the order they are documented to the model
+var EntityTypes = []EntityType{
+	EntityBrand, EntityProduct, EntityArtist, EntityMovie, EntityTVShow,
+	EntityBook, EntityPodcast, EntityVideoGame, EntityPlace, EntityPerson,
+}
+
+// URN returns the type as Qloo names it in requests, e.g. "urn:entity:brand"
+func (t EntityType) URN() string {
+	return "urn:entity:" + string(t)
+}
+
+// Valid reports whether t is a known entity type
+func (t EntityType) Valid() bool {
+	return slices.Contains(EntityTypes, t)
+}
+
+// entityTypeFromURN converts "urn:entity:brand" back to EntityBrand
+func entityTypeFromURN(urn string) EntityType {
+	return EntityType(strings.TrimPrefix(urn, "urn:entity:"))
+}
+
+// Entity is a brand, product, artist or other item in Qloo's catalogue
+type Entity struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Type       EntityType `json:"type,omitempty"`
+	Popularity float64    `json:"popularity,omitempty"` // percentile between 0 and 1
+	Affinity   float64    `json:"affinity,omitempty"`   // to the insight's seed entities, between 0 and 1
+	Tags       []string   `json:"tags,omitempty"`
+}
+
+// Demographics describes the audience of an entity. Values are affinities
+// relative to the general population: positive values mean the group is over
+// represented, negative that it is under represented.
+type Demographics struct {
+	EntityID string             `json:"entity_id"`
+	Age      map[string]float64 `json:"age,omitempty"`    // keyed by age band, e.g. "25_to_29"
+	Gender   map[string]float64 `json:"gender,omitempty"` // keyed by "male" and "female"
+}
+
+// InsightsRequest asks for entities related to one or more seed entities
+type InsightsRequest struct {
+	EntityIDs []string   // seed entities
+	Type      EntityType // type of entity to recommend
+	Limit     int        // zero uses the client's maximum
+}
+
+// apiEntity is an entity as returned by the search and insights endpoints
+type apiEntity struct {
+	EntityID   string   `json:"entity_id"`
+	Name       string   `json:"name"`
+	Types      []string `json:"types"`
+	Popularity float64  `json:"popularity"`
+	Query      struct {
+		Affinity float64 `json:"affinity"`
+	} `json:"query"`
+	Tags []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+}
+
+// searchResponse is the body returned by the entity search endpoint
+type searchResponse struct {
+	Results []apiEntity `json:"results"`
+	Status  string      `json:"status"`
+	Message string      `json:"message,omitempty"`
+}
+
+// insightsResponse is the body returned by the insights endpoint
+type insightsResponse struct {
+	Results struct {
+		Entities     []apiEntity `json:"entities"`
+		Demographics []struct {
+			EntityID string `json:"entity_id"`
+			Query    struct {
+				Age    map[string]float64 `json:"age"`
+				Gender map[string]float64 `json:"gender"`
+			} `json:"query"`
+		} `json:"demographics"`
+	} `json:"results"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,377 @@
+// Package queryparser extracts structured search constraints such as price
+// bounds, currency, brand, color, size, condition and sort order from natural
+// language shopping requests.
+package queryparser
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Condition is the required item condition
+type Condition string
+
+const (
+	ConditionAny         Condition = ""
+	ConditionNew         Condition = "new"
+	ConditionUsed        Condition = "used"
+	ConditionRefurbished Condition = "refurbished"
+)
+
+// Sort is the requested result ordering
+type Sort string
+
+const (
+	SortRelevance Sort = ""
+	SortPriceAsc  Sort = "price_asc"
+	SortPriceDesc Sort = "price_desc"
+	SortRating    Sort = "rating"
+	SortNewest    Sort = "newest"
+)
+
+// ApproxTolerance is the fraction either side of an approximate price ("around 200")
+// that is still accepted
+const ApproxTolerance = 0.2
+
+// Query holds the constraints found in a request. Zero values mean unconstrained.
+type Query struct {
+	MinPrice    float64   `json:"min_price,omitempty"`
+	MaxPrice    float64   `json:"max_price,omitempty"`
+	Currency    string    `json:"currency,omitempty"`    // ISO 4217 code
+	Approximate bool      `json:"approximate,omitempty"` // bounds are a tolerance band around a target price
+	Brand       string    `json:"brand,omitempty"`
+	Color       string    `json:"color,omitempty"`
+	Size        string    `json:"size,omitempty"`
+	Condition   Condition `json:"condition,omitempty"`
+	Sort        Sort      `json:"sort,omitempty"`
+}
+
+// HasPrice reports whether any price bound was found
+func (q Query) HasPrice() bool {
+	return q.MinPrice > 0 || q.MaxPrice > 0
+}
+
+// KnownBrands maps lower-case brand names to their display form. Multi-word
+// brands are matched before single words.
+var KnownBrands = map[string]string{
+	"acer": "Acer", "adidas": "Adidas", "anker": "Anker", "apple": "Apple", "asus": "Asus",
+	"beats": "Beats", "bose": "Bose", "canon": "Canon", "dell": "Dell", "google": "Google",
+	"hp": "HP", "huawei": "Huawei", "infinix": "Infinix", "jbl": "JBL", "lenovo": "Lenovo",
+	"levi's": "Levi's", "lg": "LG", "logitech": "Logitech", "microsoft": "Microsoft", "msi": "MSI",
+	"new balance": "New Balance", "nike": "Nike", "nikon": "Nikon", "nokia": "Nokia",
+	"oneplus": "OnePlus", "oppo": "Oppo", "puma": "Puma", "razer": "Razer", "reebok": "Reebok",
+	"samsung": "Samsung", "sennheiser": "Sennheiser", "sony": "Sony", "tecno": "Tecno",
+	"under armour": "Under Armour", "xiaomi": "Xiaomi",
+}
+
+// colors maps color words to a canonical lower-case name
+var colors = map[string]string{
+	"black": "black", "white": "white", "red": "red", "blue": "blue", "navy": "navy",
+	"green": "green", "yellow": "yellow", "pink": "pink", "purple": "purple", "orange": "orange",
+	"grey": "gray", "gray": "gray", "silver": "silver", "gold": "gold", "brown": "brown",
+	"beige": "beige", "rose gold": "rose gold", "space gray": "space gray", "space grey": "space gray",
+}
+
+// currencyAliases maps currency symbols, codes and words to ISO 4217 codes
+var currencyAliases = map[string]string{
+	"$": "USD", "us$": "USD", "usd": "USD", "dollar": "USD", "dollars": "USD", "bucks": "USD",
+	"€": "EUR", "eur": "EUR", "euro": "EUR", "euros": "EUR",
+	"£": "GBP", "gbp": "GBP", "pound": "GBP", "pounds": "GBP",
+	"ksh": "KES", "ksh.": "KES", "kshs": "KES", "kes": "KES", "shilling": "KES", "shillings": "KES", "bob": "KES",
+	"₦": "NGN", "ngn": "NGN", "naira": "NGN",
+}
+
+var (
+	amountPattern = regexp.MustCompile(`(?i)(us\$|\$|€|£|₦|kshs?\.?|kes|usd|eur|gbp|ngn)?\s*(\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d+(?:\.\d+)?)(k\b|m\b)?(?:\s*(usd|eur|gbp|kes|ksh|ngn|dollars?|bucks|euros?|pounds?|shillings?|bob|naira)\b)?`)
+
+	// Units that make a number a measurement rather than a price
+	unitPattern = regexp.MustCompile(`(?i)^\s*(?:-\s*)?(gb|tb|mb|inch(?:es)?|"|mp|hz|mah|w\b|mm|cm|kg|ml|pcs|pieces|pack|years?|months?|people|persons|%|x\b)`)
+
+	rangeSeparator = regexp.MustCompile(`(?i)^\s*(?:-|–|to|and)\s*$`)
+
+	maxPrefix = regexp.MustCompile(`(?i)(?:under|below|less\s+than|cheaper\s+than|lower\s+than|max(?:imum)?|at\s+most|up\s+to|no\s+more\s+than|not\s+more\s+than|within|budget(?:\s+of|\s+is)?|<=?)\s*$`)
+	// "for $100" is a budget, but "for 2" usually is not
+	budgetPrefix = regexp.MustCompile(`(?i)\b(?:for|spend(?:ing)?|pay(?:ing)?)\s*$`)
+	minPrefix    = regexp.MustCompile(`(?i)(?:over|above|more\s+than|higher\s+than|at\s+least|min(?:imum)?|from|starting\s+(?:at|from)|>=?)\s*$`)
+	approxPrefix = regexp.MustCompile(`(?i)(?:around|about|approximately|approx\.?|roughly|~|circa|near|close\s+to)\s*$`)
+	maxSuffix    = regexp.MustCompile(`(?i)^\s*(?:or\s+(?:less|under|below|cheaper)|and\s+(?:under|below)|max\b|tops\b)`)
+	minSuffix    = regexp.MustCompile(`(?i)^\s*(?:\+|or\s+(?:more|above|over)|and\s+(?:up|above|over)|plus\b)`)
+	approxSuffix = regexp.MustCompile(`(?i)^\s*-?ish\b`)
+
+	sizeKeyword  = regexp.MustCompile(`(?i)\bsize\s+(\d+(?:\.\d)?|xxs|xs|s|m|l|xl|xxl|xxxl|[2-5]xl|small|medium|large|extra\s+large)\b`)
+	sizeClothing = regexp.MustCompile(`(?i)\b(xxs|xs|xl|xxl|xxxl|[2-5]xl)\b`)
+	sizeScreen   = regexp.MustCompile(`(?i)\b(\d+(?:\.\d+)?)\s*(?:-\s*)?(?:inch(?:es)?\b|")`)
+
+	conditionPatterns = []struct {
+		condition Condition
+		pattern   *regexp.Regexp
+	}{
+		{ConditionRefurbished, regexp.MustCompile(`(?i)\b(refurbished|refurb|renewed|reconditioned)\b`)},
+		{ConditionUsed, regexp.MustCompile(`(?i)\b(used|second[\s-]?hand|pre[\s-]?owned|ex[\s-]uk)\b`)},
+		{ConditionNew, regexp.MustCompile(`(?i)\b(brand[\s-]new|new|unused|sealed)\b`)},
+	}
+
+	sortPatterns = []struct {
+		sort    Sort
+		pattern *regexp.Regexp
+	}{
+		{SortPriceAsc, regexp.MustCompile(`(?i)\b(cheapest|cheap|lowest[\s-]priced?|least\s+expensive|inexpensive|affordable|low[\s-]cost)\b`)},
+		{SortPriceDesc, regexp.MustCompile(`(?i)\b(most\s+expensive|priciest|highest[\s-]priced?|high[\s-]end|premium|luxury)\b`)},
+		{SortRating, regexp.MustCompile(`(?i)\b((?:best|top|highest)[\s-]rated|best\s+reviewed|most\s+popular|best)\b`)},
+		{SortNewest, regexp.MustCompile(`(?i)\b(newest|latest|most\s+recent)\b`)},
+	}
+)
+
+// amount is a price found in the text
+type amount struct {
+	start, end int
+	value      float64
+	currency   string
+}
+
+// Parse extracts search constraints from a natural language request
+func Parse(text string) Query {
+	var q Query
+
+	// Brands are matched first so names like "New Balance" or "Under Armour" are
+	// not read as a condition or a price bound
+	rest := text
+	q.Brand, rest = matchPhrase(rest, KnownBrands)
+	q.Color, rest = matchPhrase(rest, colors)
+	q.Size, rest = parseSize(rest)
+
+	parsePrices(rest, &q)
+
+	for _, c := range conditionPatterns {
+		if c.pattern.MatchString(rest) {
+			q.Condition = c.condition
+			break
+		}
+	}
+
+	for _, s := range sortPatterns {
+		if s.pattern.MatchString(rest) {
+			q.Sort = s.sort
+			break
+		}
+	}
+
+	return q
+}
+
+// matchPhrase finds the longest phrase from the table in text, returning its
+// canonical value and the text with the phrase removed
+func matchPhrase(text string, table map[string]string) (string, string) {
+	lower := strings.ToLower(text)
+	best, bestIndex := "", -1
+	for phrase := range table {
+		index := indexWord(lower, phrase)
+		if index < 0 {
+			continue
+		}
+		if len(phrase) > len(best) || (len(phrase) == len(best) && index < bestIndex) {
+			best, bestIndex = phrase, index
+		}
+	}
+	if bestIndex < 0 {
+		return "", text
+	}
+	return table[best], text[:bestIndex] + " " + text[bestIndex+len(best):]
+}
+
+// indexWord returns the index of phrase in text where it is not part of a larger word
+func indexWord(text, phrase string) int {
+	offset := 0
+	for {
+		i := strings.Index(text[offset:], phrase)
+		if i < 0 {
+			return -1
+		}
+		start, end := offset+i, offset+i+len(phrase)
+		if (start == 0 || !isWordByte(text[start-1])) && (end == len(text) || !isWordByte(text[end])) {
+			return start
+		}
+		offset = start + 1
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '\''
+}
+
+// parseSize extracts a clothing, shoe or screen size and removes it from text
+func parseSize(text string) (string, string) {
+	if m := sizeKeyword.FindStringSubmatchIndex(text); m != nil {
+		size := text[m[2]:m[3]]
+		switch strings.ToLower(strings.Join(strings.Fields(size), " ")) {
+		case "small":
+			size = "S"
+		case "medium":
+			size = "M"
+		case "large":
+			size = "L"
+		case "extra large":
+			size = "XL"
+		default:
+			size = strings.ToUpper(size)
+		}
+		return size, text[:m[0]] + " " + text[m[1]:]
+	}
+	if m := sizeScreen.FindStringSubmatchIndex(text); m != nil {
+		return text[m[2]:m[3]] + " inch", text[:m[0]] + " " + text[m[1]:]
+	}
+	if m := sizeClothing.FindStringSubmatchIndex(text); m != nil {
+		return strings.ToUpper(text[m[2]:m[3]]), text[:m[0]] + " " + text[m[1]:]
+	}
+	return "", text
+}
+
+// parsePrices finds price bounds and their currency
+func parsePrices(text string, q *Query) {
+	amounts := findAmounts(text)
+
+	for i := 0; i < len(amounts); i++ {
+		a := amounts[i]
+		if q.Currency == "" {
+			q.Currency = a.currency
+		}
+
+		// Ranges: "between 30 and 60", "€30-€60", "from 100 to 200"
+		if i+1 < len(amounts) && rangeSeparator.MatchString(text[a.end:amounts[i+1].start]) {
+			b := amounts[i+1]
+			if q.Currency == "" {
+				q.Currency = b.currency
+			}
+			low, high := math.Min(a.value, b.value), math.Max(a.value, b.value)
+			setMin(q, low)
+			setMax(q, high)
+			i++
+			continue
+		}
+
+		before := text[:a.start]
+
+		// Words between this amount and the next can only qualify one of them:
+		// in "over $50 and under $200" the "and under" belongs to $200
+		next := len(text)
+		if i+1 < len(amounts) {
+			next = amounts[i+1].start
+		}
+		after := text[a.end:next]
+		hasNext := next < len(text)
+
+		switch {
+		case approxPrefix.MatchString(before) || suffixMatches(approxSuffix, after, hasNext):
+			q.Approximate = true
+			setMin(q, round2(a.value*(1-ApproxTolerance)))
+			setMax(q, round2(a.value*(1+ApproxTolerance)))
+		case suffixMatches(maxSuffix, after, hasNext):
+			setMax(q, a.value)
+		case suffixMatches(minSuffix, after, hasNext):
+			setMin(q, a.value)
+		case maxPrefix.MatchString(before):
+			setMax(q, a.value)
+		case minPrefix.MatchString(before):
+			setMin(q, a.value)
+		case a.currency != "" && budgetPrefix.MatchString(before):
+			setMax(q, a.value)
+		case a.currency != "":
+			// A bare price such as "$100 headphones" is a target, not a hard limit
+			q.Approximate = true
+			setMin(q, round2(a.value*(1-ApproxTolerance)))
+			setMax(q, round2(a.value*(1+ApproxTolerance)))
+		}
+	}
+
+	if q.MinPrice > 0 && q.MaxPrice > 0 && q.MinPrice > q.MaxPrice {
+		q.MinPrice, q.MaxPrice = q.MaxPrice, q.MinPrice
+	}
+}
+
+// suffixMatches reports whether a qualifier such as "or less" follows an amount,
+// ignoring qualifiers that lead straight into the next amount
+func suffixMatches(pattern *regexp.Regexp, after string, hasNext bool) bool {
+	loc := pattern.FindStringIndex(after)
+	if loc == nil {
+		return false
+	}
+	return !hasNext || strings.TrimSpace(after[loc[1]:]) != ""
+}
+
+// findAmounts returns the numbers in text that look like prices
+func findAmounts(text string) []amount {
+	var amounts []amount
+	lastEnd := -1
+	for _, m := range amountPattern.FindAllStringSubmatchIndex(text, -1) {
+		if unitPattern.MatchString(text[m[1]:]) {
+			continue
+		}
+
+		// Digits glued to letters are model numbers such as "WH-1000XM5" or "iPhone 15"
+		// unless they carry a currency
+		hasCurrency := m[2] >= 0 || m[8] >= 0
+		if !hasCurrency {
+			if m[4] > 0 && isLetter(text[m[4]-1]) {
+				continue
+			}
+			if m[4] > 1 && text[m[4]-1] == '-' && isLetter(text[m[4]-2]) && m[4]-1 != lastEnd {
+				continue
+			}
+			if m[5] < len(text) && isLetter(text[m[5]]) && m[6] < 0 && !approxSuffix.MatchString(text[m[5]:]) {
+				continue
+			}
+		}
+
+		value, err := strconv.ParseFloat(strings.ReplaceAll(text[m[4]:m[5]], ",", ""), 64)
+		if err != nil || value <= 0 {
+			continue
+		}
+		if m[6] >= 0 {
+			switch strings.ToLower(text[m[6]:m[7]]) {
+			case "k":
+				value *= 1000
+			case "m":
+				value *= 1000000
+			}
+		}
+
+		a := amount{start: m[0], end: m[1], value: value}
+		if m[2] >= 0 {
+			a.currency = currencyAliases[strings.ToLower(text[m[2]:m[3]])]
+		} else if m[8] >= 0 {
+			a.currency = currencyAliases[strings.ToLower(text[m[8]:m[9]])]
+		}
+
+		// Leading whitespace belongs to the preceding words
+		for a.start < a.end && text[a.start] == ' ' {
+			a.start++
+		}
+		amounts = append(amounts, a)
+		lastEnd = m[1]
+	}
+	return amounts
+}
+
+// setMax keeps the tightest upper bound
+func setMax(q *Query, value float64) {
+	if q.MaxPrice == 0 || value < q.MaxPrice {
+		q.MaxPrice = value
+	}
+}
+
+// setMin keeps the tightest lower bound
+func setMin(q *Query, value float64) {
+	if value > q.MinPrice {
+		q.MinPrice = value
+	}
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
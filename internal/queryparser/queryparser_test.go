@@ -0,0 +1,166 @@
+package queryparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Prices(t *testing.T) {
+	tests := []struct {
+		text     string
+		minPrice float64
+		maxPrice float64
+		currency string
+		approx   bool
+	}{
+		// Upper bounds
+		{"laptops under $1000", 0, 1000, "USD", false},
+		{"phones less than $500", 0, 500, "USD", false},
+		{"headphones below 200", 0, 200, "", false},
+		{"tablet max 3k", 0, 3000, "", false},
+		{"tv for $400", 0, 400, "USD", false},
+		{"shoes up to €80", 0, 80, "EUR", false},
+		{"a blender, no more than 50 bucks", 0, 50, "USD", false},
+		{"speaker $120 or less", 0, 120, "USD", false},
+		{"budget of KSh 5,000 for a phone", 0, 5000, "KES", false},
+		{"cheaper than £45", 0, 45, "GBP", false},
+		{"at most 1.5k", 0, 1500, "", false},
+		{"sofa under ₦250,000", 0, 250000, "NGN", false},
+		{"camera under 30000 shillings", 0, 30000, "KES", false},
+		{"watch under US$ 99.99", 0, 99.99, "USD", false},
+		{"fridge under kes 45000", 0, 45000, "KES", false},
+		{"monitor within $300", 0, 300, "USD", false},
+
+		// Lower bounds
+		{"watches over $50", 50, 0, "USD", false},
+		{"bikes above 500", 500, 0, "", false},
+		{"at least €1,200", 1200, 0, "EUR", false},
+		{"laptops $800+", 800, 0, "USD", false},
+		{"desks 150 or more", 150, 0, "", false},
+		{"rings starting at $300", 300, 0, "USD", false},
+
+		// Ranges
+		{"mice between $20 and $50", 20, 50, "USD", false},
+		{"items between 50 to 150", 50, 150, "", false},
+		{"jackets €30-€60", 30, 60, "EUR", false},
+		{"shoes 30 - 60 dollars", 30, 60, "USD", false},
+		{"from 100 to 200", 100, 200, "", false},
+		{"between 300 and 100", 100, 300, "", false},
+		{"over $50 and under $200", 50, 200, "USD", false},
+		{"KSh 2,000 – KSh 5,000", 2000, 5000, "KES", false},
+		{"phones 10k-20k", 10000, 20000, "", false},
+
+		// Approximate prices use a tolerance band
+		{"a phone around 200", 160, 240, "", true},
+		{"about $1,000 laptop", 800, 1200, "USD", true},
+		{"roughly €50", 40, 60, "EUR", true},
+		{"approximately 25 pounds", 20, 30, "GBP", true},
+		{"something 300ish", 240, 360, "", true},
+		{"$100 headphones", 80, 120, "USD", true},
+
+		// Numbers that are not prices
+		{"No price mentioned", 0, 0, "", false},
+		{"iPhone 15", 0, 0, "", false},
+		{"Sony WH-1000XM5", 0, 0, "", false},
+		{"256GB ssd", 0, 0, "", false},
+		{"65 inch tv", 0, 0, "", false},
+		{"tent for 4 people", 0, 0, "", false},
+		{"2 pack of batteries", 0, 0, "", false},
+		{"ps5 controller", 0, 0, "", false},
+		{"4k monitor", 0, 0, "", false},
+		{"iPhone 15 under 800", 0, 800, "", false},
+		{"65 inch tv under $1,500", 0, 1500, "USD", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			q := Parse(test.text)
+			assert.Equal(t, test.minPrice, q.MinPrice, "min price")
+			assert.Equal(t, test.maxPrice, q.MaxPrice, "max price")
+			assert.Equal(t, test.currency, q.Currency, "currency")
+			assert.Equal(t, test.approx, q.Approximate, "approximate")
+		})
+	}
+}
+
+func TestParse_Attributes(t *testing.T) {
+	tests := []struct {
+		text      string
+		brand     string
+		color     string
+		size      string
+		condition Condition
+	}{
+		{"black Nike running shoes size 10", "Nike", "black", "10", ConditionAny},
+		{"New Balance sneakers", "New Balance", "", "", ConditionAny},
+		{"brand new New Balance 574", "New Balance", "", "", ConditionNew},
+		{"Under Armour hoodie size medium", "Under Armour", "", "M", ConditionAny},
+		{"used iPhone 13 rose gold", "", "rose gold", "", ConditionUsed},
+		{"refurbished Dell laptop", "Dell", "", "", ConditionRefurbished},
+		{"renewed Apple Watch", "Apple", "", "", ConditionRefurbished},
+		{"second hand Samsung fridge", "Samsung", "", "", ConditionUsed},
+		{"pre-owned Canon camera", "Canon", "", "", ConditionUsed},
+		{"ex-uk hp laptop", "HP", "", "", ConditionUsed},
+		{"new jbl speaker", "JBL", "", "", ConditionNew},
+		{"grey hoodie XL", "", "gray", "XL", ConditionAny},
+		{"space grey macbook", "", "space gray", "", ConditionAny},
+		{"Samsung 65 inch tv", "Samsung", "", "65 inch", ConditionAny},
+		{"LG 27\" monitor", "LG", "", "27 inch", ConditionAny},
+		{"levi's jeans size 32", "Levi's", "", "32", ConditionAny},
+		{"redmi phone", "", "", "", ConditionAny},
+		{"bluetooth headphones", "", "", "", ConditionAny},
+		{"dress size s", "", "", "S", ConditionAny},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			q := Parse(test.text)
+			assert.Equal(t, test.brand, q.Brand, "brand")
+			assert.Equal(t, test.color, q.Color, "color")
+			assert.Equal(t, test.size, q.Size, "size")
+			assert.Equal(t, test.condition, q.Condition, "condition")
+		})
+	}
+}
+
+func TestParse_Sort(t *testing.T) {
+	tests := []struct {
+		text string
+		sort Sort
+	}{
+		{"cheapest blender", SortPriceAsc},
+		{"I need a cheap blender", SortPriceAsc},
+		{"affordable laptop", SortPriceAsc},
+		{"lowest price headphones", SortPriceAsc},
+		{"lowest priced headphones", SortPriceAsc},
+		{"most expensive watch", SortPriceDesc},
+		{"premium headphones", SortPriceDesc},
+		{"top rated air fryer", SortRating},
+		{"best laptop for students", SortRating},
+		{"newest iphone", SortNewest},
+		{"latest galaxy phone", SortNewest},
+		{"headphones cheaper than $50", SortRelevance},
+		{"wireless mouse", SortRelevance},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			assert.Equal(t, test.sort, Parse(test.text).Sort)
+		})
+	}
+}
+
+func TestParse_Combined(t *testing.T) {
+	q := Parse("cheapest used black Sony headphones under KSh 5,000")
+
+	assert.Equal(t, Query{
+		MaxPrice:  5000,
+		Currency:  "KES",
+		Brand:     "Sony",
+		Color:     "black",
+		Condition: ConditionUsed,
+		Sort:      SortPriceAsc,
+	}, q)
+	assert.True(t, q.HasPrice())
+}
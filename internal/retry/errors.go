@@ -0,0 +1,174 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Class says whether retrying a failed call can help
+type Class int
+
+const (
+	// Transient failures such as timeouts and 5xx responses may succeed on retry
+	Transient Class = iota
+	// Permanent failures such as bad input or missing credentials never will
+	Permanent
+	// RateLimited failures succeed once the upstream's retry-after has passed
+	RateLimited
+)
+
+// String returns the class name used in logs
+func (c Class) String() string {
+	switch c {
+	case Permanent:
+		return "permanent"
+	case RateLimited:
+		return "rate_limited"
+	default:
+		return "transient"
+	}
+}
+
+// Error annotates an error with its retry class
+type Error struct {
+	Err        error
+	Class      Class
+	RetryAfter time.Duration // how long the upstream asked callers to wait, if it said
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// MarkPermanent marks err as one that retrying cannot fix
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Err: err, Class: Permanent}
+}
+
+// MarkTransient marks err as one that may succeed on retry
+func MarkTransient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Err: err, Class: Transient}
+}
+
+// MarkRateLimited marks err as a rate-limit response that may be retried after the given delay
+func MarkRateLimited(err error, retryAfter time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Err: err, Class: RateLimited, RetryAfter: retryAfter}
+}
+
+// StatusError is returned by upstream clients for unexpected HTTP response statuses
+type StatusError struct {
+	Service    string
+	StatusCode int
+	RetryAfter time.Duration // parsed from the Retry-After header, zero if absent
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s API returned status %d", e.Service, e.StatusCode)
+}
+
+// Class returns the retry class of the response status: 429 is rate limited,
+// 408, 425 and 5xx are transient and every other status is permanent
+func (e *StatusError) Class() Class {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return RateLimited
+	case e.StatusCode == http.StatusRequestTimeout, e.StatusCode == http.StatusTooEarly, e.StatusCode >= 500:
+		return Transient
+	default:
+		return Permanent
+	}
+}
+
+// NewStatusError creates a StatusError from resp, honoring its Retry-After header
+func NewStatusError(service string, resp *http.Response) *StatusError {
+	return &StatusError{
+		Service:    service,
+		StatusCode: resp.StatusCode,
+		RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// ParseRetryAfter parses a Retry-After header given in seconds or as an HTTP
+// date. It returns zero for missing, invalid or past values.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// Classify returns the retry class of err and the delay the upstream asked for.
+// Errors that were not classified by their source are treated as transient so
+// unknown failures keep being retried. Timeouts are transient too: http.Client
+// timeouts match context.DeadlineExceeded, and Policy.Do already stops when the
+// caller's own deadline passes.
+func Classify(err error) (Class, time.Duration) {
+	if err == nil {
+		return Permanent, 0
+	}
+	if errors.Is(err, context.Canceled) {
+		return Permanent, 0
+	}
+
+	// errors.Join: retry if any of the failures may clear up
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		class, retryAfter := Permanent, time.Duration(0)
+		for _, e := range joined.Unwrap() {
+			c, after := Classify(e)
+			if c != Permanent && class == Permanent {
+				class = c
+			}
+			if c == RateLimited {
+				class = RateLimited
+			}
+			retryAfter = max(retryAfter, after)
+		}
+		return class, retryAfter
+	}
+
+	var marked *Error
+	if errors.As(err, &marked) {
+		return marked.Class, marked.RetryAfter
+	}
+
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Class(), status.RetryAfter
+	}
+
+	return Transient, 0
+}
+
+// IsPermanent reports whether retrying err cannot help
+func IsPermanent(err error) bool {
+	class, _ := Classify(err)
+	return class == Permanent
+}
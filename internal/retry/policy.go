@@ -0,0 +1,180 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// DefaultPolicy is used for calls without a configured policy
+var DefaultPolicy = Policy{
+	MaxAttempts: 3,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	MaxElapsed:  10 * time.Second,
+}
+
+// Policy controls how often and how long a failing call is retried. Delays use
+// full jitter: a random wait between zero and BaseDelay doubled per attempt,
+// capped at MaxDelay. A Retry-After from the upstream replaces the jittered
+// delay. Retrying stops early when the next wait would pass MaxElapsed or the
+// context deadline.
+type Policy struct {
+	MaxAttempts int           // total calls including the first; values below 1 mean 1
+	BaseDelay   time.Duration // ceiling of the first jittered delay
+	MaxDelay    time.Duration // ceiling of any jittered delay; zero means uncapped
+	MaxElapsed  time.Duration // total time budget; zero means bounded only by ctx
+}
+
+// ExhaustedError is returned when a transient failure outlasts the policy
+type ExhaustedError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *ExhaustedError) Error() string {
+	return fmt.Sprintf("failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *ExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+// Notify is called before each retry with the failed attempt number, its error
+// and the wait before the next attempt
+type Notify func(attempt int, err error, delay time.Duration)
+
+// jitter returns a random duration in [0, ceiling]; replaced in tests
+var jitter = func(ceiling time.Duration) time.Duration {
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
+
+// Do calls fn until it succeeds, fails permanently or the policy is exhausted.
+// Permanent errors and context errors are returned as they are; a transient
+// error that outlasts the policy is returned in an ExhaustedError.
+func (p Policy) Do(ctx context.Context, fn func(ctx context.Context) error, notify Notify) error {
+	attempts := max(p.MaxAttempts, 1)
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		class, retryAfter := Classify(err)
+		if class == Permanent {
+			return err
+		}
+		if attempt >= attempts {
+			return &ExhaustedError{Attempts: attempt, Err: err}
+		}
+
+		delay := p.delay(attempt, retryAfter)
+		if !p.fits(ctx, start, delay) {
+			return &ExhaustedError{Attempts: attempt, Err: err}
+		}
+		if notify != nil {
+			notify(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// delay returns the wait after the given failed attempt
+func (p Policy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	ceiling := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || ceiling < p.MaxDelay); i++ {
+		ceiling *= 2
+	}
+	if p.MaxDelay > 0 {
+		ceiling = min(ceiling, p.MaxDelay)
+	}
+	return jitter(ceiling)
+}
+
+// fits reports whether waiting delay leaves time for another attempt within
+// MaxElapsed and the context deadline
+func (p Policy) fits(ctx context.Context, start time.Time, delay time.Duration) bool {
+	next := time.Now().Add(delay)
+	if p.MaxElapsed > 0 && next.Sub(start) >= p.MaxElapsed {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && !next.Before(deadline) {
+		return false
+	}
+	return true
+}
+
+// policyJSON is the file format of a Policy, with durations like "250ms"
+type policyJSON struct {
+	MaxAttempts int    `json:"max_attempts"`
+	BaseDelay   string `json:"base_delay,omitempty"`
+	MaxDelay    string `json:"max_delay,omitempty"`
+	MaxElapsed  string `json:"max_elapsed,omitempty"`
+}
+
+// MarshalJSON writes durations as strings like "250ms"
+func (p Policy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(policyJSON{
+		MaxAttempts: p.MaxAttempts,
+		BaseDelay:   p.BaseDelay.String(),
+		MaxDelay:    p.MaxDelay.String(),
+		MaxElapsed:  p.MaxElapsed.String(),
+	})
+}
+
+// UnmarshalJSON reads durations written as strings like "250ms". Omitted
+// durations are zero.
+func (p *Policy) UnmarshalJSON(data []byte) error {
+	var raw policyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	policy := Policy{MaxAttempts: raw.MaxAttempts}
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"base_delay", raw.BaseDelay, &policy.BaseDelay},
+		{"max_delay", raw.MaxDelay, &policy.MaxDelay},
+		{"max_elapsed", raw.MaxElapsed, &policy.MaxElapsed},
+	} {
+		if field.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(field.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", field.name, err)
+		}
+		*field.dest = d
+	}
+
+	*p = policy
+	return nil
+}
@@ -0,0 +1,243 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noJitter(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var ceilings []time.Duration
+	original := jitter
+	jitter = func(ceiling time.Duration) time.Duration {
+		ceilings = append(ceilings, ceiling)
+		return 0
+	}
+	t.Cleanup(func() { jitter = original })
+	return &ceilings
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		want       Class
+		retryAfter time.Duration
+	}{
+		{"unclassified", errors.New("boom"), Transient, 0},
+		{"permanent", MarkPermanent(errors.New("QLOO_API_KEY not set")), Permanent, 0},
+		{"wrapped permanent", fmt.Errorf("failed: %w", MarkPermanent(errors.New("bad"))), Permanent, 0},
+		{"rate limited", MarkRateLimited(errors.New("slow down"), time.Second), RateLimited, time.Second},
+		{"429", &StatusError{StatusCode: 429, RetryAfter: 2 * time.Second}, RateLimited, 2 * time.Second},
+		{"503", &StatusError{StatusCode: 503}, Transient, 0},
+		{"408", &StatusError{StatusCode: 408}, Transient, 0},
+		{"400", &StatusError{StatusCode: 400}, Permanent, 0},
+		{"401", fmt.Errorf("search: %w", &StatusError{StatusCode: 401}), Permanent, 0},
+		{"canceled", context.Canceled, Permanent, 0},
+		{"deadline", context.DeadlineExceeded, Transient, 0},
+		{"all permanent", errors.Join(&StatusError{StatusCode: 400}, MarkPermanent(errors.New("x"))), Permanent, 0},
+		{"any transient", errors.Join(&StatusError{StatusCode: 400}, &StatusError{StatusCode: 502}), Transient, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			class, retryAfter := Classify(test.err)
+			assert.Equal(t, test.want, class)
+			assert.Equal(t, test.retryAfter, retryAfter)
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 3*time.Second, ParseRetryAfter("3", now))
+	assert.Equal(t, 90*time.Second, ParseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Zero(t, ParseRetryAfter("", now))
+	assert.Zero(t, ParseRetryAfter("-1", now))
+	assert.Zero(t, ParseRetryAfter("soon", now))
+	assert.Zero(t, ParseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+}
+
+func TestNewStatusError(t *testing.T) {
+	resp := &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": []string{"5"}}}
+
+	err := NewStatusError("qloo", resp)
+
+	assert.Equal(t, "qloo API returned status 429", err.Error())
+	assert.Equal(t, 5*time.Second, err.RetryAfter)
+	assert.Equal(t, RateLimited, err.Class())
+}
+
+func TestPolicyDo_RetriesTransientErrors(t *testing.T) {
+	ceilings := noJitter(t)
+	policy := Policy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 250 * time.Millisecond}
+
+	calls := 0
+	var notified []int
+	err := policy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errors.New("flaky")
+	}, func(attempt int, err error, delay time.Duration) {
+		notified = append(notified, attempt)
+	})
+
+	var exhausted *ExhaustedError
+	require.ErrorAs(t, err, &exhausted)
+	assert.Equal(t, 4, exhausted.Attempts)
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, []int{1, 2, 3}, notified)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 250 * time.Millisecond}, *ceilings)
+	assert.Contains(t, err.Error(), "failed after 4 attempts")
+}
+
+func TestPolicyDo_StopsOnPermanentError(t *testing.T) {
+	noJitter(t)
+
+	calls := 0
+	permanent := MarkPermanent(errors.New("missing or invalid query parameter"))
+	err := DefaultPolicy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return permanent
+	}, nil)
+
+	assert.Equal(t, permanent, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestPolicyDo_SucceedsAfterRetry(t *testing.T) {
+	noJitter(t)
+
+	calls := 0
+	err := DefaultPolicy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 2 {
+			return &StatusError{StatusCode: 502}
+		}
+		return nil
+	}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestPolicyDo_HonorsRetryAfter(t *testing.T) {
+	ceilings := noJitter(t)
+
+	var delays []time.Duration
+	calls := 0
+	err := DefaultPolicy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 2 {
+			return &StatusError{StatusCode: 429, RetryAfter: 20 * time.Millisecond}
+		}
+		return nil
+	}, func(attempt int, err error, delay time.Duration) {
+		delays = append(delays, delay)
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{20 * time.Millisecond}, delays)
+	assert.Empty(t, *ceilings)
+}
+
+func TestPolicyDo_GivesUpWhenRetryAfterPassesDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	calls := 0
+	start := time.Now()
+	err := DefaultPolicy.Do(ctx, func(ctx context.Context) error {
+		calls++
+		return &StatusError{Service: "qloo", StatusCode: 429, RetryAfter: time.Minute}
+	}, nil)
+
+	var status *StatusError
+	assert.ErrorAs(t, err, &status)
+	assert.Equal(t, 1, calls)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestPolicyDo_BoundedByMaxElapsed(t *testing.T) {
+	policy := Policy{MaxAttempts: 10, BaseDelay: time.Second, MaxElapsed: 50 * time.Millisecond}
+	original := jitter
+	jitter = func(ceiling time.Duration) time.Duration { return ceiling }
+	t.Cleanup(func() { jitter = original })
+
+	calls := 0
+	err := policy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errors.New("flaky")
+	}, nil)
+
+	assert.ErrorContains(t, err, "failed after 1 attempts")
+	assert.Equal(t, 1, calls)
+}
+
+func TestPolicyDo_ContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := DefaultPolicy.Do(ctx, func(ctx context.Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return errors.New("slow")
+	}, nil)
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestPolicyDo_RetriesClientTimeouts(t *testing.T) {
+	noJitter(t)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := &http.Client{Timeout: 20 * time.Millisecond}
+	calls := 0
+	err := Policy{MaxAttempts: 3, BaseDelay: time.Millisecond}.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}, nil)
+
+	var exhausted *ExhaustedError
+	require.ErrorAs(t, err, &exhausted)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 3, calls)
+}
+
+func TestPolicy_JSON(t *testing.T) {
+	var policy Policy
+	require.NoError(t, json.Unmarshal([]byte(`{"max_attempts": 5, "base_delay": "250ms", "max_elapsed": "3s"}`), &policy))
+	assert.Equal(t, Policy{MaxAttempts: 5, BaseDelay: 250 * time.Millisecond, MaxElapsed: 3 * time.Second}, policy)
+
+	data, err := json.Marshal(policy)
+	require.NoError(t, err)
+	var roundTrip Policy
+	require.NoError(t, json.Unmarshal(data, &roundTrip))
+	assert.Equal(t, policy, roundTrip)
+
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"base_delay": "soon"}`), &policy), "invalid base_delay")
+}
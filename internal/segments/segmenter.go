@@ -0,0 +1,149 @@
+package segments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// Sources reported with a set of audience segments
+const (
+	SourceQloo     = "qloo"     // Qloo's taste profile
+	SourceTaxonomy = "taxonomy" // categories in the local taxonomy matched the description
+	SourceLLM      = "llm"      // inferred by the model
+	SourceDefault  = "default"  // nothing matched, so the taxonomy's default segments
+)
+
+// MaxInferredSegments bounds how many segments the model may infer
+const MaxInferredSegments = 5
+
+// Result is a set of audience segments and where they came from
+type Result struct {
+	Segments   []qloo.Segment `json:"segments"`
+	Source     string         `json:"source"`
+	Categories []string       `json:"categories,omitempty"` // taxonomy categories that matched
+}
+
+// Inferrer infers audience segments for a product description
+type Inferrer interface {
+	Infer(ctx context.Context, description string) ([]qloo.Segment, error)
+}
+
+// Segmenter segments audiences locally when Qloo cannot. Taxonomy keywords are
+// tried first, then the inferrer when one is set, then the default segments.
+type Segmenter struct {
+	Taxonomy *Taxonomy
+	Inferrer Inferrer // nil disables model inference
+}
+
+// NewSegmenter creates a segmenter for the taxonomy, using DefaultTaxonomy when nil
+func NewSegmenter(taxonomy *Taxonomy, inferrer Inferrer) *Segmenter {
+	if taxonomy == nil {
+		taxonomy = DefaultTaxonomy
+	}
+	return &Segmenter{Taxonomy: taxonomy, Inferrer: inferrer}
+}
+
+// Segment returns audience segments for the description. It always returns
+// segments; inference failures are logged and fall through to the defaults.
+func (s *Segmenter) Segment(ctx context.Context, description string) Result {
+	if matched, categories := s.Taxonomy.Match(description); len(matched) > 0 {
+		return Result{Segments: matched, Source: SourceTaxonomy, Categories: categories}
+	}
+
+	if s.Inferrer != nil && strings.TrimSpace(description) != "" {
+		inferred, err := s.Inferrer.Infer(ctx, description)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Segment inference failed, using default segments", logging.KeyError, err)
+		} else if len(inferred) > 0 {
+			return Result{Segments: inferred, Source: SourceLLM}
+		}
+	}
+
+	return Result{Segments: s.Taxonomy.Default, Source: SourceDefault}
+}
+
+// inferSystemPrompt instructs the model how to fill the segment schema
+const inferSystemPrompt = `You are a marketing analyst. List the audience segments most likely to buy
+the product the user describes, as short title-case names such as "Outdoor
+Adventurers" or "New Parents", with an affinity score between 0 and 1 for how
+strongly each segment is drawn to the product. List at most five, strongest first.`
+
+// SegmentSchema returns the JSON schema for structured segment inference
+func SegmentSchema() jsonschema.Definition {
+	return jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"segments": {
+				Type: jsonschema.Array,
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"name":           {Type: jsonschema.String, Description: "Audience segment name"},
+						"affinity_score": {Type: jsonschema.Number, Description: "Affinity between 0 and 1"},
+					},
+					Required:             []string{"name", "affinity_score"},
+					AdditionalProperties: false,
+				},
+				Description: "Audience segments, strongest first",
+			},
+		},
+		Required:             []string{"segments"},
+		AdditionalProperties: false,
+	}
+}
+
+// LLMInferrer infers segments with a model using structured output
+type LLMInferrer struct {
+	Provider llm.Provider
+	Model    string
+}
+
+// NewLLMInferrer creates a new LLM-backed segment inferrer
+func NewLLMInferrer(provider llm.Provider, model string) *LLMInferrer {
+	return &LLMInferrer{
+		Provider: provider,
+		Model:    model,
+	}
+}
+
+// Infer asks the model for segments, dropping unnamed ones and clamping scores to [0, 1]
+func (l *LLMInferrer) Infer(ctx context.Context, description string) ([]qloo.Segment, error) {
+	var output struct {
+		Segments []qloo.Segment `json:"segments"`
+	}
+	_, err := llm.ChatJSON(ctx, l.Provider, llm.ChatRequest{
+		Model: l.Model,
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: inferSystemPrompt},
+			{Role: llm.RoleUser, Content: description},
+		},
+		ResponseFormat: &llm.ResponseFormat{
+			Name:   "audience_segments",
+			Schema: SegmentSchema(),
+			Strict: true,
+		},
+	}, &output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to infer segments: %w", err)
+	}
+
+	var segments []qloo.Segment
+	for _, segment := range output.Segments {
+		segment.Name = strings.TrimSpace(segment.Name)
+		if segment.Name == "" {
+			continue
+		}
+		segment.AffinityScore = min(max(segment.AffinityScore, 0), 1)
+		segments = append(segments, segment)
+		if len(segments) == MaxInferredSegments {
+			break
+		}
+	}
+	return segments, nil
+}
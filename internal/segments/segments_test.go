@@ -0,0 +1,117 @@
+package segments
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jesee-kuya/blue/internal/llm"
+	"github.com/jesee-kuya/blue/internal/qloo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubInferrer struct {
+	segments []qloo.Segment
+	err      error
+	calls    int
+}
+
+func (s *stubInferrer) Infer(ctx context.Context, description string) ([]qloo.Segment, error) {
+	s.calls++
+	return s.segments, s.err
+}
+
+func TestDefaultTaxonomy_IsValid(t *testing.T) {
+	assert.NoError(t, DefaultTaxonomy.Validate())
+}
+
+func TestTaxonomy_Match(t *testing.T) {
+	matched, categories := DefaultTaxonomy.Match("RGB Gaming Laptops with a 17-inch screen")
+
+	assert.Equal(t, []string{"electronics", "gaming"}, categories)
+	require.NotEmpty(t, matched)
+	assert.Equal(t, qloo.Segment{Name: "Gamers", AffinityScore: 0.85}, matched[0])
+
+	// Tech Enthusiasts appear in both categories and keep the higher score
+	var techScores []float64
+	for _, segment := range matched {
+		if segment.Name == "Tech Enthusiasts" {
+			techScores = append(techScores, segment.AffinityScore)
+		}
+	}
+	assert.Equal(t, []float64{0.8}, techScores)
+
+	// Keywords only match whole words
+	matched, categories = DefaultTaxonomy.Match("a babysitting service")
+	assert.Empty(t, matched)
+	assert.Empty(t, categories)
+}
+
+func TestLoadTaxonomy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "taxonomy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"categories": [{"name": "pets", "keywords": ["dog", "cat litter"], "segments": [{"name": "Pet Parents", "affinity_score": 0.9}]}],
+		"default": [{"name": "Everyone", "affinity_score": 0.3}]
+	}`), 0o600))
+
+	taxonomy, err := LoadTaxonomy(path)
+	require.NoError(t, err)
+	matched, _ := taxonomy.Match("Clumping cat litter, 10kg")
+	assert.Equal(t, []qloo.Segment{{Name: "Pet Parents", AffinityScore: 0.9}}, matched)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"categories": [{"name": "pets", "segments": [{"name": "", "affinity_score": 2}]}]}`), 0o600))
+	_, err = LoadTaxonomy(invalid)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `category "pets": no keywords`)
+	assert.Contains(t, err.Error(), "segment without a name")
+	assert.Contains(t, err.Error(), "is not between 0 and 1")
+
+	_, err = LoadTaxonomy(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "failed to read taxonomy")
+}
+
+func TestSegmenter_Segment(t *testing.T) {
+	ctx := context.Background()
+	inferrer := &stubInferrer{segments: []qloo.Segment{{Name: "Hobby Bakers", AffinityScore: 0.7}}}
+	segmenter := NewSegmenter(nil, inferrer)
+
+	result := segmenter.Segment(ctx, "Stand mixer for the kitchen")
+	assert.Equal(t, SourceTaxonomy, result.Source)
+	assert.Equal(t, []string{"home"}, result.Categories)
+	assert.Equal(t, 0, inferrer.calls)
+
+	result = segmenter.Segment(ctx, "Sourdough starter kit")
+	assert.Equal(t, Result{Segments: inferrer.segments, Source: SourceLLM}, result)
+
+	inferrer.err = errors.New("model unavailable")
+	result = segmenter.Segment(ctx, "Sourdough starter kit")
+	assert.Equal(t, SourceDefault, result.Source)
+	assert.Equal(t, DefaultTaxonomy.Default, result.Segments)
+
+	result = NewSegmenter(nil, nil).Segment(ctx, "Sourdough starter kit")
+	assert.Equal(t, SourceDefault, result.Source)
+}
+
+func TestLLMInferrer_Infer(t *testing.T) {
+	provider := llm.NewFakeProvider(llm.FakeJSON(map[string]any{
+		"segments": []qloo.Segment{
+			{Name: " Hobby Bakers ", AffinityScore: 1.4},
+			{Name: "", AffinityScore: 0.9},
+			{Name: "Foodies", AffinityScore: -0.2},
+		},
+	}))
+
+	segments, err := NewLLMInferrer(provider, "gpt-4o-mini").Infer(context.Background(), "Sourdough starter kit")
+
+	require.NoError(t, err)
+	assert.Equal(t, []qloo.Segment{{Name: "Hobby Bakers", AffinityScore: 1}, {Name: "Foodies", AffinityScore: 0}}, segments)
+	requests := provider.Requests()
+	require.Len(t, requests, 1)
+	require.NotNil(t, requests[0].ResponseFormat)
+	assert.Equal(t, "audience_segments", requests[0].ResponseFormat.Name)
+}
@@ -0,0 +1,184 @@
+package segments
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jesee-kuya/blue/internal/qloo"
+)
+
+// Taxonomy maps product categories, recognised by keywords, to the audience
+// segments they appeal to. Default is used when no category matches.
+type Taxonomy struct {
+	Categories []Category     `json:"categories"`
+	Default    []qloo.Segment `json:"default"`
+
+	compileOnce sync.Once
+}
+
+// Category is a product category with the segments that buy it
+type Category struct {
+	Name     string         `json:"name"`
+	Keywords []string       `json:"keywords"`
+	Segments []qloo.Segment `json:"segments"`
+
+	patterns []*regexp.Regexp
+}
+
+// DefaultTaxonomy covers the categories sold on the supported marketplaces
+var DefaultTaxonomy = &Taxonomy{
+	Categories: []Category{
+		{Name: "electronics", Keywords: []string{"laptop", "phone", "smartphone", "tablet", "headphones", "earbuds", "camera", "smartwatch", "speaker", "tv", "monitor"}, Segments: []qloo.Segment{
+			{Name: "Tech Enthusiasts", AffinityScore: 0.8},
+			{Name: "Early Adopters", AffinityScore: 0.65},
+			{Name: "Remote Professionals", AffinityScore: 0.5},
+		}},
+		{Name: "gaming", Keywords: []string{"gaming", "gamer", "console", "playstation", "xbox", "nintendo", "controller", "rgb"}, Segments: []qloo.Segment{
+			{Name: "Gamers", AffinityScore: 0.85},
+			{Name: "Streamers", AffinityScore: 0.6},
+			{Name: "Tech Enthusiasts", AffinityScore: 0.55},
+		}},
+		{Name: "fashion", Keywords: []string{"dress", "shirt", "jeans", "jacket", "sneakers", "shoes", "handbag", "fashion", "clothing"}, Segments: []qloo.Segment{
+			{Name: "Fashion Forward", AffinityScore: 0.8},
+			{Name: "Trend Followers", AffinityScore: 0.6},
+			{Name: "Value Seekers", AffinityScore: 0.45},
+		}},
+		{Name: "fitness", Keywords: []string{"running", "fitness", "gym", "yoga", "workout", "treadmill", "dumbbells", "trail"}, Segments: []qloo.Segment{
+			{Name: "Fitness Enthusiasts", AffinityScore: 0.85},
+			{Name: "Outdoor Adventurers", AffinityScore: 0.55},
+			{Name: "Health Conscious", AffinityScore: 0.5},
+		}},
+		{Name: "beauty", Keywords: []string{"skincare", "makeup", "serum", "moisturizer", "lipstick", "fragrance", "perfume", "beauty"}, Segments: []qloo.Segment{
+			{Name: "Beauty Enthusiasts", AffinityScore: 0.85},
+			{Name: "Self-Care Seekers", AffinityScore: 0.6},
+		}},
+		{Name: "home", Keywords: []string{"blender", "kitchen", "cookware", "furniture", "sofa", "mattress", "vacuum", "decor", "coffee"}, Segments: []qloo.Segment{
+			{Name: "Home Makers", AffinityScore: 0.75},
+			{Name: "Home Cooks", AffinityScore: 0.6},
+			{Name: "Value Seekers", AffinityScore: 0.45},
+		}},
+		{Name: "baby", Keywords: []string{"baby", "stroller", "diapers", "toddler", "crib"}, Segments: []qloo.Segment{
+			{Name: "New Parents", AffinityScore: 0.9},
+			{Name: "Gift Shoppers", AffinityScore: 0.4},
+		}},
+	},
+	Default: []qloo.Segment{
+		{Name: "General Consumers", AffinityScore: 0.5},
+		{Name: "Value Seekers", AffinityScore: 0.4},
+	},
+}
+
+// LoadTaxonomy reads a taxonomy from a JSON file of the form
+// {"categories": [{"name": "gaming", "keywords": [...], "segments": [...]}], "default": [...]}
+func LoadTaxonomy(path string) (*Taxonomy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read taxonomy: %w", err)
+	}
+
+	var taxonomy Taxonomy
+	if err := json.Unmarshal(data, &taxonomy); err != nil {
+		return nil, fmt.Errorf("failed to parse taxonomy: %w", err)
+	}
+	if err := taxonomy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid taxonomy %s: %w", path, err)
+	}
+
+	return &taxonomy, nil
+}
+
+// Validate checks every category has keywords and every segment a name and an
+// affinity score between 0 and 1
+func (t *Taxonomy) Validate() error {
+	var errs []error
+	for i, category := range t.Categories {
+		if strings.TrimSpace(category.Name) == "" {
+			errs = append(errs, fmt.Errorf("categories[%d]: missing name", i))
+		}
+		if len(category.Keywords) == 0 {
+			errs = append(errs, fmt.Errorf("category %q: no keywords", category.Name))
+		}
+		if len(category.Segments) == 0 {
+			errs = append(errs, fmt.Errorf("category %q: no segments", category.Name))
+		}
+		errs = append(errs, validateSegments(category.Name, category.Segments)...)
+	}
+	errs = append(errs, validateSegments("default", t.Default)...)
+	return errors.Join(errs...)
+}
+
+// validateSegments reports unnamed segments and scores outside [0, 1]
+func validateSegments(owner string, segments []qloo.Segment) []error {
+	var errs []error
+	for _, segment := range segments {
+		if strings.TrimSpace(segment.Name) == "" {
+			errs = append(errs, fmt.Errorf("%s: segment without a name", owner))
+		}
+		if segment.AffinityScore < 0 || segment.AffinityScore > 1 {
+			errs = append(errs, fmt.Errorf("%s: segment %q affinity score %v is not between 0 and 1", owner, segment.Name, segment.AffinityScore))
+		}
+	}
+	return errs
+}
+
+// Match returns the segments of every category whose keywords appear in the
+// description, with the highest affinity first, and the names of the matched
+// categories. A segment shared by several categories keeps its highest score.
+func (t *Taxonomy) Match(description string) ([]qloo.Segment, []string) {
+	t.compileOnce.Do(func() {
+		for i := range t.Categories {
+			t.Categories[i].compile()
+		}
+	})
+
+	scores := make(map[string]float64)
+	var categories []string
+	for i := range t.Categories {
+		category := &t.Categories[i]
+		if !category.matches(description) {
+			continue
+		}
+		categories = append(categories, category.Name)
+		for _, segment := range category.Segments {
+			scores[segment.Name] = max(scores[segment.Name], segment.AffinityScore)
+		}
+	}
+
+	matched := make([]qloo.Segment, 0, len(scores))
+	for name, score := range scores {
+		matched = append(matched, qloo.Segment{Name: name, AffinityScore: score})
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].AffinityScore != matched[j].AffinityScore {
+			return matched[i].AffinityScore > matched[j].AffinityScore
+		}
+		return matched[i].Name < matched[j].Name
+	})
+	return matched, categories
+}
+
+// compile builds a pattern per keyword that matches it as a whole word, in the
+// singular or plural
+func (c *Category) compile() {
+	for _, keyword := range c.Keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			c.patterns = append(c.patterns, regexp.MustCompile(`(?i)\b`+regexp.QuoteMeta(keyword)+`s?\b`))
+		}
+	}
+}
+
+// matches reports whether any keyword appears in the description
+func (c *Category) matches(description string) bool {
+	for _, pattern := range c.patterns {
+		if pattern.MatchString(description) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/config"
+	"github.com/jesee-kuya/blue/internal/logging"
+)
+
+// Server serves HTTP with the configured timeouts and drains in-flight
+// requests on shutdown
+type Server struct {
+	HTTP *http.Server
+	cfg  config.Server
+}
+
+// New creates a server for handler
+func New(cfg config.Server, handler http.Handler) *Server {
+	return &Server{
+		HTTP: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       time.Duration(cfg.ReadTimeout),
+			ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeout),
+			WriteTimeout:      time.Duration(cfg.WriteTimeout),
+			IdleTimeout:       time.Duration(cfg.IdleTimeout),
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		},
+		cfg: cfg,
+	}
+}
+
+// Run listens on the configured address and serves until ctx is done
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve accepts connections on ln, over TLS when cert files are configured,
+// until ctx is done. It then stops accepting and waits up to the shutdown
+// timeout for in-flight requests, including streams, before closing whatever
+// is left.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	served := make(chan error, 1)
+	go func() {
+		slog.Info("Server listening", "addr", ln.Addr().String(), "tls", s.cfg.TLS())
+		if s.cfg.TLS() {
+			served <- s.HTTP.ServeTLS(ln, s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+		} else {
+			served <- s.HTTP.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := time.Duration(s.cfg.ShutdownTimeout)
+	slog.Info("Shutting down, draining in-flight requests", "timeout", timeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := s.HTTP.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		slog.Warn("Shutdown timed out, closing remaining connections")
+		err = s.HTTP.Close()
+	}
+	if serveErr := <-served; !errors.Is(serveErr, http.ErrServerClosed) {
+		slog.Error("Server stopped unexpectedly", logging.KeyError, serveErr)
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startServer serves handler until the returned cancel is called
+func startServer(t *testing.T, shutdownTimeout time.Duration, handler http.Handler) (string, context.CancelFunc, <-chan error) {
+	cfg := config.Default().Server
+	cfg.ShutdownTimeout = config.Duration(shutdownTimeout)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- New(cfg, handler).Serve(ctx, ln) }()
+	return "http://" + ln.Addr().String(), cancel, done
+}
+
+func TestServe_DrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	url, cancel, done := startServer(t, time.Second, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "finished")
+	}))
+
+	responses := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			responses <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		responses <- string(body)
+	}()
+
+	<-started
+	cancel()
+
+	// New connections are refused while the request drains
+	require.Eventually(t, func() bool {
+		_, err := net.Dial("tcp", url[len("http://"):])
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+
+	close(release)
+	assert.Equal(t, "finished", <-responses)
+	assert.NoError(t, <-done)
+}
+
+func TestServe_ClosesRequestsThatOutliveTheDeadline(t *testing.T) {
+	started := make(chan struct{})
+	url, cancel, done := startServer(t, 50*time.Millisecond, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+
+	go http.Get(url)
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not stop after the shutdown timeout")
+	}
+}
+
+func TestNew_AppliesTimeouts(t *testing.T) {
+	cfg := config.Default().Server
+	srv := New(cfg, http.NotFoundHandler())
+
+	assert.Equal(t, time.Duration(cfg.ReadHeaderTimeout), srv.HTTP.ReadHeaderTimeout)
+	assert.Equal(t, time.Duration(cfg.WriteTimeout), srv.HTTP.WriteTimeout)
+	assert.Equal(t, cfg.MaxHeaderBytes, srv.HTTP.MaxHeaderBytes)
+}
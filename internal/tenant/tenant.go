@@ -0,0 +1,43 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+)
+
+// Tenant identifies the customer a request is made on behalf of
+type Tenant struct {
+	ID   string `json:"id"`
+	Plan string `json:"plan,omitempty"`
+}
+
+type contextKey struct{}
+
+// WithTenant returns a copy of ctx carrying the given tenant
+func WithTenant(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the tenant carried by ctx, if any
+func FromContext(ctx context.Context) (Tenant, bool) {
+	if ctx == nil {
+		return Tenant{}, false
+	}
+	t, ok := ctx.Value(contextKey{}).(Tenant)
+	return t, ok && t.ID != ""
+}
+
+// ID returns the tenant ID carried by ctx, or an empty string for anonymous requests
+func ID(ctx context.Context) string {
+	t, _ := FromContext(ctx)
+	return t.ID
+}
+
+// ScopedKey prefixes a storage key with the tenant carried by ctx so that
+// cached and persisted data never leaks between tenants
+func ScopedKey(ctx context.Context, key string) string {
+	if id := ID(ctx); id != "" {
+		return fmt.Sprintf("tenant:%s:%s", id, key)
+	}
+	return key
+}
@@ -0,0 +1,29 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopedKey(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "qloo:profile:abc", ScopedKey(ctx, "qloo:profile:abc"))
+
+	ctx = WithTenant(ctx, Tenant{ID: "acme", Plan: "pro"})
+	assert.Equal(t, "tenant:acme:qloo:profile:abc", ScopedKey(ctx, "qloo:profile:abc"))
+}
+
+func TestFromContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	_, ok = FromContext(WithTenant(context.Background(), Tenant{}))
+	assert.False(t, ok)
+
+	got, ok := FromContext(WithTenant(context.Background(), Tenant{ID: "acme", Plan: "pro"}))
+	assert.True(t, ok)
+	assert.Equal(t, "pro", got.Plan)
+	assert.Equal(t, "acme", ID(WithTenant(context.Background(), got)))
+}
@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup_Exporters(t *testing.T) {
+	ctx := context.Background()
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	t.Setenv("OTEL_TRACES_EXPORTER", "")
+	shutdown, err := Setup(ctx)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(ctx))
+
+	t.Setenv("OTEL_TRACES_EXPORTER", "stdout")
+	shutdown, err = Setup(ctx)
+	require.NoError(t, err)
+	assert.IsType(t, &sdktrace.TracerProvider{}, otel.GetTracerProvider())
+	assert.NoError(t, shutdown(ctx))
+
+	t.Setenv("OTEL_TRACES_EXPORTER", "zipkin")
+	_, err = Setup(ctx)
+	assert.ErrorContains(t, err, `unknown OTEL_TRACES_EXPORTER "zipkin"`)
+}
+
+func TestServiceName(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "")
+	assert.Equal(t, DefaultServiceName, ServiceName())
+
+	t.Setenv("OTEL_SERVICE_NAME", "blue-staging")
+	assert.Equal(t, "blue-staging", ServiceName())
+}
+
+func TestTransport_PropagatesTraceContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	t.Setenv("OTEL_TRACES_EXPORTER", "none")
+	_, err := Setup(context.Background())
+	require.NoError(t, err)
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	ctx, span := Start(context.Background(), "search")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := HTTPClient(0).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	End(span, nil)
+
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+	assert.Len(t, recorder.Ended(), 2)
+}
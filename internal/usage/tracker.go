@@ -0,0 +1,174 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+// anonymousTenant aggregates usage for requests made without a tenant
+const anonymousTenant = "anonymous"
+
+// usageRetention is how long daily and monthly aggregates are kept
+const usageRetention = 400 * 24 * time.Hour
+
+// Budget caps a tenant's model spend; zero values mean unlimited
+type Budget struct {
+	DailyUSD   float64 `json:"daily_usd"`
+	MonthlyUSD float64 `json:"monthly_usd"`
+}
+
+// BudgetExceededError is returned when a tenant has spent its budget
+type BudgetExceededError struct {
+	TenantID string
+	Period   string
+	SpentUSD float64
+	LimitUSD float64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s spend budget of $%.2f exceeded for tenant %s ($%.2f spent)", e.Period, e.LimitUSD, e.TenantID, e.SpentUSD)
+}
+
+// Tracker aggregates usage per tenant and day in Redis and enforces spend budgets
+type Tracker struct {
+	Prices        PriceTable
+	DefaultBudget Budget
+	Budgets       map[string]Budget
+	redisClient   *cache.RedisClient
+	now           func() time.Time
+}
+
+// NewTracker creates a new usage tracker
+func NewTracker(redisClient *cache.RedisClient, prices PriceTable) *Tracker {
+	return &Tracker{
+		Prices:      prices,
+		Budgets:     make(map[string]Budget),
+		redisClient: redisClient,
+		now:         time.Now,
+	}
+}
+
+// recordScript increments the daily and monthly aggregates atomically.
+// KEYS[1] = daily key, KEYS[2] = monthly key
+// ARGV[1] = prompt tokens, ARGV[2] = completion tokens, ARGV[3] = cost, ARGV[4] = retention (s)
+var recordScript = redis.NewScript(`
+for i = 1, 2 do
+	redis.call('HINCRBY', KEYS[i], 'prompt_tokens', ARGV[1])
+	redis.call('HINCRBY', KEYS[i], 'completion_tokens', ARGV[2])
+	redis.call('HINCRBYFLOAT', KEYS[i], 'cost_usd', ARGV[3])
+	redis.call('HINCRBY', KEYS[i], 'calls', 1)
+	redis.call('EXPIRE', KEYS[i], ARGV[4])
+end
+return 1
+`)
+
+// NewTrackerWithConfig creates a tracker pricing calls from the price table
+// file, or the default prices when path is empty, with budget as the default
+// budget for every tenant
+func NewTrackerWithConfig(redisClient *cache.RedisClient, priceTableFile string, budget Budget) (*Tracker, error) {
+	prices := DefaultPrices
+	if priceTableFile != "" {
+		table, err := LoadPriceTable(priceTableFile)
+		if err != nil {
+			return nil, err
+		}
+		prices = table
+	}
+
+	tracker := NewTracker(redisClient, prices)
+	tracker.DefaultBudget = budget
+	return tracker, nil
+}
+
+// Record adds usage to the tenant's daily and monthly aggregates
+func (t *Tracker) Record(ctx context.Context, u Usage) error {
+	dailyKey, monthlyKey := t.keys(tenantID(ctx), t.now())
+	_, err := t.redisClient.RunScript(ctx, recordScript, []string{dailyKey, monthlyKey},
+		u.PromptTokens, u.CompletionTokens, strconv.FormatFloat(u.CostUSD, 'f', -1, 64), int(usageRetention.Seconds()))
+	return err
+}
+
+// Daily returns the usage aggregated for a tenant on the given day
+func (t *Tracker) Daily(ctx context.Context, tenantID string, day time.Time) (Usage, error) {
+	dailyKey, _ := t.keys(tenantID, day)
+	return t.load(ctx, dailyKey)
+}
+
+// Monthly returns the usage aggregated for a tenant in the month containing day
+func (t *Tracker) Monthly(ctx context.Context, tenantID string, day time.Time) (Usage, error) {
+	_, monthlyKey := t.keys(tenantID, day)
+	return t.load(ctx, monthlyKey)
+}
+
+// CheckBudget returns a BudgetExceededError if the tenant in ctx has no spend left
+func (t *Tracker) CheckBudget(ctx context.Context) error {
+	id := tenantID(ctx)
+	budget, ok := t.Budgets[id]
+	if !ok {
+		budget = t.DefaultBudget
+	}
+	if budget.DailyUSD <= 0 && budget.MonthlyUSD <= 0 {
+		return nil
+	}
+
+	now := t.now()
+	if budget.DailyUSD > 0 {
+		daily, err := t.Daily(ctx, id, now)
+		if err != nil {
+			return fmt.Errorf("failed to load usage: %w", err)
+		}
+		if daily.CostUSD >= budget.DailyUSD {
+			return &BudgetExceededError{TenantID: id, Period: "daily", SpentUSD: daily.CostUSD, LimitUSD: budget.DailyUSD}
+		}
+	}
+
+	if budget.MonthlyUSD > 0 {
+		monthly, err := t.Monthly(ctx, id, now)
+		if err != nil {
+			return fmt.Errorf("failed to load usage: %w", err)
+		}
+		if monthly.CostUSD >= budget.MonthlyUSD {
+			return &BudgetExceededError{TenantID: id, Period: "monthly", SpentUSD: monthly.CostUSD, LimitUSD: budget.MonthlyUSD}
+		}
+	}
+
+	return nil
+}
+
+// keys returns the daily and monthly aggregate keys for a tenant. The hash tag
+// keeps both in one cluster slot so the record script can update them together.
+func (t *Tracker) keys(tenantID string, at time.Time) (string, string) {
+	at = at.UTC()
+	return fmt.Sprintf("usage:{%s}:day:%s", tenantID, at.Format("2006-01-02")),
+		fmt.Sprintf("usage:{%s}:month:%s", tenantID, at.Format("2006-01"))
+}
+
+// load reads an aggregate hash into a Usage
+func (t *Tracker) load(ctx context.Context, key string) (Usage, error) {
+	fields, err := t.redisClient.HGetAll(ctx, key)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	var u Usage
+	u.PromptTokens, _ = strconv.Atoi(fields["prompt_tokens"])
+	u.CompletionTokens, _ = strconv.Atoi(fields["completion_tokens"])
+	u.CostUSD, _ = strconv.ParseFloat(fields["cost_usd"], 64)
+	u.Calls, _ = strconv.Atoi(fields["calls"])
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	return u, nil
+}
+
+// tenantID returns the tenant in ctx, or the anonymous bucket
+func tenantID(ctx context.Context) string {
+	if id := tenant.ID(ctx); id != "" {
+		return id
+	}
+	return anonymousTenant
+}
@@ -0,0 +1,143 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Usage records the tokens consumed by one or more model calls and their cost
+type Usage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	Calls            int     `json:"calls"`
+}
+
+// Add accumulates other into u
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.CostUSD += other.CostUSD
+	u.Calls += other.Calls
+}
+
+// Price is the cost of a model in USD per million tokens
+type Price struct {
+	PromptPerMillion     float64 `json:"prompt_per_million"`
+	CompletionPerMillion float64 `json:"completion_per_million"`
+}
+
+// PriceTable maps model names to their prices
+type PriceTable map[string]Price
+
+// DefaultPrices lists published list prices for the models we use
+var DefaultPrices = PriceTable{
+	"gpt-4o":        {PromptPerMillion: 2.50, CompletionPerMillion: 10.00},
+	"gpt-4o-mini":   {PromptPerMillion: 0.15, CompletionPerMillion: 0.60},
+	"gpt-4.1":       {PromptPerMillion: 2.00, CompletionPerMillion: 8.00},
+	"gpt-4.1-mini":  {PromptPerMillion: 0.40, CompletionPerMillion: 1.60},
+	"gpt-3.5-turbo": {PromptPerMillion: 0.50, CompletionPerMillion: 1.50},
+}
+
+// LoadPriceTable reads a JSON price table from path
+func LoadPriceTable(path string) (PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price table: %w", err)
+	}
+
+	var table PriceTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse price table: %w", err)
+	}
+
+	return table, nil
+}
+
+// Lookup returns the price for a model. Dated snapshots such as "gpt-4o-2024-08-06"
+// fall back to the longest matching model prefix.
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	if price, ok := t[model]; ok {
+		return price, true
+	}
+
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	for _, name := range names {
+		if strings.HasPrefix(model, name+"-") {
+			return t[name], true
+		}
+	}
+	return Price{}, false
+}
+
+// Cost computes the USD cost of a model call; unknown models cost nothing
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := t.Lookup(model)
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.PromptPerMillion + float64(completionTokens)*price.CompletionPerMillion) / 1_000_000
+}
+
+// Usage prices a single model call from its token counts
+func (t PriceTable) Usage(model string, promptTokens, completionTokens int) Usage {
+	return Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+		CostUSD:          t.Cost(model, promptTokens, completionTokens),
+		Calls:            1,
+	}
+}
+
+// Meter accumulates usage across every model call made while handling one request
+type Meter struct {
+	mu    sync.Mutex
+	total Usage
+}
+
+type meterKey struct{}
+
+// WithMeter returns a copy of ctx carrying a new meter, along with the meter
+func WithMeter(ctx context.Context) (context.Context, *Meter) {
+	meter := &Meter{}
+	return context.WithValue(ctx, meterKey{}, meter), meter
+}
+
+// MeterFromContext returns the meter carried by ctx, if any
+func MeterFromContext(ctx context.Context) *Meter {
+	meter, _ := ctx.Value(meterKey{}).(*Meter)
+	return meter
+}
+
+// Add records usage on the meter; it is safe to call on a nil meter
+func (m *Meter) Add(u Usage) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.total.Add(u)
+}
+
+// Total returns the usage accumulated so far
+func (m *Meter) Total() Usage {
+	if m == nil {
+		return Usage{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.total
+}
@@ -0,0 +1,152 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceTable_Cost(t *testing.T) {
+	prices := PriceTable{
+		"gpt-4o":      {PromptPerMillion: 2.50, CompletionPerMillion: 10.00},
+		"gpt-4o-mini": {PromptPerMillion: 0.15, CompletionPerMillion: 0.60},
+	}
+
+	assert.InDelta(t, 0.0075, prices.Cost("gpt-4o", 1000, 500), 1e-9)
+	assert.InDelta(t, 0.0075, prices.Cost("gpt-4o-2024-08-06", 1000, 500), 1e-9)
+	assert.InDelta(t, 0.00045, prices.Cost("gpt-4o-mini-2024-07-18", 1000, 500), 1e-9)
+	assert.Equal(t, 0.0, prices.Cost("llama3", 1000, 500))
+}
+
+func TestLoadPriceTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"local-model": {"prompt_per_million": 1, "completion_per_million": 2}}`), 0o600))
+
+	prices, err := LoadPriceTable(path)
+	require.NoError(t, err)
+	assert.InDelta(t, 3.0, prices.Cost("local-model", 1_000_000, 1_000_000), 1e-9)
+
+	_, err = LoadPriceTable(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestMeter_AccumulatesAcrossCalls(t *testing.T) {
+	ctx, meter := WithMeter(context.Background())
+
+	MeterFromContext(ctx).Add(Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, CostUSD: 0.01, Calls: 1})
+	MeterFromContext(ctx).Add(Usage{PromptTokens: 20, CompletionTokens: 5, TotalTokens: 25, CostUSD: 0.02, Calls: 1})
+
+	total := meter.Total()
+	assert.Equal(t, 30, total.PromptTokens)
+	assert.Equal(t, 40, total.TotalTokens)
+	assert.Equal(t, 2, total.Calls)
+	assert.InDelta(t, 0.03, total.CostUSD, 1e-9)
+
+	// Recording without a meter is a no-op
+	MeterFromContext(context.Background()).Add(Usage{Calls: 1})
+}
+
+func TestTracker_RecordAndBudget(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+
+	tracker := NewTracker(redisClient, DefaultPrices)
+	tracker.now = func() time.Time { return time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC) }
+	tracker.Budgets["acme"] = Budget{DailyUSD: 0.02}
+
+	acme := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"})
+	globex := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "globex"})
+
+	require.NoError(t, tracker.CheckBudget(acme))
+	require.NoError(t, tracker.Record(acme, DefaultPrices.Usage("gpt-4o", 4000, 1000)))
+
+	daily, err := tracker.Daily(acme, "acme", tracker.now())
+	require.NoError(t, err)
+	assert.Equal(t, 4000, daily.PromptTokens)
+	assert.Equal(t, 1000, daily.CompletionTokens)
+	assert.Equal(t, 1, daily.Calls)
+	assert.InDelta(t, 0.02, daily.CostUSD, 1e-9)
+
+	monthly, err := tracker.Monthly(acme, "acme", tracker.now().AddDate(0, 0, 3))
+	require.NoError(t, err)
+	assert.Equal(t, 5000, monthly.TotalTokens)
+
+	err = tracker.CheckBudget(acme)
+	var budgetErr *BudgetExceededError
+	require.True(t, errors.As(err, &budgetErr))
+	assert.Equal(t, "daily", budgetErr.Period)
+	assert.Contains(t, err.Error(), "daily spend budget of $0.02 exceeded for tenant acme")
+
+	// Tenants without a budget are unaffected
+	assert.NoError(t, tracker.CheckBudget(globex))
+}
+
+func TestTracker_MonthlyBudget(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisClient := cache.NewRedisClientWithAddr(mr.Addr())
+	defer redisClient.Close()
+
+	tracker := NewTracker(redisClient, DefaultPrices)
+	tracker.DefaultBudget = Budget{DailyUSD: 100, MonthlyUSD: 0.01}
+
+	require.NoError(t, tracker.Record(context.Background(), Usage{CostUSD: 0.01, Calls: 1}))
+
+	err := tracker.CheckBudget(context.Background())
+	var budgetErr *BudgetExceededError
+	require.True(t, errors.As(err, &budgetErr))
+	assert.Equal(t, "monthly", budgetErr.Period)
+	assert.Equal(t, anonymousTenant, budgetErr.TenantID)
+}
+
+func TestNewTrackerWithConfig(t *testing.T) {
+	tracker, err := NewTrackerWithConfig(nil, "", Budget{DailyUSD: 5, MonthlyUSD: 100})
+	require.NoError(t, err)
+	assert.Equal(t, Budget{DailyUSD: 5, MonthlyUSD: 100}, tracker.DefaultBudget)
+
+	_, err = NewTrackerWithConfig(nil, filepath.Join(t.TempDir(), "missing.json"), Budget{})
+	assert.Error(t, err)
+}
+
+func TestTracker_KeysShareClusterSlot(t *testing.T) {
+	tracker := NewTracker(nil, DefaultPrices)
+	at := time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC)
+
+	for _, id := range []string{"acme", "globex", "anonymous"} {
+		dailyKey, monthlyKey := tracker.keys(id, at)
+		assert.Equal(t, keySlot(dailyKey), keySlot(monthlyKey), "tenant %s", id)
+	}
+	assert.Equal(t, 12182, keySlot("foo"))
+}
+
+// keySlot returns the Redis Cluster slot of key, honouring {hash tags}
+func keySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return int(crc) % 16384
+}
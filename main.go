@@ -1,29 +1,184 @@
 package main
 
 import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/gin-gonic/gin"
 	"github.com/jesee-kuya/blue/handler"
+	"github.com/jesee-kuya/blue/internal/auth"
+	"github.com/jesee-kuya/blue/internal/breaker"
 	"github.com/jesee-kuya/blue/internal/cache"
+	"github.com/jesee-kuya/blue/internal/config"
+	"github.com/jesee-kuya/blue/internal/health"
+	"github.com/jesee-kuya/blue/internal/logging"
+	"github.com/jesee-kuya/blue/internal/metrics"
 	"github.com/jesee-kuya/blue/internal/middleware"
+	"github.com/jesee-kuya/blue/internal/openai"
+	"github.com/jesee-kuya/blue/internal/server"
+	"github.com/jesee-kuya/blue/internal/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
-	r := gin.Default()
+	// Set on fatal server errors; exiting in the first deferred call lets the
+	// others close Redis and flush traces first
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	// Structured logs are configured by LOG_FORMAT and LOG_LEVEL
+	if err := logging.Setup(); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+
+	// Traces are exported when OTEL_TRACES_EXPORTER is otlp or stdout
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Fatalf("Invalid tracing configuration: %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Warn("Failed to flush traces", logging.KeyError, err)
+		}
+	}()
+
+	// Settings come from CONFIG_FILE, when set, with environment overrides
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// The request ID middleware runs inside the request span so it can tag it
+	r := gin.New()
+	r.Use(
+		otelgin.Middleware(tracing.ServiceName()),
+		middleware.RequestIDMiddleware(),
+		middleware.LoggerMiddleware(),
+		gin.Recovery(),
+		middleware.MetricsMiddleware(),
+		middleware.BodyLimitMiddleware(cfg.Server.MaxBodyBytes),
+	)
 
-	// Initialize Redis client for rate limiting
-	redisClient := cache.NewRedisClient()
+	// One Redis client is shared by rate limiting, caches and stores
+	redisClient, err := cache.NewRedisClientWithOptions(cfg.RedisOptions())
+	if err != nil {
+		log.Fatalf("Invalid Redis configuration: %v", err)
+	}
 	defer redisClient.Close()
 
-	// Apply rate limiting middleware to protected routes
-	rateLimited := r.Group("/")
-	rateLimited.Use(middleware.RateLimitMiddleware(redisClient))
+	// API keys live in a JSON file when one is configured, otherwise in Redis
+	var keyStore auth.KeyStore = auth.NewRedisKeyStore(redisClient)
+	if path := cfg.Auth.APIKeysFile; path != "" {
+		fileStore, err := auth.NewFileKeyStore(path)
+		if err != nil {
+			log.Fatalf("Failed to load API keys: %v", err)
+		}
+		keyStore = fileStore
+	}
+	keys := auth.NewService(keyStore)
+
+	// The admin key bootstraps the first admin so further keys can be issued over the API
+	if adminKey := cfg.Auth.AdminAPIKey; adminKey != "" {
+		if _, err := keys.Import(context.Background(), adminKey, auth.CreateKeyRequest{TenantID: "admin", Name: "bootstrap", Admin: true}); err != nil {
+			slog.Warn("Failed to import bootstrap admin key", logging.KeyError, err)
+		}
+	}
+
+	// The orchestrator tracks model spend per tenant, optionally capped by budgets
+	aiClient, err := openai.NewClientFromConfig(cfg, redisClient)
+	if err != nil {
+		log.Fatalf("Invalid LLM configuration: %v", err)
+	}
+
+	// Share one limiter so the in-memory fallback covers every route group
+	limiter := middleware.NewRateLimiter(redisClient)
+	limiter.Plans = cfg.RateLimitPlans()
+
+	// Apply authentication and rate limiting middleware to protected routes
+	searchGroup := r.Group("/")
+	searchGroup.Use(middleware.AuthMiddleware(keys), middleware.RateLimitMiddleware(limiter, cfg.RateLimitPolicy(middleware.DefaultRateLimitPolicy.Name)))
 	{
-		rateLimited.GET("/search", handler.SearchHandler)
-		rateLimited.GET("/marketing", handler.MarketingHandler)
+		searchGroup.GET("/search", handler.SearchHandler)
 	}
 
-	// Health check without rate limiting
-	r.GET("/health", handler.HealthCheck)
+	// Marketing generation costs OpenAI tokens, so it gets a stricter policy
+	marketingGroup := r.Group("/")
+	marketingGroup.Use(
+		middleware.AuthMiddleware(keys),
+		middleware.RateLimitMiddleware(limiter, cfg.RateLimitPolicy(middleware.MarketingRateLimitPolicy.Name)),
+		middleware.BudgetMiddleware(aiClient.Usage),
+	)
+	{
+		marketingGroup.GET("/marketing", handler.MarketingHandler)
+	}
+
+	// Campaign history is scoped to the caller's tenant
+	if aiClient.Campaigns != nil {
+		defer aiClient.Campaigns.Close()
+
+		campaigns := r.Group("/campaigns")
+		campaigns.Use(middleware.AuthMiddleware(keys), middleware.RateLimitMiddleware(limiter, cfg.RateLimitPolicy(middleware.DefaultRateLimitPolicy.Name)))
+		{
+			campaigns.GET("", handler.ListCampaignsHandler(aiClient.Campaigns))
+			campaigns.GET("/:id", handler.GetCampaignHandler(aiClient.Campaigns))
+			campaigns.PATCH("/:id/status", handler.UpdateCampaignStatusHandler(aiClient.Campaigns))
+			campaigns.POST("/:id/duplicate", handler.DuplicateCampaignHandler(aiClient.Campaigns))
+			campaigns.DELETE("/:id", handler.DeleteCampaignHandler(aiClient.Campaigns))
+		}
+	}
+
+	// Key management is restricted to admin keys
+	admin := r.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(keys), middleware.RequireAdmin())
+	{
+		admin.GET("/keys", handler.ListAPIKeysHandler(keys))
+		admin.POST("/keys", handler.CreateAPIKeyHandler(keys))
+		admin.POST("/keys/:id/rotate", handler.RotateAPIKeyHandler(keys))
+		admin.DELETE("/keys/:id", handler.RevokeAPIKeyHandler(keys))
+	}
 
-	r.Run(":8080")
+	// Circuit breakers share their state through Redis when breakers.store is redis
+	breakers, err := breaker.NewGroupWithOptions(cfg.BreakerOptions(), redisClient, openai.Upstreams...)
+	if err != nil {
+		log.Fatalf("Invalid circuit breaker configuration: %v", err)
+	}
+
+	// The orchestrator shares the breakers reported by the health endpoints
+	aiClient.Breakers = breakers
+
+	// Redis is only critical when it holds the API keys; rate limits fall back to memory
+	checker := health.NewChecker(
+		health.PingCheck("redis", cfg.Auth.APIKeysFile == "", redisClient),
+		health.ConfigCheck("api_keys", true, keys),
+		health.UpstreamCheck(openai.UpstreamLLM, aiClient.Provider, breakers.Get(openai.UpstreamLLM)),
+		health.UpstreamCheck(openai.UpstreamAmazon, aiClient.AmazonClient, breakers.Get(openai.UpstreamAmazon)),
+		health.UpstreamCheck(openai.UpstreamEbay, aiClient.EbayClient, breakers.Get(openai.UpstreamEbay)),
+		health.UpstreamCheck(openai.UpstreamQloo, aiClient.QlooClient, breakers.Get(openai.UpstreamQloo)),
+	)
+
+	// Health checks without rate limiting
+	r.GET("/health", handler.HealthHandler(breakers))
+	r.GET("/health/live", handler.LivenessHandler)
+	r.GET("/health/ready", handler.ReadinessHandler(checker))
+
+	// Prometheus scrape endpoint
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// SIGTERM stops accepting connections and drains in-flight requests; Redis
+	// is closed and traces flushed by the deferred calls once they finish
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := server.New(cfg.Server, r).Run(ctx); err != nil {
+		slog.Error("Server error", logging.KeyError, err)
+		exitCode = 1
+	}
 }